@@ -28,13 +28,155 @@ type BackupStorageSpec struct {
 	// +optional
 	NFS *NFSStorageSpec `json:"nfs,omitempty"`
 
+	// Local contains configuration of a storage backed by a PersistentVolumeClaim
+	// +optional
+	Local *LocalStorageSpec `json:"local,omitempty"`
+
 	// CredentialsSecretRef references a secret containing storage credentials
 	// +optional
 	CredentialsSecretRef *corev1.LocalObjectReference `json:"credentialsSecretRef,omitempty"`
 
+	// WorkloadIdentity authenticates to the storage with a cloud identity bound to
+	// ServiceAccounts instead of the static keys of CredentialsSecretRef
+	// +optional
+	WorkloadIdentity *WorkloadIdentitySpec `json:"workloadIdentity,omitempty"`
+
 	// VerifyTLS indicates whether to verify TLS certificates
 	// +kubebuilder:default=true
 	VerifyTLS bool `json:"verifyTLS,omitempty"`
+
+	// Sync configures discovery of the backups already present in the storage
+	// +optional
+	Sync *BackupStorageSyncSpec `json:"sync,omitempty"`
+
+	// Encryption configures encryption of the backups written to the storage
+	// +optional
+	Encryption *BackupEncryptionSpec `json:"encryption,omitempty"`
+
+	// Replication copies completed backups and WAL archives to secondary storages
+	// +optional
+	Replication *BackupReplicationSpec `json:"replication,omitempty"`
+}
+
+// WorkloadIdentityProvider is the cloud mechanism binding ServiceAccounts to a cloud identity
+// +kubebuilder:validation:Enum=aws;gcp;azure
+type WorkloadIdentityProvider string
+
+const (
+	// WorkloadIdentityAWS uses IAM roles for service accounts (IRSA), for s3 storages
+	WorkloadIdentityAWS WorkloadIdentityProvider = "aws"
+	// WorkloadIdentityGCP uses GKE workload identity, for gcs storages
+	WorkloadIdentityGCP WorkloadIdentityProvider = "gcp"
+	// WorkloadIdentityAzure uses Azure workload identity, for azure storages
+	WorkloadIdentityAzure WorkloadIdentityProvider = "azure"
+)
+
+// WorkloadIdentitySpec defines the cloud identity used to access a storage.
+// The database pods and backup Jobs of the clusters using the storage run with a
+// ServiceAccount annotated for that identity, so no long-lived key is stored in a Secret.
+type WorkloadIdentitySpec struct {
+	// Provider is the workload identity mechanism of the cloud hosting the storage
+	// +kubebuilder:validation:Required
+	Provider WorkloadIdentityProvider `json:"provider"`
+
+	// RoleARN is the IAM role assumed with the aws provider
+	// +optional
+	RoleARN string `json:"roleARN,omitempty"`
+
+	// STSEndpoint overrides the STS endpoint the operator exchanges its token with
+	// when reading the storage with the aws provider
+	// +optional
+	STSEndpoint string `json:"stsEndpoint,omitempty"`
+
+	// GCPServiceAccount is the Google service account impersonated with the gcp provider
+	// +optional
+	GCPServiceAccount string `json:"gcpServiceAccount,omitempty"`
+
+	// ClientID is the client ID of the managed identity used with the azure provider
+	// +optional
+	ClientID string `json:"clientID,omitempty"`
+
+	// TenantID is the tenant of the managed identity used with the azure provider.
+	// Defaults to the tenant of the cluster.
+	// +optional
+	TenantID string `json:"tenantID,omitempty"`
+
+	// ServiceAccountName is the ServiceAccount created in the namespace of the storage
+	// for the Jobs of the storage itself, such as replication. Defaults to the storage name.
+	// +optional
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+}
+
+// BackupReplicationSpec defines asynchronous replication to secondary storages
+type BackupReplicationSpec struct {
+	// Secondaries are the BackupStorages receiving a copy of the storage content
+	// +kubebuilder:validation:MinItems=1
+	Secondaries []corev1.LocalObjectReference `json:"secondaries"`
+
+	// Interval is the time between two replication passes
+	// +kubebuilder:default="5m"
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+}
+
+// BackupEncryptionMode is the encryption mode of a backup
+// +kubebuilder:validation:Enum=sse-s3;sse-kms
+type BackupEncryptionMode string
+
+const (
+	// BackupEncryptionSSES3 uses server-side encryption with keys managed by the object store
+	BackupEncryptionSSES3 BackupEncryptionMode = "sse-s3"
+	// BackupEncryptionSSEKMS uses server-side encryption with a KMS key
+	BackupEncryptionSSEKMS BackupEncryptionMode = "sse-kms"
+)
+
+// BackupEncryptionSpec defines how backups are encrypted
+type BackupEncryptionSpec struct {
+	// Mode is the encryption mode, only supported by S3-compatible storages.
+	// +kubebuilder:validation:Required
+	Mode BackupEncryptionMode `json:"mode"`
+
+	// KMSKeyID is the KMS key ID or ARN base backups are encrypted with when using sse-kms.
+	// CNPG cannot pass a key to WAL archiving, which always uses the default KMS key of the bucket,
+	// so set the bucket default to the same key. When empty, the bucket default is used for both.
+	// +optional
+	KMSKeyID string `json:"kmsKeyID,omitempty"`
+}
+
+// BackupEncryptionKey identifies the key a backup was encrypted with
+type BackupEncryptionKey struct {
+	// Mode is the encryption mode
+	Mode BackupEncryptionMode `json:"mode"`
+
+	// KeyID identifies the key
+	// +optional
+	KeyID string `json:"keyID,omitempty"`
+}
+
+// EncryptionKeyRecord records the period during which a key was in use
+type EncryptionKeyRecord struct {
+	BackupEncryptionKey `json:",inline"`
+
+	// ActiveSince is when the key started being used for new backups
+	ActiveSince metav1.Time `json:"activeSince"`
+
+	// RetiredAt is when the key was replaced by another one
+	// +optional
+	RetiredAt *metav1.Time `json:"retiredAt,omitempty"`
+}
+
+// BackupStorageSyncSpec defines how backups in the storage are discovered
+type BackupStorageSyncSpec struct {
+	// Enabled enables periodic scanning of the storage layout.
+	// Every backup found is represented by a read-only DatabaseBackup.
+	// Only s3 storages can be scanned; for other types the Synced condition is False.
+	// +kubebuilder:default=false
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Interval is the time between two scans of the storage
+	// +kubebuilder:default="10m"
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
 }
 
 // S3StorageSpec defines S3-compatible storage configuration
@@ -93,6 +235,14 @@ type NFSStorageSpec struct {
 	Path string `json:"path"`
 }
 
+// LocalStorageSpec defines storage backed by a PersistentVolumeClaim
+type LocalStorageSpec struct {
+	// ClaimName is the name of the PersistentVolumeClaim in the namespace of the storage.
+	// It must support ReadWriteMany access when backups of several clusters run concurrently.
+	// +kubebuilder:validation:Required
+	ClaimName string `json:"claimName"`
+}
+
 // BackupStorageStatus defines the observed state of BackupStorage
 type BackupStorageStatus struct {
 	// Conditions represent the latest available observations of the storage's state
@@ -103,11 +253,49 @@ type BackupStorageStatus struct {
 	// +optional
 	Phase string `json:"phase,omitempty"`
 
+	// LastSyncTime is when the storage was last scanned for backups
+	// +optional
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+
+	// DiscoveredBackups is the number of backups found by the last scan
+	// +optional
+	DiscoveredBackups int32 `json:"discoveredBackups,omitempty"`
+
+	// Replication contains the replication state of each secondary storage
+	// +optional
+	Replication []SecondaryReplicationStatus `json:"replication,omitempty"`
+
+	// EncryptionKeys is the history of encryption keys, most recent last.
+	// Older backups still need the key that was active when they were taken.
+	// +optional
+	EncryptionKeys []EncryptionKeyRecord `json:"encryptionKeys,omitempty"`
+
 	// Message provides additional information about the current state
 	// +optional
 	Message string `json:"message,omitempty"`
 }
 
+// SecondaryReplicationStatus defines the replication state of a secondary storage
+type SecondaryReplicationStatus struct {
+	// Name is the name of the secondary BackupStorage
+	Name string `json:"name"`
+
+	// LastReplicationTime is when the last successful replication pass completed
+	// +optional
+	LastReplicationTime *metav1.Time `json:"lastReplicationTime,omitempty"`
+
+	// LastAttemptTime is when the last replication pass completed, successfully or not
+	// +optional
+	LastAttemptTime *metav1.Time `json:"lastAttemptTime,omitempty"`
+
+	// Healthy indicates if the last replication pass succeeded
+	Healthy bool `json:"healthy"`
+
+	// Message provides additional information about the last replication pass
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
 // +kubebuilder:resource:shortName=bs
@@ -0,0 +1,160 @@
+package v1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// BackupVerificationSpec defines the desired state of BackupVerification
+type BackupVerificationSpec struct {
+	// ClusterName is the name of the DatabaseCluster whose backups are verified.
+	// The cluster itself may no longer exist, e.g. for backups discovered in a BackupStorage.
+	// +kubebuilder:validation:Required
+	ClusterName string `json:"clusterName"`
+
+	// Schedule is the cron schedule for verification runs
+	// +kubebuilder:validation:Required
+	Schedule string `json:"schedule"`
+
+	// Suspend pauses scheduling of new verification runs
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+
+	// Resources specifies the compute resources for the temporary cluster
+	// +optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// Storage specifies the storage of the temporary cluster.
+	// Defaults to the storage of the source cluster.
+	// +optional
+	Storage *StorageSpec `json:"storage,omitempty"`
+
+	// Checks are run against the temporary cluster once the restore completes
+	// +optional
+	Checks []VerificationCheck `json:"checks,omitempty"`
+
+	// Timeout is the maximum duration of a verification run, restore included
+	// +kubebuilder:default="2h"
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// VerificationCheck defines a check run against a restored backup.
+// Exactly one of SQL or Command must be set.
+type VerificationCheck struct {
+	// Name identifies the check in the results
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+
+	// SQL is a query run with the engine client against the restored database
+	// +optional
+	SQL string `json:"sql,omitempty"`
+
+	// Command is run in a container with the engine client tools and connection environment
+	// +optional
+	Command []string `json:"command,omitempty"`
+
+	// Expected is the expected output of the check, compared after trimming whitespace.
+	// When empty, the check passes if it exits successfully.
+	// +optional
+	Expected string `json:"expected,omitempty"`
+}
+
+// BackupVerificationStatus defines the observed state of BackupVerification
+type BackupVerificationStatus struct {
+	// Conditions represent the latest available observations of the verification's state
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// Phase is the current phase of the verification run
+	// +optional
+	Phase BackupVerificationPhase `json:"phase,omitempty"`
+
+	// CurrentBackup is the DatabaseBackup being verified by the current run
+	// +optional
+	CurrentBackup string `json:"currentBackup,omitempty"`
+
+	// ScratchCluster is the temporary DatabaseCluster of the current run
+	// +optional
+	ScratchCluster string `json:"scratchCluster,omitempty"`
+
+	// RunStartTime is when the current run started
+	// +optional
+	RunStartTime *metav1.Time `json:"runStartTime,omitempty"`
+
+	// RestoreDuration is the measured restore time of the current run
+	// +optional
+	RestoreDuration *metav1.Duration `json:"restoreDuration,omitempty"`
+
+	// LastRunTime is when the last run finished
+	// +optional
+	LastRunTime *metav1.Time `json:"lastRunTime,omitempty"`
+
+	// LastResult is the result of the last run
+	// +optional
+	LastResult VerificationResult `json:"lastResult,omitempty"`
+
+	// LastVerifiedBackup is the DatabaseBackup verified by the last run
+	// +optional
+	LastVerifiedBackup string `json:"lastVerifiedBackup,omitempty"`
+
+	// NextRunTime is the scheduled time of the next run
+	// +optional
+	NextRunTime *metav1.Time `json:"nextRunTime,omitempty"`
+
+	// Message provides additional information about the current state
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// BackupVerificationPhase represents the current phase of a verification run
+// +kubebuilder:validation:Enum=Idle;Restoring;Checking;CleaningUp
+type BackupVerificationPhase string
+
+const (
+	BackupVerificationPhaseIdle       BackupVerificationPhase = "Idle"
+	BackupVerificationPhaseRestoring  BackupVerificationPhase = "Restoring"
+	BackupVerificationPhaseChecking   BackupVerificationPhase = "Checking"
+	BackupVerificationPhaseCleaningUp BackupVerificationPhase = "CleaningUp"
+)
+
+// VerificationResult represents the outcome of a backup verification
+// +kubebuilder:validation:Enum=Passed;Failed
+type VerificationResult string
+
+const (
+	VerificationResultPassed VerificationResult = "Passed"
+	VerificationResultFailed VerificationResult = "Failed"
+)
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:shortName=bv
+// +kubebuilder:printcolumn:name="Cluster",type=string,JSONPath=`.spec.clusterName`
+// +kubebuilder:printcolumn:name="Schedule",type=string,JSONPath=`.spec.schedule`
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Last Result",type=string,JSONPath=`.status.lastResult`
+// +kubebuilder:printcolumn:name="Last Run",type=date,JSONPath=`.status.lastRunTime`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// BackupVerification is the Schema for the backupverifications API
+type BackupVerification struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   BackupVerificationSpec   `json:"spec,omitempty"`
+	Status BackupVerificationStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// BackupVerificationList contains a list of BackupVerification
+type BackupVerificationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []BackupVerification `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&BackupVerification{}, &BackupVerificationList{})
+}
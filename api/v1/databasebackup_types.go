@@ -0,0 +1,213 @@
+package v1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DatabaseBackupSpec defines the desired state of DatabaseBackup
+type DatabaseBackupSpec struct {
+	// ClusterName is the name of the DatabaseCluster the backup was taken from
+	// +kubebuilder:validation:Required
+	ClusterName string `json:"clusterName"`
+
+	// EngineType is the database engine type of the backup
+	// +kubebuilder:validation:Enum=postgresql;mongodb;mysql;kafka
+	// +optional
+	EngineType string `json:"engineType,omitempty"`
+
+	// BackupStorageRef references the BackupStorage holding the backup
+	// +optional
+	BackupStorageRef *corev1.LocalObjectReference `json:"backupStorageRef,omitempty"`
+
+	// Method is the method used to take the backup
+	// +kubebuilder:default=objectStore
+	// +optional
+	Method BackupMethod `json:"method,omitempty"`
+
+	// ReadOnly marks a backup discovered in a BackupStorage.
+	// The operator never modifies or deletes the data of a read-only backup.
+	// +optional
+	ReadOnly bool `json:"readOnly,omitempty"`
+}
+
+// DatabaseBackupStatus defines the observed state of DatabaseBackup
+type DatabaseBackupStatus struct {
+	// Conditions represent the latest available observations of the backup's state
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// Phase is the current phase of the backup
+	// +optional
+	Phase DatabaseBackupPhase `json:"phase,omitempty"`
+
+	// BackupID is the provider-specific identifier of the backup
+	// +optional
+	BackupID string `json:"backupID,omitempty"`
+
+	// DestinationPath is the location of the backup within the storage
+	// +optional
+	DestinationPath string `json:"destinationPath,omitempty"`
+
+	// ServerName is the name the backup was archived under in the storage
+	// +optional
+	ServerName string `json:"serverName,omitempty"`
+
+	// EngineVersion is the database version the backup was taken with
+	// +optional
+	EngineVersion string `json:"engineVersion,omitempty"`
+
+	// StartedAt is when the backup started
+	// +optional
+	StartedAt *metav1.Time `json:"startedAt,omitempty"`
+
+	// CompletedAt is when the backup completed
+	// +optional
+	CompletedAt *metav1.Time `json:"completedAt,omitempty"`
+
+	// Size is the size of the backup
+	// +optional
+	Size *resource.Quantity `json:"size,omitempty"`
+
+	// Snapshots lists the VolumeSnapshots of a volumeSnapshot backup
+	// +optional
+	Snapshots []BackupSnapshot `json:"snapshots,omitempty"`
+
+	// Databases lists the databases dumped by a logical backup
+	// +optional
+	Databases []string `json:"databases,omitempty"`
+
+	// Replicas lists the secondary storages holding a copy of the backup
+	// +optional
+	Replicas []BackupReplica `json:"replicas,omitempty"`
+
+	// Encryption identifies the key needed to restore the backup
+	// +optional
+	Encryption *BackupEncryptionKey `json:"encryption,omitempty"`
+
+	// Verification contains the result of the last test restore of the backup
+	// +optional
+	Verification *BackupVerificationRecord `json:"verification,omitempty"`
+
+	// Message provides additional information about the current state
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// BackupReplica records the copy of a backup in a secondary storage
+type BackupReplica struct {
+	// StorageName is the name of the secondary BackupStorage
+	StorageName string `json:"storageName"`
+
+	// ReplicatedAt is when the copy was confirmed
+	ReplicatedAt metav1.Time `json:"replicatedAt"`
+
+	// Lag is the time between the completion of the backup and its replication
+	// +optional
+	Lag *metav1.Duration `json:"lag,omitempty"`
+}
+
+// BackupSnapshot references a VolumeSnapshot that is part of a backup
+type BackupSnapshot struct {
+	// Name is the name of the VolumeSnapshot
+	Name string `json:"name"`
+
+	// Type is the volume the snapshot was taken from
+	// +kubebuilder:validation:Enum=data;wal;tablespace
+	Type BackupSnapshotType `json:"type"`
+
+	// TablespaceName is the name of the tablespace, for tablespace snapshots
+	// +optional
+	TablespaceName string `json:"tablespaceName,omitempty"`
+}
+
+// BackupSnapshotType is the volume a snapshot was taken from
+type BackupSnapshotType string
+
+const (
+	BackupSnapshotTypeData       BackupSnapshotType = "data"
+	BackupSnapshotTypeWAL        BackupSnapshotType = "wal"
+	BackupSnapshotTypeTablespace BackupSnapshotType = "tablespace"
+)
+
+// BackupVerificationRecord records a test restore of a backup
+type BackupVerificationRecord struct {
+	// VerificationName is the BackupVerification that ran the test restore
+	VerificationName string `json:"verificationName"`
+
+	// Result is the outcome of the test restore and its checks
+	Result VerificationResult `json:"result"`
+
+	// VerifiedAt is when the verification finished
+	VerifiedAt metav1.Time `json:"verifiedAt"`
+
+	// RestoreDuration is the measured time until the restored cluster was ready
+	// +optional
+	RestoreDuration *metav1.Duration `json:"restoreDuration,omitempty"`
+
+	// Checks contains the result of each check
+	// +optional
+	Checks []VerificationCheckResult `json:"checks,omitempty"`
+
+	// Message provides additional details about the result
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// VerificationCheckResult contains the result of a single verification check
+type VerificationCheckResult struct {
+	// Name is the name of the check
+	Name string `json:"name"`
+
+	// Passed indicates if the check passed
+	Passed bool `json:"passed"`
+
+	// Output is the output of the check, truncated
+	// +optional
+	Output string `json:"output,omitempty"`
+}
+
+// DatabaseBackupPhase represents the current phase of the backup
+// +kubebuilder:validation:Enum=Pending;Running;Succeeded;Failed
+type DatabaseBackupPhase string
+
+const (
+	DatabaseBackupPhasePending   DatabaseBackupPhase = "Pending"
+	DatabaseBackupPhaseRunning   DatabaseBackupPhase = "Running"
+	DatabaseBackupPhaseSucceeded DatabaseBackupPhase = "Succeeded"
+	DatabaseBackupPhaseFailed    DatabaseBackupPhase = "Failed"
+)
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:shortName=dbb
+// +kubebuilder:printcolumn:name="Cluster",type=string,JSONPath=`.spec.clusterName`
+// +kubebuilder:printcolumn:name="Engine",type=string,JSONPath=`.spec.engineType`
+// +kubebuilder:printcolumn:name="Method",type=string,JSONPath=`.spec.method`
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Size",type=string,JSONPath=`.status.size`
+// +kubebuilder:printcolumn:name="Completed",type=date,JSONPath=`.status.completedAt`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// DatabaseBackup is the Schema for the databasebackups API
+type DatabaseBackup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   DatabaseBackupSpec   `json:"spec,omitempty"`
+	Status DatabaseBackupStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// DatabaseBackupList contains a list of DatabaseBackup
+type DatabaseBackupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []DatabaseBackup `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&DatabaseBackup{}, &DatabaseBackupList{})
+}
@@ -1,6 +1,7 @@
 package v1
 
 import (
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -33,6 +34,14 @@ type DatabaseClusterSpec struct {
 	// +optional
 	Monitoring *MonitoringSpec `json:"monitoring,omitempty"`
 
+	// Logging specifies the format, verbosity and destination of the database logs
+	// +optional
+	Logging *LoggingSpec `json:"logging,omitempty"`
+
+	// QueryInsights enables statement statistics and a summary of the slowest statements
+	// +optional
+	QueryInsights *QueryInsightsSpec `json:"queryInsights,omitempty"`
+
 	// Proxy specifies the proxy/load balancer configuration
 	// +optional
 	Proxy *ProxySpec `json:"proxy,omitempty"`
@@ -102,6 +111,143 @@ type BackupSpec struct {
 	// RetentionPolicy specifies how long to keep backups
 	// +optional
 	RetentionPolicy string `json:"retentionPolicy,omitempty"`
+
+	// Method is the method used for scheduled backups
+	// +kubebuilder:default=objectStore
+	// +optional
+	Method BackupMethod `json:"method,omitempty"`
+
+	// VolumeSnapshot configures backups taken as CSI VolumeSnapshots of the cluster's volumes.
+	// Required when the volumeSnapshot method is used by the schedule or by a backup OpsRequest.
+	// +optional
+	VolumeSnapshot *VolumeSnapshotBackupSpec `json:"volumeSnapshot,omitempty"`
+
+	// Logical configures the dumps taken by the logical method
+	// +optional
+	Logical *LogicalBackupSpec `json:"logical,omitempty"`
+
+	// GracePeriod is how long a scheduled backup may be late before the cluster
+	// reports it as missed. Defaults to one hour.
+	// +optional
+	GracePeriod *metav1.Duration `json:"gracePeriod,omitempty"`
+
+	// Hooks run around the backups taken through Backup OpsRequests,
+	// unless the OpsRequest sets its own hooks. Scheduled backups are taken without
+	// them, which the BackupHooksApplied condition reports.
+	// +optional
+	Hooks *BackupHooksSpec `json:"hooks,omitempty"`
+}
+
+// BackupMethod is the method used to take a backup
+// +kubebuilder:validation:Enum=objectStore;volumeSnapshot;logical
+type BackupMethod string
+
+const (
+	// BackupMethodObjectStore streams a physical backup to the BackupStorage
+	BackupMethodObjectStore BackupMethod = "objectStore"
+	// BackupMethodVolumeSnapshot takes CSI VolumeSnapshots of the cluster's volumes
+	BackupMethodVolumeSnapshot BackupMethod = "volumeSnapshot"
+	// BackupMethodLogical streams engine-specific dumps of selected databases to the BackupStorage
+	BackupMethodLogical BackupMethod = "logical"
+)
+
+// LogicalBackupSpec defines the content and compression of logical backups
+type LogicalBackupSpec struct {
+	// Databases lists the databases to dump, one dump per database.
+	// Defaults to the application database.
+	// +optional
+	Databases []string `json:"databases,omitempty"`
+
+	// Tables restricts the dumps to the matching tables, e.g. public.orders.
+	// All tables are dumped when empty.
+	// +optional
+	Tables []string `json:"tables,omitempty"`
+
+	// Compression is the compression applied to the dumps
+	// +kubebuilder:default=gzip
+	// +optional
+	Compression LogicalBackupCompression `json:"compression,omitempty"`
+
+	// CompressionLevel trades dump speed for size, from 1 (fastest) to 9 (smallest)
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=9
+	// +optional
+	CompressionLevel *int32 `json:"compressionLevel,omitempty"`
+}
+
+// BackupHooksSpec defines the hooks run around a backup
+type BackupHooksSpec struct {
+	// Pre hooks run in order before the backup is started
+	// +optional
+	Pre []BackupHook `json:"pre,omitempty"`
+
+	// Post hooks run in order once the backup has finished, whether it succeeded or not
+	// +optional
+	Post []BackupHook `json:"post,omitempty"`
+}
+
+// BackupHook is a SQL statement run on the primary or a Job run before or after a backup
+type BackupHook struct {
+	// Name identifies the hook in the action log
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+
+	// SQL is run with the engine client against the primary of the cluster
+	// +optional
+	SQL string `json:"sql,omitempty"`
+
+	// Job is the spec of a Job run as the hook. Its containers receive the CLUSTER_NAME,
+	// BACKUP_NAME and HOOK_PHASE environment variables.
+	// +optional
+	Job *batchv1.JobSpec `json:"job,omitempty"`
+
+	// TimeoutSeconds is how long the hook may run before it is considered failed
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=300
+	// +optional
+	TimeoutSeconds *int64 `json:"timeoutSeconds,omitempty"`
+
+	// OnFailure is the policy applied when the hook fails or times out.
+	// Abort fails the operation, before the backup is taken for pre hooks.
+	// +kubebuilder:default=Abort
+	// +optional
+	OnFailure BackupHookFailurePolicy `json:"onFailure,omitempty"`
+}
+
+// BackupHookFailurePolicy is the policy applied when a backup hook fails
+// +kubebuilder:validation:Enum=Abort;Continue
+type BackupHookFailurePolicy string
+
+const (
+	BackupHookFailurePolicyAbort    BackupHookFailurePolicy = "Abort"
+	BackupHookFailurePolicyContinue BackupHookFailurePolicy = "Continue"
+)
+
+// LogicalBackupCompression is the compression applied to logical backups
+// +kubebuilder:validation:Enum=none;gzip
+type LogicalBackupCompression string
+
+const (
+	LogicalBackupCompressionNone LogicalBackupCompression = "none"
+	LogicalBackupCompressionGzip LogicalBackupCompression = "gzip"
+)
+
+// VolumeSnapshotBackupSpec defines volume snapshot backup configuration
+type VolumeSnapshotBackupSpec struct {
+	// ClassName is the VolumeSnapshotClass used for the data volumes
+	// +kubebuilder:validation:Required
+	ClassName string `json:"className"`
+
+	// WALClassName is the VolumeSnapshotClass used for separate WAL volumes.
+	// Defaults to ClassName.
+	// +optional
+	WALClassName string `json:"walClassName,omitempty"`
+
+	// Online takes snapshots without stopping the instance.
+	// Offline snapshots fence the instance while the snapshot is taken.
+	// +kubebuilder:default=true
+	// +optional
+	Online *bool `json:"online,omitempty"`
 }
 
 // MonitoringSpec defines monitoring configuration
@@ -117,6 +263,51 @@ type MonitoringSpec struct {
 	// PMM specifies Percona Monitoring and Management configuration
 	// +optional
 	PMM *PMMSpec `json:"pmm,omitempty"`
+
+	// CustomQueries are exported as metrics next to the standard exporter metrics.
+	// They are added to those of the MonitoringConfig, replacing queries of the same name.
+	// +optional
+	CustomQueries []CustomQuery `json:"customQueries,omitempty"`
+}
+
+// CustomQuery is an SQL query whose result columns are exported as metrics
+type CustomQuery struct {
+	// Name prefixes the metrics of the query
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern=`^[a-zA-Z_][a-zA-Z0-9_]*$`
+	Name string `json:"name"`
+
+	// Query is a read-only SQL statement
+	// +kubebuilder:validation:Required
+	Query string `json:"query"`
+
+	// Databases are the databases the query runs in. Defaults to the application database.
+	// +optional
+	Databases []string `json:"databases,omitempty"`
+
+	// PrimaryOnly runs the query on the primary only
+	// +optional
+	PrimaryOnly bool `json:"primaryOnly,omitempty"`
+
+	// Metrics maps the columns of the result to metrics and labels
+	// +kubebuilder:validation:MinItems=1
+	Metrics []CustomQueryMetric `json:"metrics"`
+}
+
+// CustomQueryMetric maps a result column of a custom query
+type CustomQueryMetric struct {
+	// Column is the name of the result column
+	// +kubebuilder:validation:Required
+	Column string `json:"column"`
+
+	// Usage is how the column is exported
+	// +kubebuilder:validation:Enum=GAUGE;COUNTER;LABEL;DISCARD
+	// +kubebuilder:default=GAUGE
+	Usage string `json:"usage,omitempty"`
+
+	// Description is the help text of the metric
+	// +optional
+	Description string `json:"description,omitempty"`
 }
 
 // PMMSpec defines PMM configuration
@@ -133,6 +324,82 @@ type PMMSpec struct {
 	ServerPasswordSecretRef *corev1.SecretKeySelector `json:"serverPasswordSecretRef,omitempty"`
 }
 
+// LoggingSpec defines the database logs
+type LoggingSpec struct {
+	// Format is the format of the shipped records. PostgreSQL always writes csvlog under
+	// CloudNativePG, which prints every row wrapped in a JSON object: json ships that object,
+	// csv ships the csvlog columns of the row.
+	// +kubebuilder:validation:Enum=json;csv
+	// +kubebuilder:default=json
+	Format string `json:"format,omitempty"`
+
+	// Level is the minimum severity of the logged messages
+	// +kubebuilder:validation:Enum=debug;info;notice;warning;error
+	// +optional
+	Level string `json:"level,omitempty"`
+
+	// SlowQueryThreshold logs the statements running longer than this duration
+	// (log_min_duration_statement for PostgreSQL)
+	// +optional
+	SlowQueryThreshold *metav1.Duration `json:"slowQueryThreshold,omitempty"`
+
+	// Shipping forwards the logs to a Fluent Bit or OpenTelemetry endpoint. The collector
+	// pipeline is written to the <cluster>-log-shipping ConfigMap and tails the container logs
+	// of the nodes. The operator does not deploy or inject a collector yet, so the pipeline
+	// must be loaded by the node-level collector of the Kubernetes cluster.
+	// +optional
+	Shipping *LogShippingSpec `json:"shipping,omitempty"`
+}
+
+// LogShippingType is the kind of collector receiving the logs
+type LogShippingType string
+
+const (
+	// LogShippingOTel ships the logs to an OpenTelemetry collector over OTLP/gRPC
+	LogShippingOTel LogShippingType = "otel"
+
+	// LogShippingFluentBit ships the logs to a Fluent Bit or Fluentd forward input
+	LogShippingFluentBit LogShippingType = "fluentbit"
+)
+
+// LogShippingSpec defines where the logs are shipped
+type LogShippingSpec struct {
+	// Type is the kind of collector
+	// +kubebuilder:validation:Enum=otel;fluentbit
+	Type LogShippingType `json:"type"`
+
+	// Endpoint is the host:port of the collector
+	// +kubebuilder:validation:Required
+	Endpoint string `json:"endpoint"`
+
+	// Insecure disables TLS towards the collector
+	// +optional
+	Insecure bool `json:"insecure,omitempty"`
+
+	// Attributes are added to every log record
+	// +optional
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+// QueryInsightsSpec defines the collection of statement statistics
+// (pg_stat_statements for PostgreSQL, performance_schema for MySQL)
+type QueryInsightsSpec struct {
+	// Enabled loads the statement statistics extension of the engine
+	// +kubebuilder:default=false
+	Enabled bool `json:"enabled,omitempty"`
+
+	// TopN is the number of statements in the summary, 0 disables the summary
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=15
+	// +kubebuilder:default=10
+	// +optional
+	TopN int32 `json:"topN,omitempty"`
+
+	// RefreshInterval is how often the summary is refreshed, 15m by default
+	// +optional
+	RefreshInterval *metav1.Duration `json:"refreshInterval,omitempty"`
+}
+
 // ProxySpec defines proxy configuration
 type ProxySpec struct {
 	// Enabled enables or disables proxy
@@ -143,6 +410,32 @@ type ProxySpec struct {
 	// +optional
 	Type string `json:"type,omitempty"`
 
+	// ProxyInstanceSpec configures the read-write proxy, in front of the primary
+	ProxyInstanceSpec `json:",inline"`
+
+	// ReadOnly adds a read-only proxy in front of the replicas, configured on its own
+	// +optional
+	ReadOnly *ProxyInstanceSpec `json:"readOnly,omitempty"`
+
+	// QueryRules route the statements matching a pattern to the primary or the replicas,
+	// ahead of the built-in rules sending reads to the replicas (proxysql only)
+	// +optional
+	QueryRules []ProxyQueryRule `json:"queryRules,omitempty"`
+}
+
+// ProxyQueryRule routes statements by pattern
+type ProxyQueryRule struct {
+	// Pattern is a regular expression matched against the statements
+	// +kubebuilder:validation:Required
+	Pattern string `json:"pattern"`
+
+	// Destination is where the matching statements are sent
+	// +kubebuilder:validation:Enum=primary;replica
+	Destination string `json:"destination"`
+}
+
+// ProxyInstanceSpec defines a proxy deployment and its pool
+type ProxyInstanceSpec struct {
 	// Replicas is the number of proxy instances
 	// +kubebuilder:default=2
 	Replicas int32 `json:"replicas,omitempty"`
@@ -150,6 +443,32 @@ type ProxySpec struct {
 	// Resources specifies the compute resources for proxy instances
 	// +optional
 	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// PoolMode is when a server connection is given back to the pool (pgbouncer only)
+	// +kubebuilder:validation:Enum=session;transaction
+	// +optional
+	PoolMode string `json:"poolMode,omitempty"`
+
+	// DefaultPoolSize is the number of server connections per user and database for PgBouncer,
+	// per database server for HAProxy and ProxySQL
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	DefaultPoolSize *int32 `json:"defaultPoolSize,omitempty"`
+
+	// MaxClientConnections is the number of client connections each proxy instance accepts
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxClientConnections *int32 `json:"maxClientConnections,omitempty"`
+
+	// Parameters are passed to the proxy as is and take precedence over the settings above:
+	// pgbouncer.ini settings for PgBouncer, defaults section keywords for HAProxy and
+	// mysql_variables for ProxySQL
+	// +optional
+	Parameters map[string]string `json:"parameters,omitempty"`
+
+	// Expose specifies the service of the proxy, ClusterIP by default
+	// +optional
+	Expose *ExposeSpec `json:"expose,omitempty"`
 }
 
 // ExposeSpec defines how to expose the database
@@ -209,8 +528,23 @@ type DataSourceSpec struct {
 
 // BackupSourceSpec defines backup restoration source
 type BackupSourceSpec struct {
-	// BackupName is the name of the backup to restore from
+	// BackupName is the name of the DatabaseBackup or engine backup to restore from.
+	// When neither exists, it is the ID of a backup found in BackupStorageRef under ServerName.
 	BackupName string `json:"backupName"`
+
+	// BackupStorageRef overrides the storage the backup is read from,
+	// e.g. a secondary storage holding a replicated copy
+	// +optional
+	BackupStorageRef *corev1.LocalObjectReference `json:"backupStorageRef,omitempty"`
+
+	// ServerName is the name the backup was archived under in BackupStorageRef,
+	// required for backups that are not recorded in the cluster
+	// +optional
+	ServerName string `json:"serverName,omitempty"`
+
+	// PointInTime is the timestamp to recover to, replaying the archived WAL after the backup
+	// +optional
+	PointInTime *metav1.Time `json:"pointInTime,omitempty"`
 }
 
 // CloneSourceSpec defines cluster cloning source
@@ -249,6 +583,14 @@ type DatabaseClusterStatus struct {
 	// +optional
 	Monitoring *MonitoringStatus `json:"monitoring,omitempty"`
 
+	// QueryInsights contains the state of the slow statements summary
+	// +optional
+	QueryInsights *QueryInsightsStatus `json:"queryInsights,omitempty"`
+
+	// Restore records the latest in-place restore, whose backup the cluster is bootstrapped from
+	// +optional
+	Restore *RestoreStatus `json:"restore,omitempty"`
+
 	// ObservedGeneration is the generation observed by the controller
 	// +optional
 	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
@@ -258,6 +600,22 @@ type DatabaseClusterStatus struct {
 	Message string `json:"message,omitempty"`
 }
 
+// RestoreStatus records the backup an in-place restore recreated the cluster from
+type RestoreStatus struct {
+	// OpsRequest is the name of the Restore OpsRequest
+	OpsRequest string `json:"opsRequest"`
+
+	// BackupName is the name of the backup the cluster was restored from
+	BackupName string `json:"backupName"`
+
+	// PointInTime is the timestamp the cluster was recovered to
+	// +optional
+	PointInTime *metav1.Time `json:"pointInTime,omitempty"`
+
+	// StartedAt is when the cluster was deleted to be recreated from the backup
+	StartedAt metav1.Time `json:"startedAt"`
+}
+
 // ClusterPhase represents the current phase of the cluster
 // +kubebuilder:validation:Enum=Initializing;Ready;Updating;Failed;Deleting
 type ClusterPhase string
@@ -307,6 +665,14 @@ type DatabaseEndpoints struct {
 	// External is the external endpoint (if exposed)
 	// +optional
 	External string `json:"external,omitempty"`
+
+	// PoolerPrimary is the endpoint of the read-write proxy
+	// +optional
+	PoolerPrimary string `json:"poolerPrimary,omitempty"`
+
+	// PoolerReplica is the endpoint of the read-only proxy
+	// +optional
+	PoolerReplica string `json:"poolerReplica,omitempty"`
 }
 
 // ProxyStatus contains proxy status information
@@ -319,6 +685,15 @@ type ProxyStatus struct {
 
 	// ReadyReplicas is the number of ready replicas
 	ReadyReplicas int32 `json:"readyReplicas"`
+
+	// Endpoint is the service of the proxy
+	// +optional
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// ReadOnly contains the status of the read-only proxy, the fields above
+	// describing the read-write one
+	// +optional
+	ReadOnly *ProxyStatus `json:"readOnly,omitempty"`
 }
 
 // BackupStatus contains backup status information
@@ -334,6 +709,68 @@ type BackupStatus struct {
 	// LastBackupName is the name of the last backup
 	// +optional
 	LastBackupName string `json:"lastBackupName,omitempty"`
+
+	// FirstRecoverabilityPoint is the earliest point in time the cluster can be restored to
+	// +optional
+	FirstRecoverabilityPoint *metav1.Time `json:"firstRecoverabilityPoint,omitempty"`
+
+	// LastRecoverabilityPoint is the latest point in time the cluster can be restored to,
+	// the end of the last archived WAL file or of the last backup
+	// +optional
+	LastRecoverabilityPoint *metav1.Time `json:"lastRecoverabilityPoint,omitempty"`
+
+	// LastArchivedWAL is the name of the last WAL file archived to the backup storage
+	// +optional
+	LastArchivedWAL string `json:"lastArchivedWAL,omitempty"`
+
+	// LastArchivedWALTime is when the last WAL file was archived
+	// +optional
+	LastArchivedWALTime *metav1.Time `json:"lastArchivedWALTime,omitempty"`
+
+	// ArchivingHealthy indicates whether continuous WAL archiving is working,
+	// unset when the cluster does not archive WAL files
+	// +optional
+	ArchivingHealthy *bool `json:"archivingHealthy,omitempty"`
+
+	// ArchivingMessage explains why WAL archiving is failing
+	// +optional
+	ArchivingMessage string `json:"archivingMessage,omitempty"`
+
+	// LastFailedBackupTime is the timestamp of the last failed backup
+	// +optional
+	LastFailedBackupTime *metav1.Time `json:"lastFailedBackupTime,omitempty"`
+
+	// LastFailedBackupName is the name of the last failed backup
+	// +optional
+	LastFailedBackupName string `json:"lastFailedBackupName,omitempty"`
+
+	// SLO reports whether successful backups are taken as often as scheduled
+	// +optional
+	SLO *BackupSLOStatus `json:"slo,omitempty"`
+}
+
+// BackupSLOStatus compares the successful backups of a cluster with its schedule
+type BackupSLOStatus struct {
+	// Healthy is false once a scheduled backup is missed
+	Healthy bool `json:"healthy"`
+
+	// Deadline is when the next successful backup is due: the scheduled run
+	// following the last successful backup, plus the grace period
+	// +optional
+	Deadline *metav1.Time `json:"deadline,omitempty"`
+
+	// MissedBackups is the number of scheduled runs since the last successful
+	// backup that are past the grace period
+	// +optional
+	MissedBackups int32 `json:"missedBackups,omitempty"`
+
+	// LastBackupDuration is how long the last successful backup took
+	// +optional
+	LastBackupDuration *metav1.Duration `json:"lastBackupDuration,omitempty"`
+
+	// LastBackupSize is the size of the last successful backup
+	// +optional
+	LastBackupSize *resource.Quantity `json:"lastBackupSize,omitempty"`
 }
 
 // MonitoringStatus contains monitoring status information
@@ -347,6 +784,25 @@ type MonitoringStatus struct {
 	// Endpoint is the monitoring endpoint
 	// +optional
 	Endpoint string `json:"endpoint,omitempty"`
+
+	// Message explains why monitoring is not ready
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// QueryInsightsStatus contains the state of the slow statements summary
+type QueryInsightsStatus struct {
+	// ConfigMap is the name of the ConfigMap holding the summary
+	// +optional
+	ConfigMap string `json:"configMap,omitempty"`
+
+	// LastRefreshTime is when the summary was last refreshed
+	// +optional
+	LastRefreshTime *metav1.Time `json:"lastRefreshTime,omitempty"`
+
+	// Message explains why the last refresh failed
+	// +optional
+	Message string `json:"message,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -20,7 +20,30 @@ type MonitoringConfigSpec struct {
 	// +optional
 	Prometheus *PrometheusConfigSpec `json:"prometheus,omitempty"`
 
-	// CredentialsSecretRef references a secret containing monitoring credentials
+	// CustomQueries are exported as metrics by every cluster using the config
+	// +optional
+	CustomQueries []CustomQuery `json:"customQueries,omitempty"`
+
+	// Alerting generates alerting rules for every cluster using the config
+	// +optional
+	Alerting *AlertingSpec `json:"alerting,omitempty"`
+
+	// Grafana provisions dashboards for every cluster using the config
+	// +optional
+	Grafana *GrafanaSpec `json:"grafana,omitempty"`
+
+	// Datadog contains Datadog-specific configuration
+	// +optional
+	Datadog *DatadogConfigSpec `json:"datadog,omitempty"`
+
+	// NewRelic contains New Relic-specific configuration
+	// +optional
+	NewRelic *NewRelicConfigSpec `json:"newRelic,omitempty"`
+
+	// CredentialsSecretRef references a secret containing monitoring credentials.
+	// For pmm it holds the PMM server password under the "password" key. For datadog
+	// and newrelic it holds the "username" and "password" of the database user the
+	// agent connects with; the operator generates one per cluster when it is not set.
 	// +optional
 	CredentialsSecretRef *corev1.LocalObjectReference `json:"credentialsSecretRef,omitempty"`
 }
@@ -39,6 +62,11 @@ type PMMConfigSpec struct {
 	// +optional
 	ServerUser string `json:"serverUser,omitempty"`
 
+	// InsecureTLS skips the verification of the PMM server certificate, for servers
+	// using a self-signed certificate. The certificate is verified by default.
+	// +optional
+	InsecureTLS bool `json:"insecureTLS,omitempty"`
+
 	// Image is the PMM client image
 	// +optional
 	Image string `json:"image,omitempty"`
@@ -65,6 +93,155 @@ type PrometheusConfigSpec struct {
 	// AdditionalLabels are additional labels for ServiceMonitor
 	// +optional
 	AdditionalLabels map[string]string `json:"additionalLabels,omitempty"`
+
+	// PodMonitorEnabled scrapes the database pods directly with a PodMonitor
+	// instead of a ServiceMonitor
+	// +optional
+	PodMonitorEnabled bool `json:"podMonitorEnabled,omitempty"`
+
+	// Relabelings are applied to the targets before scraping
+	// +optional
+	Relabelings []RelabelConfig `json:"relabelings,omitempty"`
+
+	// MetricRelabelings are applied to the scraped samples before ingestion
+	// +optional
+	MetricRelabelings []RelabelConfig `json:"metricRelabelings,omitempty"`
+}
+
+// GrafanaSpec defines the dashboard ConfigMaps created for the Grafana sidecar
+type GrafanaSpec struct {
+	// Enabled enables the creation of the dashboard ConfigMaps
+	// +kubebuilder:default=true
+	Enabled bool `json:"enabled,omitempty"`
+
+	// URL is the external URL of Grafana, used to link the dashboards from the cluster status
+	// +optional
+	URL string `json:"url,omitempty"`
+
+	// DashboardLabel is the label the Grafana sidecar watches for
+	// +kubebuilder:default=grafana_dashboard
+	// +optional
+	DashboardLabel string `json:"dashboardLabel,omitempty"`
+
+	// DashboardLabelValue is the value of the dashboard label
+	// +kubebuilder:default="1"
+	// +optional
+	DashboardLabelValue string `json:"dashboardLabelValue,omitempty"`
+
+	// Folder is the Grafana folder of the dashboards, set in the grafana_folder annotation
+	// +optional
+	Folder string `json:"folder,omitempty"`
+}
+
+// AlertingSpec defines the PrometheusRule generated per cluster from the built-in rule catalog of its engine
+type AlertingSpec struct {
+	// Enabled enables the generation of the rules
+	// +kubebuilder:default=true
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Rules override or disable rules of the catalog, by name
+	// (InstanceDown, ReplicationLag, DiskNearlyFull, BackupStale, ConnectionsSaturated)
+	// +optional
+	Rules []AlertRuleOverride `json:"rules,omitempty"`
+
+	// AdditionalLabels are set on the PrometheusRule objects, to be selected by a Prometheus
+	// +optional
+	AdditionalLabels map[string]string `json:"additionalLabels,omitempty"`
+
+	// RoutingLabels are cluster labels copied onto the alerts, to route them to the owning team.
+	// Characters invalid in Prometheus label names are replaced with underscores.
+	// +optional
+	RoutingLabels []string `json:"routingLabels,omitempty"`
+}
+
+// AlertRuleOverride changes a rule of the catalog
+type AlertRuleOverride struct {
+	// Name is the name of the rule in the catalog
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+
+	// Disabled removes the rule
+	// +optional
+	Disabled bool `json:"disabled,omitempty"`
+
+	// Threshold replaces the threshold of the rule
+	// +optional
+	Threshold string `json:"threshold,omitempty"`
+
+	// For replaces how long the condition must hold before the alert fires
+	// +optional
+	For string `json:"for,omitempty"`
+
+	// Severity replaces the severity of the alert
+	// +kubebuilder:validation:Enum=critical;warning;info
+	// +optional
+	Severity string `json:"severity,omitempty"`
+}
+
+// DatadogConfigSpec defines the Datadog Autodiscovery check rendered on the database pods
+type DatadogConfigSpec struct {
+	// Tags are added to every metric of the check
+	// +optional
+	Tags []string `json:"tags,omitempty"`
+
+	// MinCollectionInterval is the interval between two runs of the check, in seconds
+	// +kubebuilder:default=15
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MinCollectionInterval int32 `json:"minCollectionInterval,omitempty"`
+
+	// DatabaseMonitoring enables Datadog Database Monitoring (query metrics and samples).
+	// The pg_monitor role of the agent user is not enough: the pg_stat_statements extension
+	// and a datadog.explain_statement function owned by a privileged role must be created in
+	// every monitored database, as described in the Datadog setup guide for PostgreSQL.
+	// +optional
+	DatabaseMonitoring bool `json:"databaseMonitoring,omitempty"`
+}
+
+// NewRelicConfigSpec defines the New Relic infrastructure integration rendered for the database pods
+type NewRelicConfigSpec struct {
+	// Interval is the interval between two runs of the integration
+	// +kubebuilder:default="30s"
+	// +optional
+	Interval string `json:"interval,omitempty"`
+
+	// CollectionList selects the databases and objects collected by the integration
+	// +kubebuilder:default="ALL"
+	// +optional
+	CollectionList string `json:"collectionList,omitempty"`
+
+	// Labels are added as custom attributes to every sample of the integration
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// RelabelConfig is a Prometheus relabeling rule
+type RelabelConfig struct {
+	// SourceLabels are the labels whose values are concatenated and matched against Regex
+	// +optional
+	SourceLabels []string `json:"sourceLabels,omitempty"`
+
+	// Separator joins the values of the source labels. Defaults to ';'.
+	// +optional
+	Separator string `json:"separator,omitempty"`
+
+	// TargetLabel is the label written by the replace action
+	// +optional
+	TargetLabel string `json:"targetLabel,omitempty"`
+
+	// Regex is matched against the joined source label values. Defaults to '(.*)'.
+	// +optional
+	Regex string `json:"regex,omitempty"`
+
+	// Replacement is the value written by the replace action. Defaults to '$1'.
+	// +optional
+	Replacement string `json:"replacement,omitempty"`
+
+	// Action is the relabeling action
+	// +kubebuilder:validation:Enum=replace;keep;drop;labelmap;labeldrop;labelkeep;hashmod;lowercase;uppercase
+	// +kubebuilder:default=replace
+	// +optional
+	Action string `json:"action,omitempty"`
 }
 
 // MonitoringConfigStatus defines the observed state of MonitoringConfig
@@ -118,6 +118,18 @@ type BackupRequestSpec struct {
 	// BackupName is the name for the backup
 	// +optional
 	BackupName string `json:"backupName,omitempty"`
+
+	// Method is the backup method. Defaults to the method of the cluster's backup configuration.
+	// +optional
+	Method BackupMethod `json:"method,omitempty"`
+
+	// Logical overrides the logical backup configuration of the cluster for this backup
+	// +optional
+	Logical *LogicalBackupSpec `json:"logical,omitempty"`
+
+	// Hooks replace the backup hooks of the cluster for this backup
+	// +optional
+	Hooks *BackupHooksSpec `json:"hooks,omitempty"`
 }
 
 // RestoreRequestSpec defines restore parameters
@@ -128,6 +140,48 @@ type RestoreRequestSpec struct {
 	// PointInTime is the timestamp to restore to (for PITR)
 	// +optional
 	PointInTime *metav1.Time `json:"pointInTime,omitempty"`
+
+	// TargetCluster restores into a new cluster created from the spec of the cluster,
+	// leaving the cluster itself untouched
+	// +optional
+	TargetCluster *RestoreTargetSpec `json:"targetCluster,omitempty"`
+
+	// InPlace confirms that the data of the cluster is replaced by the backup. It is required to
+	// restore a physical backup without a target cluster; the volumes of the cluster are
+	// snapshotted first and the snapshots kept until the restored cluster is ready.
+	// +optional
+	InPlace bool `json:"inPlace,omitempty"`
+
+	// Logical selects what is loaded from a logical backup. Everything in the backup is loaded when unset.
+	// +optional
+	Logical *LogicalRestoreSpec `json:"logical,omitempty"`
+}
+
+// LogicalRestoreSpec selects the content restored from a logical backup
+type LogicalRestoreSpec struct {
+	// Databases lists the databases to restore, all databases of the backup when empty
+	// +optional
+	Databases []string `json:"databases,omitempty"`
+
+	// Schemas restricts the restore to the given schemas
+	// +optional
+	Schemas []string `json:"schemas,omitempty"`
+
+	// Tables restricts the restore to the given tables
+	// +optional
+	Tables []string `json:"tables,omitempty"`
+}
+
+// RestoreTargetSpec defines the cluster created by an out-of-place restore
+type RestoreTargetSpec struct {
+	// Name is the name of the DatabaseCluster to create
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+
+	// SwapEndpoints repoints the services of the cluster to the restored cluster once it is ready,
+	// so that clients switch over without changing their connection settings
+	// +optional
+	SwapEndpoints bool `json:"swapEndpoints,omitempty"`
 }
 
 // SwitchoverSpec defines switchover parameters
@@ -21,6 +21,7 @@ limitations under the License.
 package v1
 
 import (
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
@@ -42,6 +43,53 @@ func (in *ActionLogEntry) DeepCopy() *ActionLogEntry {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AlertRuleOverride) DeepCopyInto(out *AlertRuleOverride) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AlertRuleOverride.
+func (in *AlertRuleOverride) DeepCopy() *AlertRuleOverride {
+	if in == nil {
+		return nil
+	}
+	out := new(AlertRuleOverride)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AlertingSpec) DeepCopyInto(out *AlertingSpec) {
+	*out = *in
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]AlertRuleOverride, len(*in))
+		copy(*out, *in)
+	}
+	if in.AdditionalLabels != nil {
+		in, out := &in.AdditionalLabels, &out.AdditionalLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.RoutingLabels != nil {
+		in, out := &in.RoutingLabels, &out.RoutingLabels
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AlertingSpec.
+func (in *AlertingSpec) DeepCopy() *AlertingSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AlertingSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AzureStorageSpec) DeepCopyInto(out *AzureStorageSpec) {
 	*out = *in
@@ -57,9 +105,149 @@ func (in *AzureStorageSpec) DeepCopy() *AzureStorageSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupEncryptionKey) DeepCopyInto(out *BackupEncryptionKey) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupEncryptionKey.
+func (in *BackupEncryptionKey) DeepCopy() *BackupEncryptionKey {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupEncryptionKey)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupEncryptionSpec) DeepCopyInto(out *BackupEncryptionSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupEncryptionSpec.
+func (in *BackupEncryptionSpec) DeepCopy() *BackupEncryptionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupEncryptionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupHook) DeepCopyInto(out *BackupHook) {
+	*out = *in
+	if in.Job != nil {
+		in, out := &in.Job, &out.Job
+		*out = new(batchv1.JobSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TimeoutSeconds != nil {
+		in, out := &in.TimeoutSeconds, &out.TimeoutSeconds
+		*out = new(int64)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupHook.
+func (in *BackupHook) DeepCopy() *BackupHook {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupHook)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupHooksSpec) DeepCopyInto(out *BackupHooksSpec) {
+	*out = *in
+	if in.Pre != nil {
+		in, out := &in.Pre, &out.Pre
+		*out = make([]BackupHook, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Post != nil {
+		in, out := &in.Post, &out.Post
+		*out = make([]BackupHook, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupHooksSpec.
+func (in *BackupHooksSpec) DeepCopy() *BackupHooksSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupHooksSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupReplica) DeepCopyInto(out *BackupReplica) {
+	*out = *in
+	in.ReplicatedAt.DeepCopyInto(&out.ReplicatedAt)
+	if in.Lag != nil {
+		in, out := &in.Lag, &out.Lag
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupReplica.
+func (in *BackupReplica) DeepCopy() *BackupReplica {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupReplica)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupReplicationSpec) DeepCopyInto(out *BackupReplicationSpec) {
+	*out = *in
+	if in.Secondaries != nil {
+		in, out := &in.Secondaries, &out.Secondaries
+		*out = make([]corev1.LocalObjectReference, len(*in))
+		copy(*out, *in)
+	}
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupReplicationSpec.
+func (in *BackupReplicationSpec) DeepCopy() *BackupReplicationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupReplicationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BackupRequestSpec) DeepCopyInto(out *BackupRequestSpec) {
 	*out = *in
+	if in.Logical != nil {
+		in, out := &in.Logical, &out.Logical
+		*out = new(LogicalBackupSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Hooks != nil {
+		in, out := &in.Hooks, &out.Hooks
+		*out = new(BackupHooksSpec)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupRequestSpec.
@@ -72,9 +260,62 @@ func (in *BackupRequestSpec) DeepCopy() *BackupRequestSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupSLOStatus) DeepCopyInto(out *BackupSLOStatus) {
+	*out = *in
+	if in.Deadline != nil {
+		in, out := &in.Deadline, &out.Deadline
+		*out = (*in).DeepCopy()
+	}
+	if in.LastBackupDuration != nil {
+		in, out := &in.LastBackupDuration, &out.LastBackupDuration
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.LastBackupSize != nil {
+		in, out := &in.LastBackupSize, &out.LastBackupSize
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupSLOStatus.
+func (in *BackupSLOStatus) DeepCopy() *BackupSLOStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupSLOStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupSnapshot) DeepCopyInto(out *BackupSnapshot) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupSnapshot.
+func (in *BackupSnapshot) DeepCopy() *BackupSnapshot {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupSnapshot)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BackupSourceSpec) DeepCopyInto(out *BackupSourceSpec) {
 	*out = *in
+	if in.BackupStorageRef != nil {
+		in, out := &in.BackupStorageRef, &out.BackupStorageRef
+		*out = new(corev1.LocalObjectReference)
+		**out = **in
+	}
+	if in.PointInTime != nil {
+		in, out := &in.PointInTime, &out.PointInTime
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupSourceSpec.
@@ -95,6 +336,26 @@ func (in *BackupSpec) DeepCopyInto(out *BackupSpec) {
 		*out = new(corev1.LocalObjectReference)
 		**out = **in
 	}
+	if in.VolumeSnapshot != nil {
+		in, out := &in.VolumeSnapshot, &out.VolumeSnapshot
+		*out = new(VolumeSnapshotBackupSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Logical != nil {
+		in, out := &in.Logical, &out.Logical
+		*out = new(LogicalBackupSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.GracePeriod != nil {
+		in, out := &in.GracePeriod, &out.GracePeriod
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.Hooks != nil {
+		in, out := &in.Hooks, &out.Hooks
+		*out = new(BackupHooksSpec)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupSpec.
@@ -114,24 +375,512 @@ func (in *BackupStatus) DeepCopyInto(out *BackupStatus) {
 		in, out := &in.LastBackupTime, &out.LastBackupTime
 		*out = (*in).DeepCopy()
 	}
-	if in.NextBackupTime != nil {
-		in, out := &in.NextBackupTime, &out.NextBackupTime
-		*out = (*in).DeepCopy()
+	if in.NextBackupTime != nil {
+		in, out := &in.NextBackupTime, &out.NextBackupTime
+		*out = (*in).DeepCopy()
+	}
+	if in.FirstRecoverabilityPoint != nil {
+		in, out := &in.FirstRecoverabilityPoint, &out.FirstRecoverabilityPoint
+		*out = (*in).DeepCopy()
+	}
+	if in.LastRecoverabilityPoint != nil {
+		in, out := &in.LastRecoverabilityPoint, &out.LastRecoverabilityPoint
+		*out = (*in).DeepCopy()
+	}
+	if in.LastArchivedWALTime != nil {
+		in, out := &in.LastArchivedWALTime, &out.LastArchivedWALTime
+		*out = (*in).DeepCopy()
+	}
+	if in.ArchivingHealthy != nil {
+		in, out := &in.ArchivingHealthy, &out.ArchivingHealthy
+		*out = new(bool)
+		**out = **in
+	}
+	if in.LastFailedBackupTime != nil {
+		in, out := &in.LastFailedBackupTime, &out.LastFailedBackupTime
+		*out = (*in).DeepCopy()
+	}
+	if in.SLO != nil {
+		in, out := &in.SLO, &out.SLO
+		*out = new(BackupSLOStatus)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupStatus.
+func (in *BackupStatus) DeepCopy() *BackupStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupStorage) DeepCopyInto(out *BackupStorage) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupStorage.
+func (in *BackupStorage) DeepCopy() *BackupStorage {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupStorage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *BackupStorage) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupStorageList) DeepCopyInto(out *BackupStorageList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]BackupStorage, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupStorageList.
+func (in *BackupStorageList) DeepCopy() *BackupStorageList {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupStorageList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *BackupStorageList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupStorageSpec) DeepCopyInto(out *BackupStorageSpec) {
+	*out = *in
+	if in.S3 != nil {
+		in, out := &in.S3, &out.S3
+		*out = new(S3StorageSpec)
+		**out = **in
+	}
+	if in.GCS != nil {
+		in, out := &in.GCS, &out.GCS
+		*out = new(GCSStorageSpec)
+		**out = **in
+	}
+	if in.Azure != nil {
+		in, out := &in.Azure, &out.Azure
+		*out = new(AzureStorageSpec)
+		**out = **in
+	}
+	if in.NFS != nil {
+		in, out := &in.NFS, &out.NFS
+		*out = new(NFSStorageSpec)
+		**out = **in
+	}
+	if in.Local != nil {
+		in, out := &in.Local, &out.Local
+		*out = new(LocalStorageSpec)
+		**out = **in
+	}
+	if in.CredentialsSecretRef != nil {
+		in, out := &in.CredentialsSecretRef, &out.CredentialsSecretRef
+		*out = new(corev1.LocalObjectReference)
+		**out = **in
+	}
+	if in.WorkloadIdentity != nil {
+		in, out := &in.WorkloadIdentity, &out.WorkloadIdentity
+		*out = new(WorkloadIdentitySpec)
+		**out = **in
+	}
+	if in.Sync != nil {
+		in, out := &in.Sync, &out.Sync
+		*out = new(BackupStorageSyncSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Encryption != nil {
+		in, out := &in.Encryption, &out.Encryption
+		*out = new(BackupEncryptionSpec)
+		**out = **in
+	}
+	if in.Replication != nil {
+		in, out := &in.Replication, &out.Replication
+		*out = new(BackupReplicationSpec)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupStorageSpec.
+func (in *BackupStorageSpec) DeepCopy() *BackupStorageSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupStorageSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupStorageStatus) DeepCopyInto(out *BackupStorageStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastSyncTime != nil {
+		in, out := &in.LastSyncTime, &out.LastSyncTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Replication != nil {
+		in, out := &in.Replication, &out.Replication
+		*out = make([]SecondaryReplicationStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.EncryptionKeys != nil {
+		in, out := &in.EncryptionKeys, &out.EncryptionKeys
+		*out = make([]EncryptionKeyRecord, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupStorageStatus.
+func (in *BackupStorageStatus) DeepCopy() *BackupStorageStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupStorageStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupStorageSyncSpec) DeepCopyInto(out *BackupStorageSyncSpec) {
+	*out = *in
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupStorageSyncSpec.
+func (in *BackupStorageSyncSpec) DeepCopy() *BackupStorageSyncSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupStorageSyncSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupVerification) DeepCopyInto(out *BackupVerification) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupVerification.
+func (in *BackupVerification) DeepCopy() *BackupVerification {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupVerification)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *BackupVerification) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupVerificationList) DeepCopyInto(out *BackupVerificationList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]BackupVerification, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupVerificationList.
+func (in *BackupVerificationList) DeepCopy() *BackupVerificationList {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupVerificationList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *BackupVerificationList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupVerificationRecord) DeepCopyInto(out *BackupVerificationRecord) {
+	*out = *in
+	in.VerifiedAt.DeepCopyInto(&out.VerifiedAt)
+	if in.RestoreDuration != nil {
+		in, out := &in.RestoreDuration, &out.RestoreDuration
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.Checks != nil {
+		in, out := &in.Checks, &out.Checks
+		*out = make([]VerificationCheckResult, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupVerificationRecord.
+func (in *BackupVerificationRecord) DeepCopy() *BackupVerificationRecord {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupVerificationRecord)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupVerificationSpec) DeepCopyInto(out *BackupVerificationSpec) {
+	*out = *in
+	in.Resources.DeepCopyInto(&out.Resources)
+	if in.Storage != nil {
+		in, out := &in.Storage, &out.Storage
+		*out = new(StorageSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Checks != nil {
+		in, out := &in.Checks, &out.Checks
+		*out = make([]VerificationCheck, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupVerificationSpec.
+func (in *BackupVerificationSpec) DeepCopy() *BackupVerificationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupVerificationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupVerificationStatus) DeepCopyInto(out *BackupVerificationStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.RunStartTime != nil {
+		in, out := &in.RunStartTime, &out.RunStartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.RestoreDuration != nil {
+		in, out := &in.RestoreDuration, &out.RestoreDuration
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.LastRunTime != nil {
+		in, out := &in.LastRunTime, &out.LastRunTime
+		*out = (*in).DeepCopy()
+	}
+	if in.NextRunTime != nil {
+		in, out := &in.NextRunTime, &out.NextRunTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupVerificationStatus.
+func (in *BackupVerificationStatus) DeepCopy() *BackupVerificationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupVerificationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloneSourceSpec) DeepCopyInto(out *CloneSourceSpec) {
+	*out = *in
+	if in.Timestamp != nil {
+		in, out := &in.Timestamp, &out.Timestamp
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloneSourceSpec.
+func (in *CloneSourceSpec) DeepCopy() *CloneSourceSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CloneSourceSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConfigParameter) DeepCopyInto(out *ConfigParameter) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigParameter.
+func (in *ConfigParameter) DeepCopy() *ConfigParameter {
+	if in == nil {
+		return nil
+	}
+	out := new(ConfigParameter)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CustomOperationSpec) DeepCopyInto(out *CustomOperationSpec) {
+	*out = *in
+	if in.Parameters != nil {
+		in, out := &in.Parameters, &out.Parameters
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomOperationSpec.
+func (in *CustomOperationSpec) DeepCopy() *CustomOperationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CustomOperationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CustomQuery) DeepCopyInto(out *CustomQuery) {
+	*out = *in
+	if in.Databases != nil {
+		in, out := &in.Databases, &out.Databases
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Metrics != nil {
+		in, out := &in.Metrics, &out.Metrics
+		*out = make([]CustomQueryMetric, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomQuery.
+func (in *CustomQuery) DeepCopy() *CustomQuery {
+	if in == nil {
+		return nil
+	}
+	out := new(CustomQuery)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CustomQueryMetric) DeepCopyInto(out *CustomQueryMetric) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomQueryMetric.
+func (in *CustomQueryMetric) DeepCopy() *CustomQueryMetric {
+	if in == nil {
+		return nil
+	}
+	out := new(CustomQueryMetric)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DataSourceSpec) DeepCopyInto(out *DataSourceSpec) {
+	*out = *in
+	if in.BackupSource != nil {
+		in, out := &in.BackupSource, &out.BackupSource
+		*out = new(BackupSourceSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CloneSource != nil {
+		in, out := &in.CloneSource, &out.CloneSource
+		*out = new(CloneSourceSpec)
+		(*in).DeepCopyInto(*out)
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupStatus.
-func (in *BackupStatus) DeepCopy() *BackupStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataSourceSpec.
+func (in *DataSourceSpec) DeepCopy() *DataSourceSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(BackupStatus)
+	out := new(DataSourceSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *BackupStorage) DeepCopyInto(out *BackupStorage) {
+func (in *DatabaseBackup) DeepCopyInto(out *DatabaseBackup) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
@@ -139,18 +888,18 @@ func (in *BackupStorage) DeepCopyInto(out *BackupStorage) {
 	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupStorage.
-func (in *BackupStorage) DeepCopy() *BackupStorage {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatabaseBackup.
+func (in *DatabaseBackup) DeepCopy() *DatabaseBackup {
 	if in == nil {
 		return nil
 	}
-	out := new(BackupStorage)
+	out := new(DatabaseBackup)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *BackupStorage) DeepCopyObject() runtime.Object {
+func (in *DatabaseBackup) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -158,31 +907,31 @@ func (in *BackupStorage) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *BackupStorageList) DeepCopyInto(out *BackupStorageList) {
+func (in *DatabaseBackupList) DeepCopyInto(out *DatabaseBackupList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]BackupStorage, len(*in))
+		*out = make([]DatabaseBackup, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupStorageList.
-func (in *BackupStorageList) DeepCopy() *BackupStorageList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatabaseBackupList.
+func (in *DatabaseBackupList) DeepCopy() *DatabaseBackupList {
 	if in == nil {
 		return nil
 	}
-	out := new(BackupStorageList)
+	out := new(DatabaseBackupList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *BackupStorageList) DeepCopyObject() runtime.Object {
+func (in *DatabaseBackupList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -190,47 +939,27 @@ func (in *BackupStorageList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *BackupStorageSpec) DeepCopyInto(out *BackupStorageSpec) {
+func (in *DatabaseBackupSpec) DeepCopyInto(out *DatabaseBackupSpec) {
 	*out = *in
-	if in.S3 != nil {
-		in, out := &in.S3, &out.S3
-		*out = new(S3StorageSpec)
-		**out = **in
-	}
-	if in.GCS != nil {
-		in, out := &in.GCS, &out.GCS
-		*out = new(GCSStorageSpec)
-		**out = **in
-	}
-	if in.Azure != nil {
-		in, out := &in.Azure, &out.Azure
-		*out = new(AzureStorageSpec)
-		**out = **in
-	}
-	if in.NFS != nil {
-		in, out := &in.NFS, &out.NFS
-		*out = new(NFSStorageSpec)
-		**out = **in
-	}
-	if in.CredentialsSecretRef != nil {
-		in, out := &in.CredentialsSecretRef, &out.CredentialsSecretRef
+	if in.BackupStorageRef != nil {
+		in, out := &in.BackupStorageRef, &out.BackupStorageRef
 		*out = new(corev1.LocalObjectReference)
 		**out = **in
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupStorageSpec.
-func (in *BackupStorageSpec) DeepCopy() *BackupStorageSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatabaseBackupSpec.
+func (in *DatabaseBackupSpec) DeepCopy() *DatabaseBackupSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(BackupStorageSpec)
+	out := new(DatabaseBackupSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *BackupStorageStatus) DeepCopyInto(out *BackupStorageStatus) {
+func (in *DatabaseBackupStatus) DeepCopyInto(out *DatabaseBackupStatus) {
 	*out = *in
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
@@ -239,95 +968,54 @@ func (in *BackupStorageStatus) DeepCopyInto(out *BackupStorageStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
-}
-
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupStorageStatus.
-func (in *BackupStorageStatus) DeepCopy() *BackupStorageStatus {
-	if in == nil {
-		return nil
+	if in.StartedAt != nil {
+		in, out := &in.StartedAt, &out.StartedAt
+		*out = (*in).DeepCopy()
 	}
-	out := new(BackupStorageStatus)
-	in.DeepCopyInto(out)
-	return out
-}
-
-// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CloneSourceSpec) DeepCopyInto(out *CloneSourceSpec) {
-	*out = *in
-	if in.Timestamp != nil {
-		in, out := &in.Timestamp, &out.Timestamp
+	if in.CompletedAt != nil {
+		in, out := &in.CompletedAt, &out.CompletedAt
 		*out = (*in).DeepCopy()
 	}
-}
-
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloneSourceSpec.
-func (in *CloneSourceSpec) DeepCopy() *CloneSourceSpec {
-	if in == nil {
-		return nil
+	if in.Size != nil {
+		in, out := &in.Size, &out.Size
+		x := (*in).DeepCopy()
+		*out = &x
 	}
-	out := new(CloneSourceSpec)
-	in.DeepCopyInto(out)
-	return out
-}
-
-// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ConfigParameter) DeepCopyInto(out *ConfigParameter) {
-	*out = *in
-}
-
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigParameter.
-func (in *ConfigParameter) DeepCopy() *ConfigParameter {
-	if in == nil {
-		return nil
+	if in.Snapshots != nil {
+		in, out := &in.Snapshots, &out.Snapshots
+		*out = make([]BackupSnapshot, len(*in))
+		copy(*out, *in)
 	}
-	out := new(ConfigParameter)
-	in.DeepCopyInto(out)
-	return out
-}
-
-// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CustomOperationSpec) DeepCopyInto(out *CustomOperationSpec) {
-	*out = *in
-	if in.Parameters != nil {
-		in, out := &in.Parameters, &out.Parameters
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
+	if in.Databases != nil {
+		in, out := &in.Databases, &out.Databases
+		*out = make([]string, len(*in))
+		copy(*out, *in)
 	}
-}
-
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomOperationSpec.
-func (in *CustomOperationSpec) DeepCopy() *CustomOperationSpec {
-	if in == nil {
-		return nil
+	if in.Replicas != nil {
+		in, out := &in.Replicas, &out.Replicas
+		*out = make([]BackupReplica, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
-	out := new(CustomOperationSpec)
-	in.DeepCopyInto(out)
-	return out
-}
-
-// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *DataSourceSpec) DeepCopyInto(out *DataSourceSpec) {
-	*out = *in
-	if in.BackupSource != nil {
-		in, out := &in.BackupSource, &out.BackupSource
-		*out = new(BackupSourceSpec)
+	if in.Encryption != nil {
+		in, out := &in.Encryption, &out.Encryption
+		*out = new(BackupEncryptionKey)
 		**out = **in
 	}
-	if in.CloneSource != nil {
-		in, out := &in.CloneSource, &out.CloneSource
-		*out = new(CloneSourceSpec)
+	if in.Verification != nil {
+		in, out := &in.Verification, &out.Verification
+		*out = new(BackupVerificationRecord)
 		(*in).DeepCopyInto(*out)
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataSourceSpec.
-func (in *DataSourceSpec) DeepCopy() *DataSourceSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatabaseBackupStatus.
+func (in *DatabaseBackupStatus) DeepCopy() *DatabaseBackupStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(DataSourceSpec)
+	out := new(DatabaseBackupStatus)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -407,6 +1095,16 @@ func (in *DatabaseClusterSpec) DeepCopyInto(out *DatabaseClusterSpec) {
 		*out = new(MonitoringSpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Logging != nil {
+		in, out := &in.Logging, &out.Logging
+		*out = new(LoggingSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.QueryInsights != nil {
+		in, out := &in.QueryInsights, &out.QueryInsights
+		*out = new(QueryInsightsSpec)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Proxy != nil {
 		in, out := &in.Proxy, &out.Proxy
 		*out = new(ProxySpec)
@@ -462,7 +1160,7 @@ func (in *DatabaseClusterStatus) DeepCopyInto(out *DatabaseClusterStatus) {
 	if in.Proxy != nil {
 		in, out := &in.Proxy, &out.Proxy
 		*out = new(ProxyStatus)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.Backup != nil {
 		in, out := &in.Backup, &out.Backup
@@ -474,6 +1172,16 @@ func (in *DatabaseClusterStatus) DeepCopyInto(out *DatabaseClusterStatus) {
 		*out = new(MonitoringStatus)
 		**out = **in
 	}
+	if in.QueryInsights != nil {
+		in, out := &in.QueryInsights, &out.QueryInsights
+		*out = new(QueryInsightsStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Restore != nil {
+		in, out := &in.Restore, &out.Restore
+		*out = new(RestoreStatus)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatabaseClusterStatus.
@@ -635,6 +1343,47 @@ func (in *DatabaseStatus) DeepCopy() *DatabaseStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DatadogConfigSpec) DeepCopyInto(out *DatadogConfigSpec) {
+	*out = *in
+	if in.Tags != nil {
+		in, out := &in.Tags, &out.Tags
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatadogConfigSpec.
+func (in *DatadogConfigSpec) DeepCopy() *DatadogConfigSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DatadogConfigSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EncryptionKeyRecord) DeepCopyInto(out *EncryptionKeyRecord) {
+	*out = *in
+	out.BackupEncryptionKey = in.BackupEncryptionKey
+	in.ActiveSince.DeepCopyInto(&out.ActiveSince)
+	if in.RetiredAt != nil {
+		in, out := &in.RetiredAt, &out.RetiredAt
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EncryptionKeyRecord.
+func (in *EncryptionKeyRecord) DeepCopy() *EncryptionKeyRecord {
+	if in == nil {
+		return nil
+	}
+	out := new(EncryptionKeyRecord)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EngineFeatures) DeepCopyInto(out *EngineFeatures) {
 	*out = *in
@@ -687,42 +1436,179 @@ func (in *ExposeSpec) DeepCopyInto(out *ExposeSpec) {
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExposeSpec.
-func (in *ExposeSpec) DeepCopy() *ExposeSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExposeSpec.
+func (in *ExposeSpec) DeepCopy() *ExposeSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ExposeSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GCSStorageSpec) DeepCopyInto(out *GCSStorageSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GCSStorageSpec.
+func (in *GCSStorageSpec) DeepCopy() *GCSStorageSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(GCSStorageSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GrafanaSpec) DeepCopyInto(out *GrafanaSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GrafanaSpec.
+func (in *GrafanaSpec) DeepCopy() *GrafanaSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(GrafanaSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HorizontalScalingSpec) DeepCopyInto(out *HorizontalScalingSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HorizontalScalingSpec.
+func (in *HorizontalScalingSpec) DeepCopy() *HorizontalScalingSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(HorizontalScalingSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LocalStorageSpec) DeepCopyInto(out *LocalStorageSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LocalStorageSpec.
+func (in *LocalStorageSpec) DeepCopy() *LocalStorageSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(LocalStorageSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LogShippingSpec) DeepCopyInto(out *LogShippingSpec) {
+	*out = *in
+	if in.Attributes != nil {
+		in, out := &in.Attributes, &out.Attributes
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LogShippingSpec.
+func (in *LogShippingSpec) DeepCopy() *LogShippingSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(LogShippingSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LoggingSpec) DeepCopyInto(out *LoggingSpec) {
+	*out = *in
+	if in.SlowQueryThreshold != nil {
+		in, out := &in.SlowQueryThreshold, &out.SlowQueryThreshold
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.Shipping != nil {
+		in, out := &in.Shipping, &out.Shipping
+		*out = new(LogShippingSpec)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LoggingSpec.
+func (in *LoggingSpec) DeepCopy() *LoggingSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(ExposeSpec)
+	out := new(LoggingSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *GCSStorageSpec) DeepCopyInto(out *GCSStorageSpec) {
+func (in *LogicalBackupSpec) DeepCopyInto(out *LogicalBackupSpec) {
 	*out = *in
+	if in.Databases != nil {
+		in, out := &in.Databases, &out.Databases
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Tables != nil {
+		in, out := &in.Tables, &out.Tables
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.CompressionLevel != nil {
+		in, out := &in.CompressionLevel, &out.CompressionLevel
+		*out = new(int32)
+		**out = **in
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GCSStorageSpec.
-func (in *GCSStorageSpec) DeepCopy() *GCSStorageSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LogicalBackupSpec.
+func (in *LogicalBackupSpec) DeepCopy() *LogicalBackupSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(GCSStorageSpec)
+	out := new(LogicalBackupSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HorizontalScalingSpec) DeepCopyInto(out *HorizontalScalingSpec) {
+func (in *LogicalRestoreSpec) DeepCopyInto(out *LogicalRestoreSpec) {
 	*out = *in
+	if in.Databases != nil {
+		in, out := &in.Databases, &out.Databases
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Schemas != nil {
+		in, out := &in.Schemas, &out.Schemas
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Tables != nil {
+		in, out := &in.Tables, &out.Tables
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HorizontalScalingSpec.
-func (in *HorizontalScalingSpec) DeepCopy() *HorizontalScalingSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LogicalRestoreSpec.
+func (in *LogicalRestoreSpec) DeepCopy() *LogicalRestoreSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(HorizontalScalingSpec)
+	out := new(LogicalRestoreSpec)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -799,6 +1685,33 @@ func (in *MonitoringConfigSpec) DeepCopyInto(out *MonitoringConfigSpec) {
 		*out = new(PrometheusConfigSpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.CustomQueries != nil {
+		in, out := &in.CustomQueries, &out.CustomQueries
+		*out = make([]CustomQuery, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Alerting != nil {
+		in, out := &in.Alerting, &out.Alerting
+		*out = new(AlertingSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Grafana != nil {
+		in, out := &in.Grafana, &out.Grafana
+		*out = new(GrafanaSpec)
+		**out = **in
+	}
+	if in.Datadog != nil {
+		in, out := &in.Datadog, &out.Datadog
+		*out = new(DatadogConfigSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NewRelic != nil {
+		in, out := &in.NewRelic, &out.NewRelic
+		*out = new(NewRelicConfigSpec)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.CredentialsSecretRef != nil {
 		in, out := &in.CredentialsSecretRef, &out.CredentialsSecretRef
 		*out = new(corev1.LocalObjectReference)
@@ -851,6 +1764,13 @@ func (in *MonitoringSpec) DeepCopyInto(out *MonitoringSpec) {
 		*out = new(PMMSpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.CustomQueries != nil {
+		in, out := &in.CustomQueries, &out.CustomQueries
+		*out = make([]CustomQuery, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MonitoringSpec.
@@ -893,6 +1813,28 @@ func (in *NFSStorageSpec) DeepCopy() *NFSStorageSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NewRelicConfigSpec) DeepCopyInto(out *NewRelicConfigSpec) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NewRelicConfigSpec.
+func (in *NewRelicConfigSpec) DeepCopy() *NewRelicConfigSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NewRelicConfigSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OpsRequest) DeepCopyInto(out *OpsRequest) {
 	*out = *in
@@ -984,7 +1926,7 @@ func (in *OpsRequestSpec) DeepCopyInto(out *OpsRequestSpec) {
 	if in.Backup != nil {
 		in, out := &in.Backup, &out.Backup
 		*out = new(BackupRequestSpec)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.Restore != nil {
 		in, out := &in.Restore, &out.Restore
@@ -1140,6 +2082,20 @@ func (in *PrometheusConfigSpec) DeepCopyInto(out *PrometheusConfigSpec) {
 			(*out)[key] = val
 		}
 	}
+	if in.Relabelings != nil {
+		in, out := &in.Relabelings, &out.Relabelings
+		*out = make([]RelabelConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.MetricRelabelings != nil {
+		in, out := &in.MetricRelabelings, &out.MetricRelabelings
+		*out = make([]RelabelConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PrometheusConfigSpec.
@@ -1153,9 +2109,72 @@ func (in *PrometheusConfigSpec) DeepCopy() *PrometheusConfigSpec {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ProxySpec) DeepCopyInto(out *ProxySpec) {
+func (in *ProxyInstanceSpec) DeepCopyInto(out *ProxyInstanceSpec) {
 	*out = *in
 	in.Resources.DeepCopyInto(&out.Resources)
+	if in.DefaultPoolSize != nil {
+		in, out := &in.DefaultPoolSize, &out.DefaultPoolSize
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxClientConnections != nil {
+		in, out := &in.MaxClientConnections, &out.MaxClientConnections
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Parameters != nil {
+		in, out := &in.Parameters, &out.Parameters
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Expose != nil {
+		in, out := &in.Expose, &out.Expose
+		*out = new(ExposeSpec)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProxyInstanceSpec.
+func (in *ProxyInstanceSpec) DeepCopy() *ProxyInstanceSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ProxyInstanceSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProxyQueryRule) DeepCopyInto(out *ProxyQueryRule) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProxyQueryRule.
+func (in *ProxyQueryRule) DeepCopy() *ProxyQueryRule {
+	if in == nil {
+		return nil
+	}
+	out := new(ProxyQueryRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProxySpec) DeepCopyInto(out *ProxySpec) {
+	*out = *in
+	in.ProxyInstanceSpec.DeepCopyInto(&out.ProxyInstanceSpec)
+	if in.ReadOnly != nil {
+		in, out := &in.ReadOnly, &out.ReadOnly
+		*out = new(ProxyInstanceSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.QueryRules != nil {
+		in, out := &in.QueryRules, &out.QueryRules
+		*out = make([]ProxyQueryRule, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProxySpec.
@@ -1171,6 +2190,11 @@ func (in *ProxySpec) DeepCopy() *ProxySpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ProxyStatus) DeepCopyInto(out *ProxyStatus) {
 	*out = *in
+	if in.ReadOnly != nil {
+		in, out := &in.ReadOnly, &out.ReadOnly
+		*out = new(ProxyStatus)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProxyStatus.
@@ -1183,6 +2207,45 @@ func (in *ProxyStatus) DeepCopy() *ProxyStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QueryInsightsSpec) DeepCopyInto(out *QueryInsightsSpec) {
+	*out = *in
+	if in.RefreshInterval != nil {
+		in, out := &in.RefreshInterval, &out.RefreshInterval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QueryInsightsSpec.
+func (in *QueryInsightsSpec) DeepCopy() *QueryInsightsSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(QueryInsightsSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QueryInsightsStatus) DeepCopyInto(out *QueryInsightsStatus) {
+	*out = *in
+	if in.LastRefreshTime != nil {
+		in, out := &in.LastRefreshTime, &out.LastRefreshTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QueryInsightsStatus.
+func (in *QueryInsightsStatus) DeepCopy() *QueryInsightsStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(QueryInsightsStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RebuildInstanceSpec) DeepCopyInto(out *RebuildInstanceSpec) {
 	*out = *in
@@ -1218,6 +2281,26 @@ func (in *ReconfiguringSpec) DeepCopy() *ReconfiguringSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RelabelConfig) DeepCopyInto(out *RelabelConfig) {
+	*out = *in
+	if in.SourceLabels != nil {
+		in, out := &in.SourceLabels, &out.SourceLabels
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RelabelConfig.
+func (in *RelabelConfig) DeepCopy() *RelabelConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(RelabelConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RestoreRequestSpec) DeepCopyInto(out *RestoreRequestSpec) {
 	*out = *in
@@ -1225,6 +2308,16 @@ func (in *RestoreRequestSpec) DeepCopyInto(out *RestoreRequestSpec) {
 		in, out := &in.PointInTime, &out.PointInTime
 		*out = (*in).DeepCopy()
 	}
+	if in.TargetCluster != nil {
+		in, out := &in.TargetCluster, &out.TargetCluster
+		*out = new(RestoreTargetSpec)
+		**out = **in
+	}
+	if in.Logical != nil {
+		in, out := &in.Logical, &out.Logical
+		*out = new(LogicalRestoreSpec)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestoreRequestSpec.
@@ -1237,6 +2330,41 @@ func (in *RestoreRequestSpec) DeepCopy() *RestoreRequestSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RestoreStatus) DeepCopyInto(out *RestoreStatus) {
+	*out = *in
+	if in.PointInTime != nil {
+		in, out := &in.PointInTime, &out.PointInTime
+		*out = (*in).DeepCopy()
+	}
+	in.StartedAt.DeepCopyInto(&out.StartedAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestoreStatus.
+func (in *RestoreStatus) DeepCopy() *RestoreStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RestoreStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RestoreTargetSpec) DeepCopyInto(out *RestoreTargetSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestoreTargetSpec.
+func (in *RestoreTargetSpec) DeepCopy() *RestoreTargetSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RestoreTargetSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *S3StorageSpec) DeepCopyInto(out *S3StorageSpec) {
 	*out = *in
@@ -1252,6 +2380,29 @@ func (in *S3StorageSpec) DeepCopy() *S3StorageSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecondaryReplicationStatus) DeepCopyInto(out *SecondaryReplicationStatus) {
+	*out = *in
+	if in.LastReplicationTime != nil {
+		in, out := &in.LastReplicationTime, &out.LastReplicationTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastAttemptTime != nil {
+		in, out := &in.LastAttemptTime, &out.LastAttemptTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecondaryReplicationStatus.
+func (in *SecondaryReplicationStatus) DeepCopy() *SecondaryReplicationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SecondaryReplicationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *StorageSpec) DeepCopyInto(out *StorageSpec) {
 	*out = *in
@@ -1308,6 +2459,41 @@ func (in *UpgradeSpec) DeepCopy() *UpgradeSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VerificationCheck) DeepCopyInto(out *VerificationCheck) {
+	*out = *in
+	if in.Command != nil {
+		in, out := &in.Command, &out.Command
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VerificationCheck.
+func (in *VerificationCheck) DeepCopy() *VerificationCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(VerificationCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VerificationCheckResult) DeepCopyInto(out *VerificationCheckResult) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VerificationCheckResult.
+func (in *VerificationCheckResult) DeepCopy() *VerificationCheckResult {
+	if in == nil {
+		return nil
+	}
+	out := new(VerificationCheckResult)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VerticalScalingSpec) DeepCopyInto(out *VerticalScalingSpec) {
 	*out = *in
@@ -1339,3 +2525,38 @@ func (in *VolumeExpansionSpec) DeepCopy() *VolumeExpansionSpec {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VolumeSnapshotBackupSpec) DeepCopyInto(out *VolumeSnapshotBackupSpec) {
+	*out = *in
+	if in.Online != nil {
+		in, out := &in.Online, &out.Online
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeSnapshotBackupSpec.
+func (in *VolumeSnapshotBackupSpec) DeepCopy() *VolumeSnapshotBackupSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(VolumeSnapshotBackupSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkloadIdentitySpec) DeepCopyInto(out *WorkloadIdentitySpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkloadIdentitySpec.
+func (in *WorkloadIdentitySpec) DeepCopy() *WorkloadIdentitySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkloadIdentitySpec)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,368 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	dbaasv1 "github.com/huynt0812/dbaas-operator/api/v1"
+	"github.com/huynt0812/dbaas-operator/pkg/backupstorage"
+)
+
+const (
+	// backupStorageLabel marks DatabaseBackups discovered in a BackupStorage
+	backupStorageLabel = "dbaas.io/backup-storage"
+
+	// defaultSyncInterval is used when BackupStorageSyncSpec.Interval is not set
+	defaultSyncInterval = 10 * time.Minute
+)
+
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// BackupStorageReconciler reconciles a BackupStorage object
+type BackupStorageReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=dbaas.io,resources=backupstorages,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=dbaas.io,resources=backupstorages/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=dbaas.io,resources=databasebackups,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=dbaas.io,resources=databasebackups/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=serviceaccounts,verbs=get;list;watch;create;update;patch
+// +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;delete
+
+// Reconcile is part of the main kubernetes reconciliation loop
+func (r *BackupStorageReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := log.FromContext(ctx)
+
+	// Fetch the BackupStorage instance
+	storage := &dbaasv1.BackupStorage{}
+	if err := r.Get(ctx, req.NamespacedName, storage); err != nil {
+		if errors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		log.Error(err, "unable to fetch BackupStorage")
+		return ctrl.Result{}, err
+	}
+
+	// Record key rotations first so that discovered backups resolve the right key
+	if err := r.trackEncryptionKeys(ctx, storage); err != nil {
+		log.Error(err, "failed to track encryption keys")
+		return ctrl.Result{}, err
+	}
+
+	if err := r.reconcileWorkloadIdentity(ctx, storage); err != nil {
+		log.Error(err, "failed to reconcile workload identity")
+		return ctrl.Result{}, err
+	}
+
+	requeueAfter, err := r.reconcileSync(ctx, storage)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	replicationAfter, err := r.reconcileReplication(ctx, storage)
+	if err != nil {
+		log.Error(err, "failed to reconcile replication")
+		return ctrl.Result{}, err
+	}
+	requeueAfter = minRequeue(requeueAfter, replicationAfter)
+
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
+}
+
+// reconcileSync scans the storage when catalog sync is enabled and due, and returns when to scan next
+func (r *BackupStorageReconciler) reconcileSync(ctx context.Context, storage *dbaasv1.BackupStorage) (time.Duration, error) {
+	log := log.FromContext(ctx)
+
+	// Nothing to do unless catalog sync is enabled
+	if storage.Spec.Sync == nil || !storage.Spec.Sync.Enabled {
+		return 0, nil
+	}
+
+	// Storages the operator cannot read never sync, report it instead of retrying
+	if err := backupstorage.ValidateSync(storage); err != nil {
+		changed := meta.SetStatusCondition(&storage.Status.Conditions, metav1.Condition{
+			Type:               "Synced",
+			Status:             metav1.ConditionFalse,
+			Reason:             "SyncNotSupported",
+			Message:            err.Error(),
+			ObservedGeneration: storage.Generation,
+		})
+		if !changed {
+			return 0, nil
+		}
+		return 0, r.Status().Update(ctx, storage)
+	}
+
+	interval := defaultSyncInterval
+	if storage.Spec.Sync.Interval != nil && storage.Spec.Sync.Interval.Duration > 0 {
+		interval = storage.Spec.Sync.Interval.Duration
+	}
+
+	// Skip the scan if the last one is recent enough and saw the current spec. A spec change,
+	// such as a new bucket or prefix, resets the interval.
+	synced := meta.FindStatusCondition(storage.Status.Conditions, "Synced")
+	if synced != nil && synced.ObservedGeneration != storage.Generation {
+		storage.Status.LastSyncTime = nil
+	}
+	if storage.Status.LastSyncTime != nil {
+		if wait := interval - time.Since(storage.Status.LastSyncTime.Time); wait > 0 {
+			return wait, nil
+		}
+	}
+
+	discovered, err := r.syncCatalog(ctx, storage)
+	if err != nil {
+		log.Error(err, "failed to sync backup catalog")
+		return interval, r.updateSyncStatus(ctx, storage, 0, err)
+	}
+
+	log.Info("Synced backup catalog", "backups", discovered)
+	return interval, r.updateSyncStatus(ctx, storage, discovered, nil)
+}
+
+// syncCatalog scans the storage and makes the read-only DatabaseBackups match its content
+func (r *BackupStorageReconciler) syncCatalog(ctx context.Context, storage *dbaasv1.BackupStorage) (int32, error) {
+	store, err := backupstorage.NewStore(ctx, r.Client, storage)
+	if err != nil {
+		return 0, err
+	}
+
+	entries, err := backupstorage.Discover(ctx, store, storage)
+	if err != nil {
+		return 0, err
+	}
+
+	seen := make(map[string]bool, len(entries))
+	for i := range entries {
+		backupID := entries[i].BackupID
+		if entries[i].Method == dbaasv1.BackupMethodLogical {
+			// Logical and base backups of a server may share a timestamp
+			backupID += "-logical"
+		}
+		name := discoveredBackupName(storage.Name, entries[i].ServerName, backupID)
+		seen[name] = true
+		if err := r.ensureDiscoveredBackup(ctx, storage, name, &entries[i]); err != nil {
+			return 0, err
+		}
+	}
+
+	// Drop records of backups that are no longer present in the storage
+	existing := &dbaasv1.DatabaseBackupList{}
+	if err := r.List(ctx, existing,
+		client.InNamespace(storage.Namespace),
+		client.MatchingLabels{backupStorageLabel: storage.Name},
+	); err != nil {
+		return 0, err
+	}
+	for i := range existing.Items {
+		backup := &existing.Items[i]
+		if !backup.Spec.ReadOnly || seen[backup.Name] {
+			continue
+		}
+		if err := r.Delete(ctx, backup); client.IgnoreNotFound(err) != nil {
+			return 0, err
+		}
+	}
+
+	return int32(len(entries)), nil
+}
+
+// ensureDiscoveredBackup creates the DatabaseBackup for a catalog entry if missing and refreshes its status
+func (r *BackupStorageReconciler) ensureDiscoveredBackup(ctx context.Context, storage *dbaasv1.BackupStorage, name string, entry *backupstorage.CatalogEntry) error {
+	backup := &dbaasv1.DatabaseBackup{}
+	err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: storage.Namespace}, backup)
+	if errors.IsNotFound(err) {
+		backup = &dbaasv1.DatabaseBackup{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: storage.Namespace,
+				Labels: map[string]string{
+					backupStorageLabel: storage.Name,
+					"dbaas.io/cluster": entry.ServerName,
+					"dbaas.io/engine":  entry.EngineType,
+				},
+			},
+			Spec: dbaasv1.DatabaseBackupSpec{
+				ClusterName:      entry.ServerName,
+				EngineType:       entry.EngineType,
+				Method:           entry.Method,
+				BackupStorageRef: &corev1.LocalObjectReference{Name: storage.Name},
+				ReadOnly:         true,
+			},
+		}
+		if err := controllerutil.SetControllerReference(storage, backup, r.Scheme); err != nil {
+			return err
+		}
+		if err := r.Create(ctx, backup); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+
+	// Backups created by the operator itself are tracked through their own status
+	if !backup.Spec.ReadOnly {
+		return nil
+	}
+
+	backup.Status.BackupID = entry.BackupID
+	backup.Status.DestinationPath = entry.DestinationPath
+	backup.Status.ServerName = entry.ServerName
+	backup.Status.EngineVersion = entry.EngineVersion
+	backup.Status.StartedAt = toMetaTime(entry.BeginTime)
+	backup.Status.CompletedAt = toMetaTime(entry.EndTime)
+	backup.Status.Size = resource.NewQuantity(entry.Size, resource.BinarySI)
+	backup.Status.Databases = entry.Databases
+	if entry.BeginTime != nil {
+		backup.Status.Encryption = backupstorage.KeyAt(storage, *entry.BeginTime)
+	}
+	backup.Status.Phase = entry.Phase
+	switch entry.Phase {
+	case dbaasv1.DatabaseBackupPhaseSucceeded:
+		backup.Status.Message = fmt.Sprintf("discovered in BackupStorage %s", storage.Name)
+	case dbaasv1.DatabaseBackupPhaseRunning:
+		backup.Status.Message = "backup is still in progress in the source cluster"
+	default:
+		backup.Status.Message = "backup did not complete in the source cluster"
+	}
+
+	return r.Status().Update(ctx, backup)
+}
+
+// updateSyncStatus records the outcome of a catalog scan on the BackupStorage
+func (r *BackupStorageReconciler) updateSyncStatus(ctx context.Context, storage *dbaasv1.BackupStorage, discovered int32, syncErr error) error {
+	storage.Status.LastSyncTime = &metav1.Time{Time: time.Now()}
+
+	condition := metav1.Condition{
+		Type:               "Synced",
+		Status:             metav1.ConditionTrue,
+		Reason:             "CatalogSynced",
+		Message:            fmt.Sprintf("%d backups discovered", discovered),
+		ObservedGeneration: storage.Generation,
+	}
+	if syncErr != nil {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "CatalogSyncFailed"
+		condition.Message = syncErr.Error()
+		storage.Status.Message = syncErr.Error()
+	} else {
+		storage.Status.DiscoveredBackups = discovered
+		storage.Status.Message = ""
+	}
+	meta.SetStatusCondition(&storage.Status.Conditions, condition)
+
+	return r.Status().Update(ctx, storage)
+}
+
+// trackEncryptionKeys appends the current encryption key to the key history when it changed
+func (r *BackupStorageReconciler) trackEncryptionKeys(ctx context.Context, storage *dbaasv1.BackupStorage) error {
+	condition := metav1.Condition{
+		Type:               "EncryptionReady",
+		Status:             metav1.ConditionTrue,
+		Reason:             "KeyResolved",
+		ObservedGeneration: storage.Generation,
+	}
+
+	key, err := backupstorage.CurrentKey(storage)
+	if err != nil {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "InvalidEncryption"
+		condition.Message = err.Error()
+		if meta.SetStatusCondition(&storage.Status.Conditions, condition) {
+			if updateErr := r.Status().Update(ctx, storage); updateErr != nil {
+				return updateErr
+			}
+		}
+		// Invalid settings need a spec change, not a retry
+		return nil
+	}
+
+	if key == nil {
+		condition.Reason = "NotEncrypted"
+		condition.Message = "backups are not encrypted by the operator"
+	} else if key.KeyID == "" {
+		condition.Message = fmt.Sprintf("backups are encrypted with %s and the default key of the bucket", key.Mode)
+	} else {
+		condition.Message = fmt.Sprintf("backups are encrypted with %s key %s", key.Mode, key.KeyID)
+	}
+	changed := meta.SetStatusCondition(&storage.Status.Conditions, condition)
+
+	var active *dbaasv1.BackupEncryptionKey
+	history := storage.Status.EncryptionKeys
+	if n := len(history); n > 0 && history[n-1].RetiredAt == nil {
+		active = &history[n-1].BackupEncryptionKey
+	}
+
+	if !backupstorage.SameKey(active, key) {
+		now := metav1.Now()
+		if active != nil {
+			history[len(history)-1].RetiredAt = &now
+		}
+		if key != nil {
+			history = append(history, dbaasv1.EncryptionKeyRecord{
+				BackupEncryptionKey: *key,
+				ActiveSince:         now,
+			})
+		}
+		storage.Status.EncryptionKeys = history
+		changed = true
+		log.FromContext(ctx).Info("Encryption key changed", "key", condition.Message)
+	}
+
+	if !changed {
+		return nil
+	}
+	return r.Status().Update(ctx, storage)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *BackupStorageReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&dbaasv1.BackupStorage{}).
+		Owns(&batchv1.Job{}).
+		Complete(r)
+}
+
+// discoveredBackupName builds a valid object name for a backup found in a storage
+func discoveredBackupName(storageName, serverName, backupID string) string {
+	name := strings.ToLower(fmt.Sprintf("%s-%s-%s", storageName, serverName, backupID))
+	name = strings.Trim(invalidNameChars.ReplaceAllString(name, "-"), "-")
+	if len(name) > 253 {
+		name = strings.TrimRight(name[:253], "-")
+	}
+	return name
+}
+
+// minRequeue returns the shortest non-zero requeue delay
+func minRequeue(a, b time.Duration) time.Duration {
+	if a == 0 || (b != 0 && b < a) {
+		return b
+	}
+	return a
+}
+
+func toMetaTime(t *time.Time) *metav1.Time {
+	if t == nil {
+		return nil
+	}
+	return &metav1.Time{Time: *t}
+}
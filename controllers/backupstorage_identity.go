@@ -0,0 +1,81 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	dbaasv1 "github.com/huynt0812/dbaas-operator/api/v1"
+	"github.com/huynt0812/dbaas-operator/pkg/backupstorage"
+)
+
+// workloadIdentityCondition reports whether the workload identity of a storage can be used
+const workloadIdentityCondition = "WorkloadIdentityReady"
+
+// reconcileWorkloadIdentity validates the workload identity of the storage, keeps the ServiceAccount
+// of the Jobs of the storage bound to it, and checks the token the operator reads the storage with
+func (r *BackupStorageReconciler) reconcileWorkloadIdentity(ctx context.Context, storage *dbaasv1.BackupStorage) error {
+	if !backupstorage.UsesWorkloadIdentity(storage) {
+		if meta.RemoveStatusCondition(&storage.Status.Conditions, workloadIdentityCondition) {
+			return r.Status().Update(ctx, storage)
+		}
+		return nil
+	}
+
+	condition := metav1.Condition{
+		Type:               workloadIdentityCondition,
+		Status:             metav1.ConditionTrue,
+		Reason:             "IdentityBound",
+		Message:            fmt.Sprintf("service account %s is bound to the %s identity", backupstorage.ServiceAccountName(storage), storage.Spec.WorkloadIdentity.Provider),
+		ObservedGeneration: storage.Generation,
+	}
+	if err := backupstorage.ValidateWorkloadIdentity(storage); err != nil {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "InvalidWorkloadIdentity"
+		condition.Message = err.Error()
+	} else {
+		if err := r.ensureServiceAccount(ctx, storage); err != nil {
+			return err
+		}
+		// The operator itself exchanges its projected token to list the storage
+		if path := backupstorage.TokenFile(storage); path != "" {
+			if _, err := backupstorage.ReadToken(path, time.Now()); err != nil {
+				condition.Status = metav1.ConditionFalse
+				condition.Reason = "TokenUnavailable"
+				condition.Message = fmt.Sprintf("the operator cannot read the storage: %v", err)
+			}
+		}
+	}
+
+	if meta.SetStatusCondition(&storage.Status.Conditions, condition) {
+		return r.Status().Update(ctx, storage)
+	}
+	return nil
+}
+
+// ensureServiceAccount creates or updates the ServiceAccount of the Jobs of the storage with the identity annotations
+func (r *BackupStorageReconciler) ensureServiceAccount(ctx context.Context, storage *dbaasv1.BackupStorage) error {
+	serviceAccount := &corev1.ServiceAccount{}
+	serviceAccount.Name = backupstorage.ServiceAccountName(storage)
+	serviceAccount.Namespace = storage.Namespace
+
+	_, err := controllerutil.CreateOrUpdate(ctx, r.Client, serviceAccount, func() error {
+		if serviceAccount.Annotations == nil {
+			serviceAccount.Annotations = make(map[string]string)
+		}
+		for k, v := range backupstorage.ServiceAccountAnnotations(storage) {
+			serviceAccount.Annotations[k] = v
+		}
+		// Adopt the ServiceAccount only when the operator created it
+		if serviceAccount.CreationTimestamp.IsZero() {
+			return controllerutil.SetControllerReference(storage, serviceAccount, r.Scheme)
+		}
+		return nil
+	})
+	return err
+}
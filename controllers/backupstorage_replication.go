@@ -0,0 +1,228 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	dbaasv1 "github.com/huynt0812/dbaas-operator/api/v1"
+	"github.com/huynt0812/dbaas-operator/pkg/backupstorage"
+)
+
+const (
+	// replicaStorageLabel marks the replication Jobs of a secondary storage
+	replicaStorageLabel = "dbaas.io/replica-storage"
+
+	// defaultReplicationInterval is used when BackupReplicationSpec.Interval is not set
+	defaultReplicationInterval = 5 * time.Minute
+)
+
+// reconcileReplication runs the replication passes to the secondary storages and returns when to check again
+func (r *BackupStorageReconciler) reconcileReplication(ctx context.Context, storage *dbaasv1.BackupStorage) (time.Duration, error) {
+	previous := storage.Status.DeepCopy()
+
+	var secondaries []corev1.LocalObjectReference
+	interval := defaultReplicationInterval
+	if storage.Spec.Replication != nil {
+		secondaries = storage.Spec.Replication.Secondaries
+		if storage.Spec.Replication.Interval != nil && storage.Spec.Replication.Interval.Duration > 0 {
+			interval = storage.Spec.Replication.Interval.Duration
+		}
+	}
+
+	// Keep the status of configured secondaries only
+	statuses := make([]dbaasv1.SecondaryReplicationStatus, 0, len(secondaries))
+	for _, secondary := range secondaries {
+		status := dbaasv1.SecondaryReplicationStatus{Name: secondary.Name}
+		for _, existing := range storage.Status.Replication {
+			if existing.Name == secondary.Name {
+				status = existing
+			}
+		}
+		statuses = append(statuses, status)
+	}
+
+	var requeueAfter time.Duration
+	for i := range statuses {
+		after, err := r.replicateTo(ctx, storage, &statuses[i], interval)
+		if err != nil {
+			return 0, err
+		}
+		requeueAfter = minRequeue(requeueAfter, after)
+	}
+
+	if len(statuses) == 0 {
+		statuses = nil
+	}
+	storage.Status.Replication = statuses
+	if equality.Semantic.DeepEqual(previous, &storage.Status) {
+		return requeueAfter, nil
+	}
+	return requeueAfter, r.Status().Update(ctx, storage)
+}
+
+// replicateTo collects the outcome of the last replication pass to a secondary and starts the next one when due
+func (r *BackupStorageReconciler) replicateTo(ctx context.Context, storage *dbaasv1.BackupStorage, status *dbaasv1.SecondaryReplicationStatus, interval time.Duration) (time.Duration, error) {
+	log := log.FromContext(ctx)
+	labels := map[string]string{
+		backupStorageLabel:  storage.Name,
+		replicaStorageLabel: status.Name,
+	}
+
+	jobs := &batchv1.JobList{}
+	if err := r.List(ctx, jobs, client.InNamespace(storage.Namespace), client.MatchingLabels(labels)); err != nil {
+		return 0, err
+	}
+
+	running := false
+	for i := range jobs.Items {
+		job := &jobs.Items[i]
+		finishedAt, failed, finished := jobFinished(job)
+		if !finished {
+			running = true
+			continue
+		}
+
+		status.LastAttemptTime = &finishedAt
+		status.Healthy = !failed
+		if failed {
+			status.Message = fmt.Sprintf("replication job %s failed", job.Name)
+		} else {
+			status.LastReplicationTime = &finishedAt
+			status.Message = ""
+			startedAt := job.CreationTimestamp
+			if job.Status.StartTime != nil {
+				startedAt = *job.Status.StartTime
+			}
+			if err := r.markReplicated(ctx, storage, status.Name, startedAt, finishedAt); err != nil {
+				return 0, err
+			}
+		}
+
+		if err := r.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground)); client.IgnoreNotFound(err) != nil {
+			return 0, err
+		}
+	}
+
+	if running {
+		return 30 * time.Second, nil
+	}
+
+	// Wait for the next pass
+	if status.LastAttemptTime != nil {
+		if wait := interval - time.Since(status.LastAttemptTime.Time); wait > 0 {
+			return wait, nil
+		}
+	}
+
+	secondary := &dbaasv1.BackupStorage{}
+	if err := r.Get(ctx, types.NamespacedName{Name: status.Name, Namespace: storage.Namespace}, secondary); err != nil {
+		if !errors.IsNotFound(err) {
+			return 0, err
+		}
+		status.Healthy = false
+		status.Message = fmt.Sprintf("secondary BackupStorage %s not found", status.Name)
+		return interval, nil
+	}
+	if secondary.Name == storage.Name {
+		status.Healthy = false
+		status.Message = "a storage cannot replicate to itself"
+		return 0, nil
+	}
+
+	job, err := backupstorage.ReplicationJob(storage, secondary)
+	if err != nil {
+		status.Healthy = false
+		status.Message = err.Error()
+		return interval, nil
+	}
+	job.GenerateName = truncateName(fmt.Sprintf("%s-replicate-%s", storage.Name, secondary.Name), 57) + "-"
+	job.Labels = labels
+	if job.Spec.Template.Labels == nil {
+		job.Spec.Template.Labels = make(map[string]string)
+	}
+	for k, v := range labels {
+		job.Spec.Template.Labels[k] = v
+	}
+	if err := controllerutil.SetControllerReference(storage, job, r.Scheme); err != nil {
+		return 0, err
+	}
+	if err := r.Create(ctx, job); err != nil {
+		return 0, err
+	}
+
+	log.Info("Started replication pass", "secondary", secondary.Name, "job", job.Name)
+	return 30 * time.Second, nil
+}
+
+// markReplicated records the copy in the secondary on the backups completed before the replication pass started
+func (r *BackupStorageReconciler) markReplicated(ctx context.Context, storage *dbaasv1.BackupStorage, secondary string, startedAt, finishedAt metav1.Time) error {
+	backups := &dbaasv1.DatabaseBackupList{}
+	if err := r.List(ctx, backups, client.InNamespace(storage.Namespace)); err != nil {
+		return err
+	}
+
+	for i := range backups.Items {
+		backup := &backups.Items[i]
+		if backup.Spec.BackupStorageRef == nil || backup.Spec.BackupStorageRef.Name != storage.Name ||
+			backup.Status.Phase != dbaasv1.DatabaseBackupPhaseSucceeded ||
+			backup.Status.CompletedAt == nil || !backup.Status.CompletedAt.Before(&startedAt) {
+			continue
+		}
+
+		replicated := false
+		for _, replica := range backup.Status.Replicas {
+			if replica.StorageName == secondary {
+				replicated = true
+				break
+			}
+		}
+		if replicated {
+			continue
+		}
+
+		backup.Status.Replicas = append(backup.Status.Replicas, dbaasv1.BackupReplica{
+			StorageName:  secondary,
+			ReplicatedAt: finishedAt,
+			Lag:          &metav1.Duration{Duration: finishedAt.Sub(backup.Status.CompletedAt.Time).Round(time.Second)},
+		})
+		if err := r.Status().Update(ctx, backup); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// jobFinished reports whether a Job finished, when, and if it failed
+func jobFinished(job *batchv1.Job) (metav1.Time, bool, bool) {
+	for _, condition := range job.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case batchv1.JobComplete:
+			return condition.LastTransitionTime, false, true
+		case batchv1.JobFailed:
+			return condition.LastTransitionTime, true, true
+		}
+	}
+	return metav1.Time{}, false, false
+}
+
+// truncateName shortens a generated object name to the given length
+func truncateName(name string, length int) string {
+	if len(name) <= length {
+		return name
+	}
+	return name[:length]
+}
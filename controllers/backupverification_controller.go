@@ -0,0 +1,573 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	dbaasv1 "github.com/huynt0812/dbaas-operator/api/v1"
+	"github.com/huynt0812/dbaas-operator/pkg/provider"
+)
+
+const (
+	// backupVerificationLabel marks the scratch clusters and check Jobs of a BackupVerification
+	backupVerificationLabel = "dbaas.io/backup-verification"
+
+	// defaultVerificationTimeout is used when BackupVerificationSpec.Timeout is not set
+	defaultVerificationTimeout = 2 * time.Hour
+
+	// maxCheckOutput is the number of output bytes kept per check
+	maxCheckOutput = 1024
+
+	// outputScript runs a command and writes the start of its output to the termination log
+	outputScript = `out="$(%s 2>&1)"; rc=$?; printf '%%s' "$out" | head -c %d > /dev/termination-log; exit $rc`
+)
+
+// BackupVerificationReconciler reconciles a BackupVerification object
+type BackupVerificationReconciler struct {
+	client.Client
+	Scheme          *runtime.Scheme
+	ProviderFactory provider.ProviderFactory
+}
+
+// +kubebuilder:rbac:groups=dbaas.io,resources=backupverifications,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=dbaas.io,resources=backupverifications/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=dbaas.io,resources=databaseclusters,verbs=get;list;watch;create;delete
+// +kubebuilder:rbac:groups=dbaas.io,resources=databasebackups,verbs=get;list;watch
+// +kubebuilder:rbac:groups=dbaas.io,resources=databasebackups/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;delete
+// +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch
+
+// Reconcile is part of the main kubernetes reconciliation loop
+func (r *BackupVerificationReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := log.FromContext(ctx)
+
+	// Fetch the BackupVerification instance
+	verification := &dbaasv1.BackupVerification{}
+	if err := r.Get(ctx, req.NamespacedName, verification); err != nil {
+		if errors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		log.Error(err, "unable to fetch BackupVerification")
+		return ctrl.Result{}, err
+	}
+
+	switch verification.Status.Phase {
+	case dbaasv1.BackupVerificationPhaseRestoring:
+		return r.checkRestore(ctx, verification)
+	case dbaasv1.BackupVerificationPhaseChecking:
+		return r.checkResults(ctx, verification)
+	case dbaasv1.BackupVerificationPhaseCleaningUp:
+		return r.cleanup(ctx, verification)
+	default:
+		return r.schedule(ctx, verification)
+	}
+}
+
+// schedule starts a verification run when one is due
+func (r *BackupVerificationReconciler) schedule(ctx context.Context, verification *dbaasv1.BackupVerification) (ctrl.Result, error) {
+	sched, err := cron.ParseStandard(verification.Spec.Schedule)
+	if err != nil {
+		verification.Status.Phase = dbaasv1.BackupVerificationPhaseIdle
+		verification.Status.Message = fmt.Sprintf("invalid schedule: %v", err)
+		return ctrl.Result{}, r.Status().Update(ctx, verification)
+	}
+
+	now := time.Now()
+	if verification.Status.Phase == "" || verification.Status.NextRunTime == nil {
+		verification.Status.Phase = dbaasv1.BackupVerificationPhaseIdle
+		verification.Status.NextRunTime = &metav1.Time{Time: sched.Next(now)}
+		if err := r.Status().Update(ctx, verification); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	if verification.Spec.Suspend {
+		return ctrl.Result{}, nil
+	}
+
+	if wait := verification.Status.NextRunTime.Sub(now); wait > 0 {
+		return ctrl.Result{RequeueAfter: wait}, nil
+	}
+
+	return r.startRun(ctx, verification, sched)
+}
+
+// startRun restores the latest backup of the cluster into a scratch cluster
+func (r *BackupVerificationReconciler) startRun(ctx context.Context, verification *dbaasv1.BackupVerification, sched cron.Schedule) (ctrl.Result, error) {
+	log := log.FromContext(ctx)
+
+	backup, err := r.latestBackup(ctx, verification)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if backup == nil {
+		next := sched.Next(time.Now())
+		verification.Status.NextRunTime = &metav1.Time{Time: next}
+		verification.Status.Message = fmt.Sprintf("no successful backup of cluster %s can be restored into a new cluster", verification.Spec.ClusterName)
+		return ctrl.Result{RequeueAfter: time.Until(next)}, r.Status().Update(ctx, verification)
+	}
+
+	scratch, err := r.buildScratchCluster(ctx, verification, backup)
+	if err != nil {
+		next := sched.Next(time.Now())
+		verification.Status.NextRunTime = &metav1.Time{Time: next}
+		verification.Status.Message = err.Error()
+		return ctrl.Result{RequeueAfter: time.Until(next)}, r.Status().Update(ctx, verification)
+	}
+
+	if err := r.Create(ctx, scratch); err != nil && !errors.IsAlreadyExists(err) {
+		return ctrl.Result{}, err
+	}
+
+	log.Info("Started backup verification", "backup", backup.Name, "scratchCluster", scratch.Name)
+
+	verification.Status.Phase = dbaasv1.BackupVerificationPhaseRestoring
+	verification.Status.CurrentBackup = backup.Name
+	verification.Status.ScratchCluster = scratch.Name
+	verification.Status.RunStartTime = &metav1.Time{Time: time.Now()}
+	verification.Status.RestoreDuration = nil
+	verification.Status.Message = fmt.Sprintf("restoring backup %s into %s", backup.Name, scratch.Name)
+	if err := r.Status().Update(ctx, verification); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{RequeueAfter: 30 * time.Second}, nil
+}
+
+// checkRestore waits for the scratch cluster to become ready, then starts the checks
+func (r *BackupVerificationReconciler) checkRestore(ctx context.Context, verification *dbaasv1.BackupVerification) (ctrl.Result, error) {
+	scratch := &dbaasv1.DatabaseCluster{}
+	if err := r.Get(ctx, types.NamespacedName{
+		Name:      verification.Status.ScratchCluster,
+		Namespace: verification.Namespace,
+	}, scratch); err != nil {
+		if errors.IsNotFound(err) {
+			return r.finishRun(ctx, verification, dbaasv1.VerificationResultFailed, nil, "scratch cluster disappeared during restore")
+		}
+		return ctrl.Result{}, err
+	}
+
+	if scratch.Status.Phase != dbaasv1.ClusterPhaseReady {
+		if r.timedOut(verification) {
+			return r.finishRun(ctx, verification, dbaasv1.VerificationResultFailed, nil, "restore did not complete within the timeout")
+		}
+		return ctrl.Result{RequeueAfter: 30 * time.Second}, nil
+	}
+
+	verification.Status.RestoreDuration = &metav1.Duration{Duration: time.Since(verification.Status.RunStartTime.Time).Round(time.Second)}
+
+	if len(verification.Spec.Checks) == 0 {
+		return r.finishRun(ctx, verification, dbaasv1.VerificationResultPassed, nil, "restore completed")
+	}
+
+	prov, err := r.ProviderFactory.GetProvider(scratch.Spec.Engine.Type, r.Client, r.Scheme)
+	if err != nil {
+		return r.finishRun(ctx, verification, dbaasv1.VerificationResultFailed, nil, fmt.Sprintf("unable to get provider: %v", err))
+	}
+
+	for i := range verification.Spec.Checks {
+		job, err := r.buildCheckJob(verification, scratch, prov, i)
+		if err != nil {
+			return r.finishRun(ctx, verification, dbaasv1.VerificationResultFailed, nil, err.Error())
+		}
+		if err := r.Create(ctx, job); err != nil && !errors.IsAlreadyExists(err) {
+			return ctrl.Result{}, err
+		}
+	}
+
+	verification.Status.Phase = dbaasv1.BackupVerificationPhaseChecking
+	verification.Status.Message = fmt.Sprintf("restore completed in %s, running %d checks",
+		verification.Status.RestoreDuration.Duration, len(verification.Spec.Checks))
+	if err := r.Status().Update(ctx, verification); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+}
+
+// checkResults collects the results of the check Jobs
+func (r *BackupVerificationReconciler) checkResults(ctx context.Context, verification *dbaasv1.BackupVerification) (ctrl.Result, error) {
+	timedOut := r.timedOut(verification)
+	results := make([]dbaasv1.VerificationCheckResult, 0, len(verification.Spec.Checks))
+	result := dbaasv1.VerificationResultPassed
+
+	for i, check := range verification.Spec.Checks {
+		job := &batchv1.Job{}
+		if err := r.Get(ctx, types.NamespacedName{
+			Name:      checkJobName(verification, i),
+			Namespace: verification.Namespace,
+		}, job); err != nil {
+			return ctrl.Result{}, err
+		}
+
+		checkResult := dbaasv1.VerificationCheckResult{Name: check.Name}
+		switch {
+		case job.Status.Succeeded > 0 || job.Status.Failed > 0:
+			output, err := jobOutput(ctx, r.Client, job)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			checkResult.Output = output
+			checkResult.Passed = job.Status.Succeeded > 0 &&
+				(check.Expected == "" || strings.TrimSpace(output) == strings.TrimSpace(check.Expected))
+		case timedOut:
+			checkResult.Output = "check did not complete within the timeout"
+		default:
+			return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+		}
+
+		if !checkResult.Passed {
+			result = dbaasv1.VerificationResultFailed
+		}
+		results = append(results, checkResult)
+	}
+
+	passed := 0
+	for _, checkResult := range results {
+		if checkResult.Passed {
+			passed++
+		}
+	}
+
+	return r.finishRun(ctx, verification, result, results, fmt.Sprintf("%d/%d checks passed", passed, len(results)))
+}
+
+// finishRun records the result on the verified backup and moves on to cleanup
+func (r *BackupVerificationReconciler) finishRun(ctx context.Context, verification *dbaasv1.BackupVerification, result dbaasv1.VerificationResult, checks []dbaasv1.VerificationCheckResult, message string) (ctrl.Result, error) {
+	log := log.FromContext(ctx)
+	now := metav1.Now()
+
+	backup := &dbaasv1.DatabaseBackup{}
+	err := r.Get(ctx, types.NamespacedName{Name: verification.Status.CurrentBackup, Namespace: verification.Namespace}, backup)
+	if err == nil {
+		backup.Status.Verification = &dbaasv1.BackupVerificationRecord{
+			VerificationName: verification.Name,
+			Result:           result,
+			VerifiedAt:       now,
+			RestoreDuration:  verification.Status.RestoreDuration,
+			Checks:           checks,
+			Message:          message,
+		}
+		if err := r.Status().Update(ctx, backup); err != nil {
+			return ctrl.Result{}, err
+		}
+	} else if !errors.IsNotFound(err) {
+		return ctrl.Result{}, err
+	}
+
+	log.Info("Finished backup verification", "backup", verification.Status.CurrentBackup, "result", result)
+
+	condition := metav1.Condition{
+		Type:               "Verified",
+		Status:             metav1.ConditionTrue,
+		Reason:             "VerificationPassed",
+		Message:            message,
+		ObservedGeneration: verification.Generation,
+	}
+	if result != dbaasv1.VerificationResultPassed {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "VerificationFailed"
+	}
+	meta.SetStatusCondition(&verification.Status.Conditions, condition)
+
+	verification.Status.Phase = dbaasv1.BackupVerificationPhaseCleaningUp
+	verification.Status.LastRunTime = &now
+	verification.Status.LastResult = result
+	verification.Status.LastVerifiedBackup = verification.Status.CurrentBackup
+	verification.Status.Message = message
+	if err := r.Status().Update(ctx, verification); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{Requeue: true}, nil
+}
+
+// cleanup tears down the check Jobs and the scratch cluster of the finished run
+func (r *BackupVerificationReconciler) cleanup(ctx context.Context, verification *dbaasv1.BackupVerification) (ctrl.Result, error) {
+	if err := r.DeleteAllOf(ctx, &batchv1.Job{},
+		client.InNamespace(verification.Namespace),
+		client.MatchingLabels{backupVerificationLabel: verification.Name},
+		client.PropagationPolicy(metav1.DeletePropagationBackground),
+	); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if verification.Status.ScratchCluster != "" {
+		scratch := &dbaasv1.DatabaseCluster{}
+		err := r.Get(ctx, types.NamespacedName{Name: verification.Status.ScratchCluster, Namespace: verification.Namespace}, scratch)
+		if err == nil {
+			if scratch.DeletionTimestamp.IsZero() {
+				if err := r.Delete(ctx, scratch); client.IgnoreNotFound(err) != nil {
+					return ctrl.Result{}, err
+				}
+			}
+			// Wait for the provider cleanup to complete before allowing the next run
+			return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+		} else if !errors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+	}
+
+	sched, err := cron.ParseStandard(verification.Spec.Schedule)
+	if err != nil {
+		verification.Status.NextRunTime = nil
+	} else {
+		verification.Status.NextRunTime = &metav1.Time{Time: sched.Next(time.Now())}
+	}
+	verification.Status.Phase = dbaasv1.BackupVerificationPhaseIdle
+	verification.Status.CurrentBackup = ""
+	verification.Status.ScratchCluster = ""
+	verification.Status.RunStartTime = nil
+	if err := r.Status().Update(ctx, verification); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{Requeue: true}, nil
+}
+
+// latestBackup returns the most recent successful DatabaseBackup of the verified cluster that a
+// scratch cluster can bootstrap from
+func (r *BackupVerificationReconciler) latestBackup(ctx context.Context, verification *dbaasv1.BackupVerification) (*dbaasv1.DatabaseBackup, error) {
+	backups := &dbaasv1.DatabaseBackupList{}
+	if err := r.List(ctx, backups, client.InNamespace(verification.Namespace)); err != nil {
+		return nil, err
+	}
+
+	var latest *dbaasv1.DatabaseBackup
+	for i := range backups.Items {
+		backup := &backups.Items[i]
+		if backup.Spec.ClusterName != verification.Spec.ClusterName ||
+			backup.Status.Phase != dbaasv1.DatabaseBackupPhaseSucceeded {
+			continue
+		}
+		if latest != nil && !backupTime(backup).After(backupTime(latest)) {
+			continue
+		}
+		restorable, err := r.restorable(ctx, backup)
+		if err != nil {
+			return nil, err
+		}
+		if restorable {
+			latest = backup
+		}
+	}
+	return latest, nil
+}
+
+// restorable reports whether a cluster can bootstrap from the backup through its data source.
+// Logical dumps are restored into a running cluster, and backups whose storage is gone cannot be read.
+func (r *BackupVerificationReconciler) restorable(ctx context.Context, backup *dbaasv1.DatabaseBackup) (bool, error) {
+	switch backup.Spec.Method {
+	case dbaasv1.BackupMethodLogical:
+		return false, nil
+	case dbaasv1.BackupMethodVolumeSnapshot:
+		return true, nil
+	}
+	if backup.Spec.BackupStorageRef == nil {
+		return true, nil
+	}
+	storage := &dbaasv1.BackupStorage{}
+	err := r.Get(ctx, types.NamespacedName{Name: backup.Spec.BackupStorageRef.Name, Namespace: backup.Namespace}, storage)
+	if errors.IsNotFound(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// buildScratchCluster builds the temporary single-instance cluster restored from backup
+func (r *BackupVerificationReconciler) buildScratchCluster(ctx context.Context, verification *dbaasv1.BackupVerification, backup *dbaasv1.DatabaseBackup) (*dbaasv1.DatabaseCluster, error) {
+	engine := dbaasv1.EngineSpec{
+		Type:    backup.Spec.EngineType,
+		Version: backup.Status.EngineVersion,
+	}
+	storage := verification.Spec.Storage
+
+	// Prefer the settings of the source cluster when it still exists
+	source := &dbaasv1.DatabaseCluster{}
+	err := r.Get(ctx, types.NamespacedName{Name: verification.Spec.ClusterName, Namespace: verification.Namespace}, source)
+	if err == nil {
+		engine = source.Spec.Engine
+		if storage == nil {
+			storage = source.Spec.Storage.DeepCopy()
+		}
+	} else if !errors.IsNotFound(err) {
+		return nil, err
+	}
+
+	if engine.Type == "" || engine.Version == "" {
+		return nil, fmt.Errorf("cannot determine engine of backup %s", backup.Name)
+	}
+	if storage == nil {
+		return nil, fmt.Errorf("storage is required when the source cluster %s does not exist", verification.Spec.ClusterName)
+	}
+
+	resources := verification.Spec.Resources
+	if resources.Requests == nil && resources.Limits == nil {
+		resources = corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("250m"),
+				corev1.ResourceMemory: resource.MustParse("512Mi"),
+			},
+			Limits: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("1"),
+				corev1.ResourceMemory: resource.MustParse("1Gi"),
+			},
+		}
+	}
+
+	scratch := &dbaasv1.DatabaseCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-verify", verification.Name),
+			Namespace: verification.Namespace,
+			Labels: map[string]string{
+				backupVerificationLabel: verification.Name,
+			},
+		},
+		Spec: dbaasv1.DatabaseClusterSpec{
+			Engine:      engine,
+			ClusterSize: 1,
+			Resources:   resources,
+			Storage:     *storage,
+			DataSource: &dbaasv1.DataSourceSpec{
+				BackupSource: &dbaasv1.BackupSourceSpec{
+					BackupName: backup.Name,
+				},
+			},
+		},
+	}
+	if err := controllerutil.SetControllerReference(verification, scratch, r.Scheme); err != nil {
+		return nil, err
+	}
+
+	return scratch, nil
+}
+
+// buildCheckJob builds the Job running a single check against the scratch cluster.
+// The check output is written to the termination log so it can be compared with the expected value.
+func (r *BackupVerificationReconciler) buildCheckJob(verification *dbaasv1.BackupVerification, scratch *dbaasv1.DatabaseCluster, prov provider.Provider, index int) (*batchv1.Job, error) {
+	check := verification.Spec.Checks[index]
+
+	container, err := prov.ClientContainer(scratch)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case check.SQL != "" && len(check.Command) > 0:
+		return nil, fmt.Errorf("check %s must set either sql or command, not both", check.Name)
+	case check.SQL != "":
+		container.Env = append(container.Env, corev1.EnvVar{Name: "CHECK_SQL", Value: check.SQL})
+		container.Command = []string{"sh", "-c", fmt.Sprintf(outputScript, prov.QueryCommand("CHECK_SQL"), maxCheckOutput)}
+	case len(check.Command) > 0:
+		container.Command = append([]string{"sh", "-c", fmt.Sprintf(outputScript, `"$@"`, maxCheckOutput), check.Name}, check.Command...)
+	default:
+		return nil, fmt.Errorf("check %s must set sql or command", check.Name)
+	}
+	container.Name = "check"
+	container.TerminationMessagePolicy = corev1.TerminationMessageReadFile
+
+	backoffLimit := int32(0)
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      checkJobName(verification, index),
+			Namespace: verification.Namespace,
+			Labels: map[string]string{
+				backupVerificationLabel: verification.Name,
+			},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						backupVerificationLabel: verification.Name,
+					},
+				},
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					Containers:    []corev1.Container{*container},
+				},
+			},
+		},
+	}
+	if err := controllerutil.SetControllerReference(verification, job, r.Scheme); err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// jobOutput returns the termination message of the last pod run by the Job
+func jobOutput(ctx context.Context, c client.Client, job *batchv1.Job) (string, error) {
+	pods := &corev1.PodList{}
+	if err := c.List(ctx, pods,
+		client.InNamespace(job.Namespace),
+		client.MatchingLabels{"job-name": job.Name},
+	); err != nil {
+		return "", err
+	}
+
+	var output string
+	var latest time.Time
+	for _, pod := range pods.Items {
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.State.Terminated == nil {
+				continue
+			}
+			if finished := status.State.Terminated.FinishedAt.Time; output == "" || finished.After(latest) {
+				output = status.State.Terminated.Message
+				latest = finished
+			}
+		}
+	}
+	return output, nil
+}
+
+// timedOut reports whether the current run exceeded its timeout
+func (r *BackupVerificationReconciler) timedOut(verification *dbaasv1.BackupVerification) bool {
+	if verification.Status.RunStartTime == nil {
+		return false
+	}
+	timeout := defaultVerificationTimeout
+	if verification.Spec.Timeout != nil && verification.Spec.Timeout.Duration > 0 {
+		timeout = verification.Spec.Timeout.Duration
+	}
+	return time.Since(verification.Status.RunStartTime.Time) > timeout
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *BackupVerificationReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&dbaasv1.BackupVerification{}).
+		Owns(&dbaasv1.DatabaseCluster{}).
+		Owns(&batchv1.Job{}).
+		Complete(r)
+}
+
+func checkJobName(verification *dbaasv1.BackupVerification, index int) string {
+	return fmt.Sprintf("%s-check-%d", verification.Name, index)
+}
+
+// backupTime returns the completion time of a backup, falling back to its creation time
+func backupTime(backup *dbaasv1.DatabaseBackup) time.Time {
+	if backup.Status.CompletedAt != nil {
+		return backup.Status.CompletedAt.Time
+	}
+	return backup.CreationTimestamp.Time
+}
@@ -0,0 +1,161 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	dbaasv1 "github.com/huynt0812/dbaas-operator/api/v1"
+)
+
+const (
+	// backupHealthyCondition reports whether the cluster takes its scheduled backups
+	backupHealthyCondition = "BackupHealthy"
+
+	// backupHooksAppliedCondition reports whether the backups of the cluster run its hooks
+	backupHooksAppliedCondition = "BackupHooksApplied"
+
+	// defaultBackupGracePeriod is used when BackupSpec.GracePeriod is not set
+	defaultBackupGracePeriod = time.Hour
+
+	// maxMissedBackups bounds the count of missed runs for frequent schedules
+	maxMissedBackups = 1000
+)
+
+// updateBackupSLO compares the last successful backup of the cluster with its schedule,
+// raising the BackupHealthy condition and a Warning event once a backup is missed, and
+// exports the age, duration and size of the last backup
+func (r *DatabaseClusterReconciler) updateBackupSLO(ctx context.Context, cluster *dbaasv1.DatabaseCluster, status *dbaasv1.DatabaseClusterStatus) error {
+	if cluster.Spec.Backup == nil || !cluster.Spec.Backup.Enabled || status.Backup == nil {
+		meta.RemoveStatusCondition(&status.Conditions, backupHealthyCondition)
+		deleteBackupMetrics(cluster)
+		return nil
+	}
+
+	now := time.Now()
+	slo := &dbaasv1.BackupSLOStatus{Healthy: true}
+	condition := metav1.Condition{
+		Type:               backupHealthyCondition,
+		Status:             metav1.ConditionTrue,
+		Reason:             "BackupsOnSchedule",
+		ObservedGeneration: cluster.Generation,
+	}
+
+	// A cluster that never had a successful backup is due its first scheduled one
+	since := cluster.CreationTimestamp.Time
+	if last := status.Backup.LastBackupTime; last != nil {
+		since = last.Time
+		backupAgeSeconds.WithLabelValues(cluster.Namespace, cluster.Name).Set(now.Sub(since).Seconds())
+	} else {
+		backupAgeSeconds.DeleteLabelValues(cluster.Namespace, cluster.Name)
+	}
+
+	grace := defaultBackupGracePeriod
+	if cluster.Spec.Backup.GracePeriod != nil {
+		grace = cluster.Spec.Backup.GracePeriod.Duration
+	}
+
+	if cluster.Spec.Backup.Schedule == "" {
+		condition.Status = metav1.ConditionUnknown
+		condition.Reason = "NoSchedule"
+		condition.Message = "backups are not scheduled"
+	} else if sched, err := cron.ParseStandard(cluster.Spec.Backup.Schedule); err != nil {
+		condition.Status = metav1.ConditionUnknown
+		condition.Reason = "InvalidSchedule"
+		condition.Message = fmt.Sprintf("invalid backup schedule: %v", err)
+	} else {
+		due := sched.Next(since)
+		slo.Deadline = &metav1.Time{Time: due.Add(grace)}
+		for run := due; !run.Add(grace).After(now) && slo.MissedBackups < maxMissedBackups; run = sched.Next(run) {
+			slo.MissedBackups++
+		}
+		if slo.MissedBackups > 0 {
+			slo.Healthy = false
+			condition.Status = metav1.ConditionFalse
+			condition.Reason = "BackupMissed"
+			condition.Message = fmt.Sprintf("no successful backup since %s, %d scheduled backups missed",
+				since.UTC().Format(time.RFC3339), slo.MissedBackups)
+		} else {
+			condition.Message = fmt.Sprintf("next successful backup due by %s", slo.Deadline.UTC().Format(time.RFC3339))
+		}
+	}
+
+	if err := r.lastBackupMetrics(ctx, cluster, status.Backup.LastBackupName, slo); err != nil {
+		return err
+	}
+	if slo.Healthy {
+		backupHealthy.WithLabelValues(cluster.Namespace, cluster.Name).Set(1)
+	} else {
+		backupHealthy.WithLabelValues(cluster.Namespace, cluster.Name).Set(0)
+	}
+
+	// Warn once when the cluster starts missing backups, not on every reconcile
+	previous := meta.FindStatusCondition(cluster.Status.Conditions, backupHealthyCondition)
+	if condition.Status == metav1.ConditionFalse && (previous == nil || previous.Status != metav1.ConditionFalse) {
+		r.Recorder.Event(cluster, corev1.EventTypeWarning, condition.Reason, condition.Message)
+	}
+
+	status.Backup.SLO = slo
+	meta.SetStatusCondition(&status.Conditions, condition)
+	return nil
+}
+
+// lastBackupMetrics records the duration and size of the last successful backup
+func (r *DatabaseClusterReconciler) lastBackupMetrics(ctx context.Context, cluster *dbaasv1.DatabaseCluster, backupName string, slo *dbaasv1.BackupSLOStatus) error {
+	backupDurationSeconds.DeleteLabelValues(cluster.Namespace, cluster.Name)
+	backupSizeBytes.DeleteLabelValues(cluster.Namespace, cluster.Name)
+	if backupName == "" {
+		return nil
+	}
+
+	backup := &dbaasv1.DatabaseBackup{}
+	if err := r.Get(ctx, types.NamespacedName{Name: backupName, Namespace: cluster.Namespace}, backup); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	if backup.Status.StartedAt != nil && backup.Status.CompletedAt != nil {
+		duration := backup.Status.CompletedAt.Sub(backup.Status.StartedAt.Time)
+		slo.LastBackupDuration = &metav1.Duration{Duration: duration}
+		backupDurationSeconds.WithLabelValues(cluster.Namespace, cluster.Name).Set(duration.Seconds())
+	}
+	if backup.Status.Size != nil {
+		size := backup.Status.Size.DeepCopy()
+		slo.LastBackupSize = &size
+		backupSizeBytes.WithLabelValues(cluster.Namespace, cluster.Name).Set(float64(size.Value()))
+	}
+	return nil
+}
+
+// updateBackupHooksCondition reports that the scheduled backups of a cluster are taken without its
+// hooks, which only run around Backup OpsRequests
+func updateBackupHooksCondition(cluster *dbaasv1.DatabaseCluster, status *dbaasv1.DatabaseClusterStatus) {
+	spec := cluster.Spec.Backup
+	if spec == nil || !spec.Enabled || spec.Hooks == nil {
+		meta.RemoveStatusCondition(&status.Conditions, backupHooksAppliedCondition)
+		return
+	}
+
+	condition := metav1.Condition{
+		Type:               backupHooksAppliedCondition,
+		Status:             metav1.ConditionTrue,
+		Reason:             "HooksApplied",
+		Message:            "hooks run around Backup OpsRequests",
+		ObservedGeneration: cluster.Generation,
+	}
+	if spec.Schedule != "" {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "ScheduledBackupsWithoutHooks"
+		condition.Message = "hooks run around Backup OpsRequests only, the scheduled backups are taken without them"
+	}
+	meta.SetStatusCondition(&status.Conditions, condition)
+}
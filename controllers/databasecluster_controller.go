@@ -7,6 +7,7 @@ import (
 
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
@@ -20,6 +21,7 @@ type DatabaseClusterReconciler struct {
 	client.Client
 	Scheme          *runtime.Scheme
 	ProviderFactory provider.ProviderFactory
+	Recorder        record.EventRecorder
 }
 
 // +kubebuilder:rbac:groups=dbaas.io,resources=databaseclusters,verbs=get;list;watch;create;update;patch;delete
@@ -27,11 +29,29 @@ type DatabaseClusterReconciler struct {
 // +kubebuilder:rbac:groups=dbaas.io,resources=databaseclusters/finalizers,verbs=update
 // +kubebuilder:rbac:groups=postgresql.cnpg.io,resources=clusters,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=postgresql.cnpg.io,resources=backups,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=postgresql.cnpg.io,resources=scheduledbackups,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=postgresql.cnpg.io,resources=poolers,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=dbaas.io,resources=databasebackups,verbs=get;list;watch;create;update;patch
+// +kubebuilder:rbac:groups=dbaas.io,resources=databasebackups/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=batch,resources=cronjobs,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;delete
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups=monitoring.coreos.com,resources=podmonitors;servicemonitors;prometheusrules,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=services;configmaps;secrets,verbs=get;list;watch;create;update;patch;delete
 
 // Reconcile is part of the main kubernetes reconciliation loop
-func (r *DatabaseClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+func (r *DatabaseClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
 	log := log.FromContext(ctx)
 
+	// Errors are counted by the provider of the engine of the cluster
+	var engine string
+	defer func() {
+		if err != nil {
+			reconcileErrorsTotal.WithLabelValues("databasecluster", engine).Inc()
+		}
+	}()
+
 	// Fetch the DatabaseCluster instance
 	cluster := &dbaasv1.DatabaseCluster{}
 	if err := r.Get(ctx, req.NamespacedName, cluster); err != nil {
@@ -41,6 +61,7 @@ func (r *DatabaseClusterReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 		log.Error(err, "unable to fetch DatabaseCluster")
 		return ctrl.Result{}, err
 	}
+	engine = cluster.Spec.Engine.Type
 
 	// Get the provider for this engine type
 	prov, err := r.ProviderFactory.GetProvider(cluster.Spec.Engine.Type, r.Client, r.Scheme)
@@ -153,18 +174,18 @@ func (r *DatabaseClusterReconciler) applyTransformations(applier provider.Applie
 func (r *DatabaseClusterReconciler) createOrUpdateChildCluster(ctx context.Context, childCluster runtime.Object) error {
 	obj := childCluster.(client.Object)
 
-	// Try to create
-	err := r.Create(ctx, obj)
-	if err == nil {
-		return nil
-	}
-
-	// If already exists, update
-	if errors.IsAlreadyExists(err) {
-		return r.Update(ctx, obj)
+	// Create the child cluster when it does not exist yet
+	existing := obj.DeepCopyObject().(client.Object)
+	err := r.Get(ctx, client.ObjectKeyFromObject(obj), existing)
+	if errors.IsNotFound(err) {
+		return r.Create(ctx, obj)
+	} else if err != nil {
+		return err
 	}
 
-	return err
+	// Otherwise replace it with the built object, at the version just read
+	obj.SetResourceVersion(existing.GetResourceVersion())
+	return r.Update(ctx, obj)
 }
 
 // updateStatus updates the DatabaseCluster status
@@ -174,8 +195,30 @@ func (r *DatabaseClusterReconciler) updateStatus(ctx context.Context, cluster *d
 		return err
 	}
 
+	// Conditions are owned by the controller, keep those the provider did not set
+	if status.Conditions == nil {
+		status.Conditions = cluster.Status.Conditions
+	}
+	// The restore is recorded by the Restore operation the cluster is bootstrapped from
+	status.Restore = cluster.Status.Restore
+	if err := r.updateBackupSLO(ctx, cluster, status); err != nil {
+		return err
+	}
+	updateBackupHooksCondition(cluster, status)
+	r.updateCustomQueriesCondition(ctx, cluster, status)
+	r.updateAlertRulesCondition(ctx, cluster, status)
+	if err := r.reconcileQueryInsights(ctx, cluster, prov, status); err != nil {
+		return err
+	}
+	recordBackupOutcomes(cluster, status)
+
+	previous := cluster.Status.Phase
 	cluster.Status = *status
-	return r.Status().Update(ctx, cluster)
+	if err := r.Status().Update(ctx, cluster); err != nil {
+		return err
+	}
+	recordTimeToReady(cluster, previous)
+	return nil
 }
 
 // handleDeletion handles cluster deletion
@@ -189,6 +232,8 @@ func (r *DatabaseClusterReconciler) handleDeletion(ctx context.Context, cluster
 			return ctrl.Result{}, err
 		}
 
+		deleteBackupMetrics(cluster)
+
 		// Remove finalizer
 		cluster.Finalizers = removeString(cluster.Finalizers, "dbaas.io/finalizer")
 		if err := r.Update(ctx, cluster); err != nil {
@@ -0,0 +1,196 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	dbaasv1 "github.com/huynt0812/dbaas-operator/api/v1"
+	"github.com/huynt0812/dbaas-operator/pkg/monitoring"
+	"github.com/huynt0812/dbaas-operator/pkg/provider"
+)
+
+const (
+	// queryInsightsLabel marks the Jobs refreshing the statements summary of a cluster
+	queryInsightsLabel = "dbaas.io/query-insights"
+
+	// maxInsightsOutput is the size of a termination message, the room the summary has
+	maxInsightsOutput = 4096
+
+	// linesOutputScript runs a command and writes the whole lines of its output that fit in the
+	// given number of bytes to the termination log, so that no summary entry is cut in the middle
+	linesOutputScript = `out="$(%s 2>&1)"; rc=$?; printf '%%s\n' "$out" | LC_ALL=C awk -v max=%d '{ n += length($0) + 1; if (n > max) exit; print }' > /dev/termination-log; exit $rc`
+
+	// insightsJobTimeout bounds the run of a refresh Job
+	insightsJobTimeout = int64(120)
+)
+
+// reconcileQueryInsights refreshes the summary of the slowest statements of the cluster. Every
+// refresh interval a Job prints the summary on the primary, and the summary of the latest finished
+// Job is copied to a ConfigMap, so that developers can see hot queries without database access.
+func (r *DatabaseClusterReconciler) reconcileQueryInsights(ctx context.Context, cluster *dbaasv1.DatabaseCluster, prov provider.Provider, status *dbaasv1.DatabaseClusterStatus) error {
+	status.QueryInsights = cluster.Status.QueryInsights
+
+	jobs := &batchv1.JobList{}
+	if err := r.List(ctx, jobs,
+		client.InNamespace(cluster.Namespace),
+		client.MatchingLabels{queryInsightsLabel: cluster.Name},
+	); err != nil {
+		return err
+	}
+	sort.Slice(jobs.Items, func(i, j int) bool {
+		return jobs.Items[i].CreationTimestamp.Before(&jobs.Items[j].CreationTimestamp)
+	})
+
+	spec := cluster.Spec.QueryInsights
+	limit := monitoring.InsightsTopN(spec)
+	if limit == 0 {
+		status.QueryInsights = nil
+		for i := range jobs.Items {
+			if err := r.deleteInsightsJob(ctx, &jobs.Items[i]); err != nil {
+				return err
+			}
+		}
+		configMap := &corev1.ConfigMap{}
+		configMap.Name, configMap.Namespace = monitoring.InsightsConfigMapName(cluster), cluster.Namespace
+		return client.IgnoreNotFound(r.Delete(ctx, configMap))
+	}
+
+	// Only the latest Job is kept, its summary is the current one
+	var latest *batchv1.Job
+	if n := len(jobs.Items); n > 0 {
+		latest = &jobs.Items[n-1]
+		for i := range jobs.Items[:n-1] {
+			if err := r.deleteInsightsJob(ctx, &jobs.Items[i]); err != nil {
+				return err
+			}
+		}
+	}
+	if latest != nil {
+		finishedAt, failed, done := jobFinished(latest)
+		if !done {
+			return nil
+		}
+		if err := r.collectQueryInsights(ctx, cluster, latest, finishedAt, failed, status); err != nil {
+			return err
+		}
+		if time.Since(latest.CreationTimestamp.Time) < monitoring.InsightsRefreshInterval(spec) {
+			return nil
+		}
+	}
+
+	// The statements are read on the primary, wait for the cluster to serve
+	if status.Phase != dbaasv1.ClusterPhaseReady {
+		return nil
+	}
+	job, err := r.queryInsightsJob(cluster, prov, limit)
+	if err != nil {
+		status.QueryInsights = &dbaasv1.QueryInsightsStatus{Message: err.Error()}
+		return nil
+	}
+	return client.IgnoreAlreadyExists(r.Create(ctx, job))
+}
+
+// collectQueryInsights copies the summary printed by a finished Job to the summary ConfigMap
+func (r *DatabaseClusterReconciler) collectQueryInsights(ctx context.Context, cluster *dbaasv1.DatabaseCluster, job *batchv1.Job, finishedAt metav1.Time, failed bool, status *dbaasv1.DatabaseClusterStatus) error {
+	insights := &dbaasv1.QueryInsightsStatus{}
+	if status.QueryInsights != nil {
+		insights = status.QueryInsights.DeepCopy()
+	}
+	status.QueryInsights = insights
+
+	output, err := jobOutput(ctx, r.Client, job)
+	if err != nil {
+		return err
+	}
+	if failed {
+		insights.Message = fmt.Sprintf("refresh failed: %s", strings.TrimSpace(output))
+		return nil
+	}
+	statements, err := monitoring.ParseStatements(output)
+	if err != nil {
+		insights.Message = err.Error()
+		return nil
+	}
+	data, err := monitoring.InsightsSummary(statements)
+	if err != nil {
+		return err
+	}
+
+	configMap := &corev1.ConfigMap{}
+	configMap.Name, configMap.Namespace = monitoring.InsightsConfigMapName(cluster), cluster.Namespace
+	if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, configMap, func() error {
+		configMap.Labels = map[string]string{
+			"dbaas.io/cluster": cluster.Name,
+			"dbaas.io/engine":  cluster.Spec.Engine.Type,
+		}
+		configMap.Data = data
+		return controllerutil.SetControllerReference(cluster, configMap, r.Scheme)
+	}); err != nil {
+		return err
+	}
+	insights.ConfigMap = configMap.Name
+	insights.LastRefreshTime = &finishedAt
+	insights.Message = ""
+	return nil
+}
+
+// queryInsightsJob builds the Job printing the slowest statements of the cluster to its termination log
+func (r *DatabaseClusterReconciler) queryInsightsJob(cluster *dbaasv1.DatabaseCluster, prov provider.Provider, limit int32) (*batchv1.Job, error) {
+	sql, err := prov.TopStatementsSQL(limit)
+	if err != nil {
+		return nil, err
+	}
+	// The statements of every user are only readable with pg_read_all_stats, which the
+	// application user does not have
+	container, err := prov.StatsClientContainer(cluster)
+	if err != nil {
+		return nil, err
+	}
+	container.Name = "query-insights"
+	container.Env = append(container.Env, corev1.EnvVar{Name: "INSIGHTS_SQL", Value: sql})
+	container.Command = []string{"sh", "-c", fmt.Sprintf(linesOutputScript, prov.QueryCommand("INSIGHTS_SQL"), maxInsightsOutput)}
+	container.TerminationMessagePolicy = corev1.TerminationMessageReadFile
+
+	labels := map[string]string{
+		"dbaas.io/cluster": cluster.Name,
+		queryInsightsLabel: cluster.Name,
+	}
+	backoffLimit := int32(0)
+	timeout := insightsJobTimeout
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-query-insights-%d", cluster.Name, time.Now().Unix()),
+			Namespace: cluster.Namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:          &backoffLimit,
+			ActiveDeadlineSeconds: &timeout,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					Containers:    []corev1.Container{*container},
+				},
+			},
+		},
+	}
+	if err := controllerutil.SetControllerReference(cluster, job, r.Scheme); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// deleteInsightsJob deletes a refresh Job along with its pods
+func (r *DatabaseClusterReconciler) deleteInsightsJob(ctx context.Context, job *batchv1.Job) error {
+	return client.IgnoreNotFound(r.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground)))
+}
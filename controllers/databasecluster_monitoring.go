@@ -0,0 +1,87 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	dbaasv1 "github.com/huynt0812/dbaas-operator/api/v1"
+	"github.com/huynt0812/dbaas-operator/pkg/monitoring"
+)
+
+const (
+	// customQueriesValidCondition reports whether the custom queries of the cluster were rolled out
+	customQueriesValidCondition = "CustomQueriesValid"
+
+	// alertRulesValidCondition reports whether the alerting rules of the cluster were rolled out
+	alertRulesValidCondition = "AlertRulesValid"
+)
+
+// updateCustomQueriesCondition validates the custom queries of the cluster and its MonitoringConfig.
+// Providers only roll out valid queries, so a False condition means the exporter still runs the
+// last valid ones.
+func (r *DatabaseClusterReconciler) updateCustomQueriesCondition(ctx context.Context, cluster *dbaasv1.DatabaseCluster, status *dbaasv1.DatabaseClusterStatus) {
+	if cluster.Spec.Monitoring == nil || !cluster.Spec.Monitoring.Enabled {
+		meta.RemoveStatusCondition(&status.Conditions, customQueriesValidCondition)
+		return
+	}
+
+	condition := metav1.Condition{
+		Type:               customQueriesValidCondition,
+		Status:             metav1.ConditionTrue,
+		Reason:             "QueriesValid",
+		ObservedGeneration: cluster.Generation,
+	}
+
+	config, err := monitoring.ResolveConfig(ctx, r.Client, cluster)
+	queries := monitoring.CustomQueries(cluster, config)
+	switch {
+	case err != nil:
+		condition.Status = metav1.ConditionUnknown
+		condition.Reason = "MonitoringConfigUnavailable"
+		condition.Message = err.Error()
+	case len(queries) == 0:
+		meta.RemoveStatusCondition(&status.Conditions, customQueriesValidCondition)
+		return
+	default:
+		if err := monitoring.ValidateCustomQueries(queries); err != nil {
+			condition.Status = metav1.ConditionFalse
+			condition.Reason = "InvalidQueries"
+			condition.Message = err.Error()
+		} else {
+			condition.Message = fmt.Sprintf("%d custom queries rolled out", len(queries))
+		}
+	}
+	meta.SetStatusCondition(&status.Conditions, condition)
+}
+
+// updateAlertRulesCondition validates the alerting section of the MonitoringConfig of the cluster.
+// Providers only roll out valid rules, so a False condition means the last valid rules still apply.
+func (r *DatabaseClusterReconciler) updateAlertRulesCondition(ctx context.Context, cluster *dbaasv1.DatabaseCluster, status *dbaasv1.DatabaseClusterStatus) {
+	if cluster.Spec.Monitoring == nil || !cluster.Spec.Monitoring.Enabled {
+		meta.RemoveStatusCondition(&status.Conditions, alertRulesValidCondition)
+		return
+	}
+	config, err := monitoring.ResolveConfig(ctx, r.Client, cluster)
+	if err != nil || config == nil || config.Spec.Alerting == nil || !config.Spec.Alerting.Enabled {
+		// An unavailable MonitoringConfig is reported by the monitoring status
+		meta.RemoveStatusCondition(&status.Conditions, alertRulesValidCondition)
+		return
+	}
+
+	condition := metav1.Condition{
+		Type:               alertRulesValidCondition,
+		Status:             metav1.ConditionTrue,
+		Reason:             "RulesValid",
+		Message:            fmt.Sprintf("alerting rules generated in PrometheusRule %s", monitoring.AlertRulesName(cluster)),
+		ObservedGeneration: cluster.Generation,
+	}
+	if err := monitoring.ValidateAlerting(config.Spec.Alerting, cluster.Spec.Engine.Type); err != nil {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "InvalidRules"
+		condition.Message = err.Error()
+	}
+	meta.SetStatusCondition(&status.Conditions, condition)
+}
@@ -0,0 +1,180 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	dbaasv1 "github.com/huynt0812/dbaas-operator/api/v1"
+)
+
+// clusterListTimeout bounds the listing of the clusters on scrape
+const clusterListTimeout = 10 * time.Second
+
+// The per-cluster metrics label the cluster with dbaas_namespace and dbaas_cluster: Prometheus
+// overwrites the namespace label of the scraped series with the one of the operator, keeping
+// the original as exported_namespace
+var (
+	backupAgeSeconds = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "dbaas_backup_age_seconds",
+		Help: "Time since the last successful backup of the cluster",
+	}, []string{"dbaas_namespace", "dbaas_cluster"})
+
+	backupDurationSeconds = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "dbaas_backup_duration_seconds",
+		Help: "Duration of the last successful backup of the cluster",
+	}, []string{"dbaas_namespace", "dbaas_cluster"})
+
+	backupSizeBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "dbaas_backup_size_bytes",
+		Help: "Size of the last successful backup of the cluster",
+	}, []string{"dbaas_namespace", "dbaas_cluster"})
+
+	backupHealthy = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "dbaas_backup_healthy",
+		Help: "Whether the cluster has taken its scheduled backups (1) or missed one (0)",
+	}, []string{"dbaas_namespace", "dbaas_cluster"})
+
+	backupsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "dbaas_backups_total",
+		Help: "Backups of the cluster that finished, by outcome (succeeded or failed)",
+	}, []string{"dbaas_namespace", "dbaas_cluster", "outcome"})
+
+	reconcileErrorsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "dbaas_reconcile_errors_total",
+		Help: "Reconciles that returned an error, by controller and provider",
+	}, []string{"controller", "provider"})
+
+	opsRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "dbaas_opsrequests_total",
+		Help: "OpsRequests that finished, by type and outcome",
+	}, []string{"type", "outcome"})
+
+	opsRequestDurationSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "dbaas_opsrequest_duration_seconds",
+		Help:    "Time from the start to the completion of the OpsRequests, by type and outcome",
+		Buckets: prometheus.ExponentialBuckets(5, 2, 12),
+	}, []string{"type", "outcome"})
+
+	clusterTimeToReadySeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "dbaas_cluster_time_to_ready_seconds",
+		Help:    "Time from the creation of a cluster to its first Ready phase, by engine",
+		Buckets: prometheus.ExponentialBuckets(15, 2, 10),
+	}, []string{"engine"})
+
+	clustersDesc = prometheus.NewDesc(
+		"dbaas_clusters",
+		"Database clusters by engine and phase",
+		[]string{"engine", "phase"}, nil,
+	)
+)
+
+func init() {
+	metrics.Registry.MustRegister(backupAgeSeconds, backupDurationSeconds, backupSizeBytes, backupHealthy, backupsTotal,
+		reconcileErrorsTotal, opsRequestsTotal, opsRequestDurationSeconds, clusterTimeToReadySeconds)
+}
+
+// RegisterClusterMetrics registers the count of clusters by engine and phase, read from the given
+// reader on every scrape
+func RegisterClusterMetrics(reader client.Reader) error {
+	return metrics.Registry.Register(&clusterCollector{reader: reader})
+}
+
+// clusterCollector counts the DatabaseClusters by engine and phase
+type clusterCollector struct {
+	reader client.Reader
+}
+
+// Describe implements prometheus.Collector
+func (c *clusterCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- clustersDesc
+}
+
+// Collect implements prometheus.Collector
+func (c *clusterCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), clusterListTimeout)
+	defer cancel()
+
+	clusters := &dbaasv1.DatabaseClusterList{}
+	if err := c.reader.List(ctx, clusters); err != nil {
+		ch <- prometheus.NewInvalidMetric(clustersDesc, err)
+		return
+	}
+	counts := make(map[[2]string]int)
+	for _, cluster := range clusters.Items {
+		phase := string(cluster.Status.Phase)
+		if phase == "" {
+			phase = "Unknown"
+		}
+		counts[[2]string{cluster.Spec.Engine.Type, phase}]++
+	}
+	for key, count := range counts {
+		ch <- prometheus.MustNewConstMetric(clustersDesc, prometheus.GaugeValue, float64(count), key[0], key[1])
+	}
+}
+
+// deleteBackupMetrics removes the backup series of a cluster
+func deleteBackupMetrics(cluster *dbaasv1.DatabaseCluster) {
+	for _, gauge := range []*prometheus.GaugeVec{backupAgeSeconds, backupDurationSeconds, backupSizeBytes, backupHealthy} {
+		gauge.DeleteLabelValues(cluster.Namespace, cluster.Name)
+	}
+	backupsTotal.DeletePartialMatch(prometheus.Labels{"namespace": cluster.Namespace, "cluster": cluster.Name})
+}
+
+// recordBackupOutcomes counts the backups that finished since the previous status of the cluster,
+// the success rate being the ratio of the succeeded backups to all of them
+func recordBackupOutcomes(cluster *dbaasv1.DatabaseCluster, status *dbaasv1.DatabaseClusterStatus) {
+	if status.Backup == nil {
+		return
+	}
+	previous := cluster.Status.Backup
+	if previous == nil {
+		previous = &dbaasv1.BackupStatus{}
+	}
+	if name := status.Backup.LastBackupName; name != "" && name != previous.LastBackupName {
+		backupsTotal.WithLabelValues(cluster.Namespace, cluster.Name, "succeeded").Inc()
+	}
+	if name := status.Backup.LastFailedBackupName; name != "" && name != previous.LastFailedBackupName {
+		backupsTotal.WithLabelValues(cluster.Namespace, cluster.Name, "failed").Inc()
+	}
+}
+
+// recordTimeToReady observes the time a new cluster took to become Ready. Clusters coming back
+// to Ready after an update are not new and are not observed.
+func recordTimeToReady(cluster *dbaasv1.DatabaseCluster, previous dbaasv1.ClusterPhase) {
+	if cluster.Status.Phase != dbaasv1.ClusterPhaseReady {
+		return
+	}
+	if previous != "" && previous != dbaasv1.ClusterPhaseInitializing {
+		return
+	}
+	clusterTimeToReadySeconds.WithLabelValues(cluster.Spec.Engine.Type).Observe(time.Since(cluster.CreationTimestamp.Time).Seconds())
+}
+
+// recordOpsRequest counts a finished OpsRequest and observes its duration
+func recordOpsRequest(ops *dbaasv1.OpsRequest) {
+	outcome := ""
+	switch ops.Status.Phase {
+	case dbaasv1.OpsRequestPhaseSucceeded:
+		outcome = "succeeded"
+	case dbaasv1.OpsRequestPhaseFailed:
+		outcome = "failed"
+	default:
+		return
+	}
+	opsType := string(ops.Spec.Type)
+	opsRequestsTotal.WithLabelValues(opsType, outcome).Inc()
+
+	// Requests rejected before they started have no duration
+	if ops.Status.StartTime == nil {
+		return
+	}
+	completion := time.Now()
+	if ops.Status.CompletionTime != nil {
+		completion = ops.Status.CompletionTime.Time
+	}
+	opsRequestDurationSeconds.WithLabelValues(opsType, outcome).Observe(completion.Sub(ops.Status.StartTime.Time).Seconds())
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	batchv1 "k8s.io/api/batch/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -27,11 +28,22 @@ type OpsRequestReconciler struct {
 // +kubebuilder:rbac:groups=dbaas.io,resources=opsrequests,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=dbaas.io,resources=opsrequests/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=dbaas.io,resources=opsrequests/finalizers,verbs=update
+// +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=persistentvolumeclaims,verbs=get;list;watch
+// +kubebuilder:rbac:groups=snapshot.storage.k8s.io,resources=volumesnapshots,verbs=get;list;watch;create;update;patch;delete
 
 // Reconcile is part of the main kubernetes reconciliation loop
-func (r *OpsRequestReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+func (r *OpsRequestReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
 	log := log.FromContext(ctx)
 
+	// Errors are counted by the provider of the engine of the target cluster
+	var engine string
+	defer func() {
+		if err != nil {
+			reconcileErrorsTotal.WithLabelValues("opsrequest", engine).Inc()
+		}
+	}()
+
 	// Fetch the OpsRequest instance
 	ops := &dbaasv1.OpsRequest{}
 	if err := r.Get(ctx, req.NamespacedName, ops); err != nil {
@@ -64,6 +76,7 @@ func (r *OpsRequestReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		log.Error(err, "unable to fetch target DatabaseCluster")
 		return r.updateStatusFailed(ctx, ops, fmt.Sprintf("target cluster not found: %v", err))
 	}
+	engine = cluster.Spec.Engine.Type
 
 	// Get the provider for this engine type
 	prov, err := r.ProviderFactory.GetProvider(cluster.Spec.Engine.Type, r.Client, r.Scheme)
@@ -77,6 +90,12 @@ func (r *OpsRequestReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 
 	// Set status to running if pending
 	if ops.Status.Phase == "" || ops.Status.Phase == dbaasv1.OpsRequestPhasePending {
+		// Reject operations that cannot succeed before touching the cluster
+		if err := r.validateOperation(ctx, cluster, ops); err != nil {
+			log.Info("Rejected operation", "reason", err.Error())
+			return r.updateStatusFailed(ctx, ops, err.Error())
+		}
+
 		ops.Status.Phase = dbaasv1.OpsRequestPhaseRunning
 		ops.Status.StartTime = &metav1.Time{Time: time.Now()}
 		if err := r.Status().Update(ctx, ops); err != nil {
@@ -84,6 +103,27 @@ func (r *OpsRequestReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		}
 	}
 
+	// The pre-backup hooks must all have finished before the backup is started
+	hooks := backupHooks(cluster, ops)
+	var hookLog []dbaasv1.ActionLogEntry
+	if hooks != nil {
+		var done bool
+		hookLog, done, err = r.runBackupHooks(ctx, prov, cluster, ops, preBackupHook, hooks.Pre)
+		if err != nil {
+			log.Error(err, "pre-backup hooks failed")
+			ops.Status.ActionLog = hookLog
+			return r.updateStatusFailed(ctx, ops, err.Error())
+		}
+		if !done {
+			ops.Status.Message = "running pre-backup hooks"
+			ops.Status.ActionLog = hookLog
+			if err := r.Status().Update(ctx, ops); err != nil {
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+		}
+	}
+
 	// Execute the operation based on type
 	if err := r.executeOperation(ctx, opsHandler, cluster, ops); err != nil {
 		log.Error(err, "failed to execute operation")
@@ -97,11 +137,17 @@ func (r *OpsRequestReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
 	}
 
+	// The post-backup hooks run once the backup has finished
+	if hooks != nil {
+		r.finishBackupHooks(ctx, prov, cluster, ops, hooks, hookLog, status)
+	}
+
 	// Update OpsRequest status
 	ops.Status = *status
 	if err := r.Status().Update(ctx, ops); err != nil {
 		return ctrl.Result{}, err
 	}
+	recordOpsRequest(ops)
 
 	// Requeue if still running
 	if status.Phase == dbaasv1.OpsRequestPhaseRunning {
@@ -156,6 +202,7 @@ func (r *OpsRequestReconciler) updateStatusFailed(ctx context.Context, ops *dbaa
 	if err := r.Status().Update(ctx, ops); err != nil {
 		return ctrl.Result{}, err
 	}
+	recordOpsRequest(ops)
 
 	return ctrl.Result{}, nil
 }
@@ -164,5 +211,6 @@ func (r *OpsRequestReconciler) updateStatusFailed(ctx context.Context, ops *dbaa
 func (r *OpsRequestReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&dbaasv1.OpsRequest{}).
+		Owns(&batchv1.Job{}).
 		Complete(r)
 }
@@ -0,0 +1,229 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	dbaasv1 "github.com/huynt0812/dbaas-operator/api/v1"
+	"github.com/huynt0812/dbaas-operator/pkg/provider"
+)
+
+const (
+	// backupHookLabel marks the Jobs running backup hooks, with the OpsRequest name as value
+	backupHookLabel = "dbaas.io/backup-hook"
+
+	// defaultHookTimeout is used when BackupHook.TimeoutSeconds is not set
+	defaultHookTimeout = int64(300)
+
+	preBackupHook  = "pre"
+	postBackupHook = "post"
+)
+
+// backupHooks returns the hooks of a backup operation, the hooks of the OpsRequest replacing those of the cluster
+func backupHooks(cluster *dbaasv1.DatabaseCluster, ops *dbaasv1.OpsRequest) *dbaasv1.BackupHooksSpec {
+	if ops.Spec.Type != dbaasv1.OpsRequestTypeBackup {
+		return nil
+	}
+	if ops.Spec.Backup != nil && ops.Spec.Backup.Hooks != nil {
+		return ops.Spec.Backup.Hooks
+	}
+	if cluster.Spec.Backup != nil {
+		return cluster.Spec.Backup.Hooks
+	}
+	return nil
+}
+
+// validateBackupHooks checks that every hook runs either SQL or a Job
+func validateBackupHooks(hooks *dbaasv1.BackupHooksSpec) error {
+	if hooks == nil {
+		return nil
+	}
+	for _, hook := range append(append([]dbaasv1.BackupHook{}, hooks.Pre...), hooks.Post...) {
+		switch {
+		case hook.SQL != "" && hook.Job != nil:
+			return fmt.Errorf("backup hook %s must set either sql or job, not both", hook.Name)
+		case hook.SQL == "" && hook.Job == nil:
+			return fmt.Errorf("backup hook %s must set sql or job", hook.Name)
+		case hook.Job != nil && len(hook.Job.Template.Spec.Containers) == 0:
+			return fmt.Errorf("job of backup hook %s has no containers", hook.Name)
+		}
+	}
+	return nil
+}
+
+// runBackupHooks runs the hooks of a phase one after the other, each in its own Job. It returns the
+// action log of the hooks started so far and whether they have all finished. A failed hook with the
+// Abort policy stops the sequence with an error.
+func (r *OpsRequestReconciler) runBackupHooks(ctx context.Context, prov provider.Provider, cluster *dbaasv1.DatabaseCluster, ops *dbaasv1.OpsRequest, phase string, hooks []dbaasv1.BackupHook) ([]dbaasv1.ActionLogEntry, bool, error) {
+	var entries []dbaasv1.ActionLogEntry
+	for i, hook := range hooks {
+		action := fmt.Sprintf("%s-backup hook %s", phase, hook.Name)
+
+		job := &batchv1.Job{}
+		err := r.Get(ctx, types.NamespacedName{Name: hookJobName(ops, phase, i), Namespace: ops.Namespace}, job)
+		if errors.IsNotFound(err) {
+			if job, err = r.hookJob(prov, cluster, ops, phase, i, hook); err != nil {
+				return entries, false, err
+			}
+			if err := r.Create(ctx, job); err != nil {
+				return entries, false, err
+			}
+			entries = append(entries, dbaasv1.ActionLogEntry{
+				Timestamp: metav1.Time{Time: time.Now()},
+				Action:    action,
+				Status:    string(dbaasv1.OpsRequestPhaseRunning),
+				Message:   fmt.Sprintf("started job %s", job.Name),
+			})
+			return entries, false, nil
+		} else if err != nil {
+			return entries, false, err
+		}
+
+		finishedAt, failed, finished := jobFinished(job)
+		if !finished {
+			entries = append(entries, dbaasv1.ActionLogEntry{
+				Timestamp: job.CreationTimestamp,
+				Action:    action,
+				Status:    string(dbaasv1.OpsRequestPhaseRunning),
+				Message:   fmt.Sprintf("running job %s", job.Name),
+			})
+			return entries, false, nil
+		}
+
+		output, err := jobOutput(ctx, r.Client, job)
+		if err != nil {
+			return entries, false, err
+		}
+		if !failed {
+			entries = append(entries, dbaasv1.ActionLogEntry{
+				Timestamp: finishedAt,
+				Action:    action,
+				Status:    string(dbaasv1.OpsRequestPhaseSucceeded),
+				Message:   output,
+			})
+			continue
+		}
+
+		// Timed out Jobs are killed before their pod writes any output
+		if output == "" {
+			for _, condition := range job.Status.Conditions {
+				if condition.Type == batchv1.JobFailed {
+					output = condition.Message
+				}
+			}
+		}
+		entries = append(entries, dbaasv1.ActionLogEntry{
+			Timestamp: finishedAt,
+			Action:    action,
+			Status:    string(dbaasv1.OpsRequestPhaseFailed),
+			Message:   output,
+		})
+		if hook.OnFailure != dbaasv1.BackupHookFailurePolicyContinue {
+			return entries, true, fmt.Errorf("%s failed: %s", action, output)
+		}
+	}
+	return entries, true, nil
+}
+
+// finishBackupHooks runs the post hooks once the backup has finished, holding the operation in the
+// Running phase until they complete, and adds the action log of all hooks to the status
+func (r *OpsRequestReconciler) finishBackupHooks(ctx context.Context, prov provider.Provider, cluster *dbaasv1.DatabaseCluster, ops *dbaasv1.OpsRequest, hooks *dbaasv1.BackupHooksSpec, preLog []dbaasv1.ActionLogEntry, status *dbaasv1.OpsRequestStatus) {
+	status.ActionLog = append(preLog, status.ActionLog...)
+	if status.Phase == dbaasv1.OpsRequestPhaseRunning || status.Phase == dbaasv1.OpsRequestPhasePending {
+		return
+	}
+
+	postLog, done, err := r.runBackupHooks(ctx, prov, cluster, ops, postBackupHook, hooks.Post)
+	status.ActionLog = append(status.ActionLog, postLog...)
+	switch {
+	case err != nil:
+		status.Phase = dbaasv1.OpsRequestPhaseFailed
+		status.Message = fmt.Sprintf("%s, %v", status.Message, err)
+	case !done:
+		status.Phase = dbaasv1.OpsRequestPhaseRunning
+		status.CompletionTime = nil
+		status.Message = fmt.Sprintf("%s, running post-backup hooks", status.Message)
+	}
+}
+
+// hookJob builds the Job running a backup hook, either the SQL of the hook on the primary
+// of the cluster or the Job spec of the hook
+func (r *OpsRequestReconciler) hookJob(prov provider.Provider, cluster *dbaasv1.DatabaseCluster, ops *dbaasv1.OpsRequest, phase string, index int, hook dbaasv1.BackupHook) (*batchv1.Job, error) {
+	backupName := ops.Name
+	if ops.Spec.Backup != nil && ops.Spec.Backup.BackupName != "" {
+		backupName = ops.Spec.Backup.BackupName
+	}
+	env := []corev1.EnvVar{
+		{Name: "CLUSTER_NAME", Value: cluster.Name},
+		{Name: "BACKUP_NAME", Value: backupName},
+		{Name: "HOOK_PHASE", Value: phase},
+	}
+
+	var spec batchv1.JobSpec
+	if hook.Job != nil {
+		spec = *hook.Job.DeepCopy()
+		for i := range spec.Template.Spec.Containers {
+			spec.Template.Spec.Containers[i].Env = append(spec.Template.Spec.Containers[i].Env, env...)
+		}
+	} else {
+		container, err := prov.ClientContainer(cluster)
+		if err != nil {
+			return nil, err
+		}
+		container.Name = "hook"
+		container.Env = append(container.Env, env...)
+		container.Env = append(container.Env, corev1.EnvVar{Name: "HOOK_SQL", Value: hook.SQL})
+		container.Command = []string{"sh", "-c", fmt.Sprintf(outputScript, prov.QueryCommand("HOOK_SQL"), maxCheckOutput)}
+		container.TerminationMessagePolicy = corev1.TerminationMessageReadFile
+		spec.Template.Spec.Containers = []corev1.Container{*container}
+	}
+
+	if spec.Template.Spec.RestartPolicy == "" {
+		spec.Template.Spec.RestartPolicy = corev1.RestartPolicyNever
+	}
+	if spec.BackoffLimit == nil {
+		backoffLimit := int32(0)
+		spec.BackoffLimit = &backoffLimit
+	}
+	timeout := defaultHookTimeout
+	if hook.TimeoutSeconds != nil {
+		timeout = *hook.TimeoutSeconds
+	}
+	spec.ActiveDeadlineSeconds = &timeout
+
+	labels := map[string]string{
+		"dbaas.io/cluster": cluster.Name,
+		backupHookLabel:    ops.Name,
+	}
+	if spec.Template.Labels == nil {
+		spec.Template.Labels = make(map[string]string)
+	}
+	for k, v := range labels {
+		spec.Template.Labels[k] = v
+	}
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      hookJobName(ops, phase, index),
+			Namespace: ops.Namespace,
+			Labels:    labels,
+		},
+		Spec: spec,
+	}
+	if err := controllerutil.SetControllerReference(ops, job, r.Scheme); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+func hookJobName(ops *dbaasv1.OpsRequest, phase string, index int) string {
+	return fmt.Sprintf("%s-%s-hook-%d", ops.Name, phase, index)
+}
@@ -0,0 +1,62 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	dbaasv1 "github.com/huynt0812/dbaas-operator/api/v1"
+)
+
+// validateOperation rejects operations that cannot succeed before they are started
+func (r *OpsRequestReconciler) validateOperation(ctx context.Context, cluster *dbaasv1.DatabaseCluster, ops *dbaasv1.OpsRequest) error {
+	switch ops.Spec.Type {
+	case dbaasv1.OpsRequestTypeBackup:
+		return validateBackupHooks(backupHooks(cluster, ops))
+	case dbaasv1.OpsRequestTypeRestore:
+		return r.validateRestore(ctx, cluster, ops)
+	}
+	return nil
+}
+
+// validateRestore checks that the requested point in time lies within the recoverability window
+func (r *OpsRequestReconciler) validateRestore(ctx context.Context, cluster *dbaasv1.DatabaseCluster, ops *dbaasv1.OpsRequest) error {
+	if ops.Spec.Restore == nil || ops.Spec.Restore.PointInTime == nil {
+		return nil
+	}
+	target := ops.Spec.Restore.PointInTime.Time
+	if target.After(time.Now()) {
+		return fmt.Errorf("point in time %s is in the future", target.Format(time.RFC3339))
+	}
+
+	backup := &dbaasv1.DatabaseBackup{}
+	err := r.Get(ctx, types.NamespacedName{Name: ops.Spec.Restore.BackupName, Namespace: ops.Namespace}, backup)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	if err == nil {
+		if backup.Status.CompletedAt != nil && target.Before(backup.Status.CompletedAt.Time) {
+			return fmt.Errorf("point in time %s is before the end of backup %s at %s",
+				target.Format(time.RFC3339), backup.Name, backup.Status.CompletedAt.Format(time.RFC3339))
+		}
+		// The window of the cluster only describes its own backups
+		if backup.Spec.ReadOnly || backup.Spec.ClusterName != cluster.Name {
+			return nil
+		}
+	}
+
+	window := cluster.Status.Backup
+	if window == nil || window.FirstRecoverabilityPoint == nil || window.LastRecoverabilityPoint == nil {
+		return fmt.Errorf("cluster %s has no recoverability window yet", cluster.Name)
+	}
+	if target.Before(window.FirstRecoverabilityPoint.Time) || target.After(window.LastRecoverabilityPoint.Time) {
+		return fmt.Errorf("point in time %s is outside the recoverability window %s - %s",
+			target.Format(time.RFC3339),
+			window.FirstRecoverabilityPoint.Format(time.RFC3339),
+			window.LastRecoverabilityPoint.Format(time.RFC3339))
+	}
+	return nil
+}
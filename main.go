@@ -13,11 +13,14 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 
 	cnpgv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
 	dbaasv1 "github.com/huynt0812/dbaas-operator/api/v1"
 	"github.com/huynt0812/dbaas-operator/controllers"
 	"github.com/huynt0812/dbaas-operator/pkg/provider"
+	snapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v7/apis/volumesnapshot/v1"
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
 )
 
 var (
@@ -29,6 +32,8 @@ func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 	utilruntime.Must(dbaasv1.AddToScheme(scheme))
 	utilruntime.Must(cnpgv1.AddToScheme(scheme))
+	utilruntime.Must(monitoringv1.AddToScheme(scheme))
+	utilruntime.Must(snapshotv1.AddToScheme(scheme))
 }
 
 func main() {
@@ -51,6 +56,7 @@ func main() {
 
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme:                 scheme,
+		Metrics:                metricsserver.Options{BindAddress: metricsAddr},
 		HealthProbeBindAddress: probeAddr,
 		LeaderElection:         enableLeaderElection,
 		LeaderElectionID:       "dbaas-operator.dbaas.io",
@@ -60,6 +66,12 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Register the count of clusters by engine and phase
+	if err := controllers.RegisterClusterMetrics(mgr.GetClient()); err != nil {
+		setupLog.Error(err, "unable to register cluster metrics")
+		os.Exit(1)
+	}
+
 	// Create provider factory
 	providerFactory := provider.NewProviderFactory()
 
@@ -68,6 +80,7 @@ func main() {
 		Client:          mgr.GetClient(),
 		Scheme:          mgr.GetScheme(),
 		ProviderFactory: providerFactory,
+		Recorder:        mgr.GetEventRecorderFor("databasecluster-controller"),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "DatabaseCluster")
 		os.Exit(1)
@@ -83,6 +96,25 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Setup BackupStorage controller
+	if err = (&controllers.BackupStorageReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "BackupStorage")
+		os.Exit(1)
+	}
+
+	// Setup BackupVerification controller
+	if err = (&controllers.BackupVerificationReconciler{
+		Client:          mgr.GetClient(),
+		Scheme:          mgr.GetScheme(),
+		ProviderFactory: providerFactory,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "BackupVerification")
+		os.Exit(1)
+	}
+
 	// Add health and ready checks
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
@@ -0,0 +1,210 @@
+package backupstorage
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	dbaasv1 "github.com/huynt0812/dbaas-operator/api/v1"
+)
+
+// barmanInfoFile is the metadata file written by barman-cloud next to every base backup,
+// laid out as <destinationPath>/<serverName>/base/<backupID>/backup.info. Logical backups
+// write the same file under <destinationPath>/<serverName>/logical/<backupID>.
+const barmanInfoFile = "backup.info"
+
+// backupDirectories maps the directories scanned in each server to the method of the backups they hold
+var backupDirectories = []struct {
+	name   string
+	method dbaasv1.BackupMethod
+}{
+	{name: "base", method: dbaasv1.BackupMethodObjectStore},
+	{name: "logical", method: dbaasv1.BackupMethodLogical},
+}
+
+// barmanTimeLayouts are the timestamp formats found in barman backup.info files
+var barmanTimeLayouts = []string{
+	"2006-01-02 15:04:05.999999-07:00",
+	"2006-01-02 15:04:05-07:00",
+	"Mon Jan 2 15:04:05 2006",
+}
+
+// CatalogEntry describes a backup found in a backup storage
+type CatalogEntry struct {
+	// ServerName is the name the backup was archived under, normally the source cluster name
+	ServerName string
+
+	// BackupID is the identifier of the backup within the server directory
+	BackupID string
+
+	// EngineType is the database engine the backup belongs to
+	EngineType string
+
+	// EngineVersion is the database version the backup was taken with
+	EngineVersion string
+
+	// DestinationPath is the storage root the backup was found in
+	DestinationPath string
+
+	// BeginTime is when the backup started
+	BeginTime *time.Time
+
+	// EndTime is when the backup completed
+	EndTime *time.Time
+
+	// Size is the backup size in bytes
+	Size int64
+
+	// Method is how the backup was taken: a base backup, or a logical dump
+	Method dbaasv1.BackupMethod
+
+	// Phase is the phase of the backup after its barman status: Succeeded once DONE,
+	// Running while barman-cloud is still taking it or waiting for its WALs, Failed otherwise
+	Phase dbaasv1.DatabaseBackupPhase
+
+	// Databases lists the databases dumped by a logical backup
+	Databases []string
+}
+
+// Discover scans the storage layout and returns every backup it finds. Only the base and logical
+// directories of each server are listed, the archived WALs next to them are never walked.
+func Discover(ctx context.Context, store Store, storage *dbaasv1.BackupStorage) ([]CatalogEntry, error) {
+	destinationPath, err := DestinationPath(storage)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := Prefix(storage)
+	if prefix != "" {
+		prefix += "/"
+	}
+
+	servers, err := store.ListPrefixes(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []CatalogEntry
+	for _, server := range servers {
+		for _, directory := range backupDirectories {
+			objects, err := store.List(ctx, server+directory.name+"/")
+			if err != nil {
+				return nil, err
+			}
+
+			for _, object := range objects {
+				serverName, backupID, ok := parseBackupInfoKey(strings.TrimPrefix(object.Key, prefix), directory.name)
+				if !ok {
+					continue
+				}
+
+				content, err := store.Get(ctx, object.Key)
+				if err != nil {
+					return nil, err
+				}
+
+				entry, err := ParseBackupInfo(content)
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse %s: %w", object.Key, err)
+				}
+				entry.ServerName = serverName
+				entry.BackupID = backupID
+				entry.DestinationPath = destinationPath
+				entry.Method = directory.method
+				entries = append(entries, entry)
+			}
+		}
+	}
+
+	return entries, nil
+}
+
+// parseBackupInfoKey extracts the server name and backup ID from a key
+// of the form <serverName>/<directory>/<backupID>/backup.info
+func parseBackupInfoKey(key, directory string) (serverName, backupID string, ok bool) {
+	parts := strings.Split(key, "/")
+	if len(parts) != 4 || parts[1] != directory || parts[3] != barmanInfoFile {
+		return "", "", false
+	}
+	if parts[0] == "" || parts[2] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[2], true
+}
+
+// ParseBackupInfo reads the key=value pairs of a barman backup.info file
+func ParseBackupInfo(content []byte) (CatalogEntry, error) {
+	entry := CatalogEntry{
+		EngineType: "postgresql",
+		Phase:      dbaasv1.DatabaseBackupPhaseFailed,
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(string(content)))
+	for scanner.Scan() {
+		key, value, found := strings.Cut(scanner.Text(), "=")
+		if !found {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if value == "" || value == "None" {
+			continue
+		}
+
+		switch strings.TrimSpace(key) {
+		case "backup_id":
+			entry.BackupID = value
+		case "begin_time":
+			entry.BeginTime = parseBarmanTime(value)
+		case "end_time":
+			entry.EndTime = parseBarmanTime(value)
+		case "size":
+			size, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return entry, fmt.Errorf("invalid size %q: %w", value, err)
+			}
+			entry.Size = size
+		case "version":
+			entry.EngineVersion = postgresVersion(value)
+		case "status":
+			entry.Phase = barmanPhase(value)
+		case "databases":
+			entry.Databases = strings.Fields(value)
+		}
+	}
+
+	return entry, scanner.Err()
+}
+
+// barmanPhase maps the status of a barman backup to the phase of its DatabaseBackup
+func barmanPhase(status string) dbaasv1.DatabaseBackupPhase {
+	switch status {
+	case "DONE":
+		return dbaasv1.DatabaseBackupPhaseSucceeded
+	case "STARTED", "WAITING_FOR_WALS", "SYNCING":
+		return dbaasv1.DatabaseBackupPhaseRunning
+	default:
+		return dbaasv1.DatabaseBackupPhaseFailed
+	}
+}
+
+// parseBarmanTime parses a barman timestamp, returning nil for unknown formats
+func parseBarmanTime(value string) *time.Time {
+	for _, layout := range barmanTimeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t
+		}
+	}
+	return nil
+}
+
+// postgresVersion converts a server_version_num such as 160002 to "16.2"
+func postgresVersion(value string) string {
+	num, err := strconv.Atoi(value)
+	if err != nil || num < 100000 {
+		return value
+	}
+	return fmt.Sprintf("%d.%d", num/10000, num%10000)
+}
@@ -0,0 +1,76 @@
+package backupstorage
+
+import (
+	"fmt"
+	"time"
+
+	dbaasv1 "github.com/huynt0812/dbaas-operator/api/v1"
+)
+
+// ValidateEncryption checks that the encryption settings are supported by the storage type
+func ValidateEncryption(storage *dbaasv1.BackupStorage) error {
+	encryption := storage.Spec.Encryption
+	if encryption == nil {
+		return nil
+	}
+
+	switch encryption.Mode {
+	case dbaasv1.BackupEncryptionSSES3, dbaasv1.BackupEncryptionSSEKMS:
+		if storage.Spec.Type != "s3" {
+			return fmt.Errorf("encryption mode %s requires an s3 storage, got %s", encryption.Mode, storage.Spec.Type)
+		}
+		if encryption.Mode == dbaasv1.BackupEncryptionSSES3 && encryption.KMSKeyID != "" {
+			return fmt.Errorf("kmsKeyID is only used with encryption mode %s", dbaasv1.BackupEncryptionSSEKMS)
+		}
+	default:
+		return fmt.Errorf("unsupported encryption mode: %s", encryption.Mode)
+	}
+	return nil
+}
+
+// CurrentKey returns the key new backups written to the storage are encrypted with.
+// A nil key means backups are not encrypted by the operator.
+func CurrentKey(storage *dbaasv1.BackupStorage) (*dbaasv1.BackupEncryptionKey, error) {
+	if err := ValidateEncryption(storage); err != nil {
+		return nil, err
+	}
+
+	encryption := storage.Spec.Encryption
+	if encryption == nil {
+		return nil, nil
+	}
+
+	key := &dbaasv1.BackupEncryptionKey{Mode: encryption.Mode}
+	switch encryption.Mode {
+	case dbaasv1.BackupEncryptionSSES3:
+		key.KeyID = "AES256"
+	case dbaasv1.BackupEncryptionSSEKMS:
+		// An empty key ID stands for the default KMS key of the bucket
+		key.KeyID = encryption.KMSKeyID
+	}
+	return key, nil
+}
+
+// KeyAt returns the key that was active in the storage at the given time, or nil if backups were not encrypted
+func KeyAt(storage *dbaasv1.BackupStorage, t time.Time) *dbaasv1.BackupEncryptionKey {
+	var key *dbaasv1.BackupEncryptionKey
+	for i := range storage.Status.EncryptionKeys {
+		record := &storage.Status.EncryptionKeys[i]
+		if record.ActiveSince.Time.After(t) {
+			break
+		}
+		if record.RetiredAt != nil && !record.RetiredAt.Time.After(t) {
+			continue
+		}
+		key = record.BackupEncryptionKey.DeepCopy()
+	}
+	return key
+}
+
+// SameKey reports whether two keys are the same, treating nil as no encryption
+func SameKey(a, b *dbaasv1.BackupEncryptionKey) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Mode == b.Mode && a.KeyID == b.KeyID
+}
@@ -0,0 +1,89 @@
+package backupstorage
+
+import (
+	"fmt"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+
+	dbaasv1 "github.com/huynt0812/dbaas-operator/api/v1"
+)
+
+// FileMountPath is where file-based storages are mounted in backup Jobs.
+// Backups use the same layout as the object stores: <serverName>/base/<backupID>/backup.info
+const FileMountPath = "/backup"
+
+// IsFileStorage reports whether the storage is a filesystem mounted into backup Jobs
+// rather than an object store accessed over the network
+func IsFileStorage(storage *dbaasv1.BackupStorage) bool {
+	return storage.Spec.Type == "nfs" || storage.Spec.Type == "local"
+}
+
+// FileVolume returns the volume exposing a file-based storage to a pod
+func FileVolume(storage *dbaasv1.BackupStorage, name string) (corev1.Volume, error) {
+	volume := corev1.Volume{Name: name}
+	switch storage.Spec.Type {
+	case "nfs":
+		if storage.Spec.NFS == nil {
+			return volume, fmt.Errorf("nfs configuration is required for storage type nfs")
+		}
+		volume.NFS = &corev1.NFSVolumeSource{
+			Server: storage.Spec.NFS.Server,
+			Path:   storage.Spec.NFS.Path,
+		}
+	case "local":
+		if storage.Spec.Local == nil {
+			return volume, fmt.Errorf("local configuration is required for storage type local")
+		}
+		volume.PersistentVolumeClaim = &corev1.PersistentVolumeClaimVolumeSource{
+			ClaimName: storage.Spec.Local.ClaimName,
+		}
+	default:
+		return volume, fmt.Errorf("storage type %s is not file-based", storage.Spec.Type)
+	}
+	return volume, nil
+}
+
+// FileLocation returns a URL describing the root of a file-based storage
+func FileLocation(storage *dbaasv1.BackupStorage) string {
+	switch {
+	case storage.Spec.Type == "nfs" && storage.Spec.NFS != nil:
+		return fmt.Sprintf("nfs://%s%s", storage.Spec.NFS.Server, storage.Spec.NFS.Path)
+	case storage.Spec.Type == "local" && storage.Spec.Local != nil:
+		return fmt.Sprintf("pvc://%s/%s", storage.Namespace, storage.Spec.Local.ClaimName)
+	}
+	return ""
+}
+
+// Location returns a URL describing the root of any storage
+func Location(storage *dbaasv1.BackupStorage) string {
+	if IsFileStorage(storage) {
+		return FileLocation(storage)
+	}
+	destinationPath, err := DestinationPath(storage)
+	if err != nil {
+		return ""
+	}
+	return destinationPath
+}
+
+// RetentionDays converts a retention policy such as "30d", "4w" or "3m" to a number of days
+func RetentionDays(policy string) (int, error) {
+	if len(policy) < 2 {
+		return 0, fmt.Errorf("invalid retention policy %q", policy)
+	}
+	n, err := strconv.Atoi(policy[:len(policy)-1])
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid retention policy %q", policy)
+	}
+	switch policy[len(policy)-1] {
+	case 'd':
+		return n, nil
+	case 'w':
+		return n * 7, nil
+	case 'm':
+		return n * 30, nil
+	default:
+		return 0, fmt.Errorf("invalid retention policy %q", policy)
+	}
+}
@@ -0,0 +1,240 @@
+package backupstorage
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	dbaasv1 "github.com/huynt0812/dbaas-operator/api/v1"
+)
+
+// Annotations and labels read by the workload identity webhooks of each cloud
+const (
+	AWSRoleARNAnnotation        = "eks.amazonaws.com/role-arn"
+	GCPServiceAccountAnnotation = "iam.gke.io/gcp-service-account"
+	AzureClientIDAnnotation     = "azure.workload.identity/client-id"
+	AzureTenantIDAnnotation     = "azure.workload.identity/tenant-id"
+	AzureUseLabel               = "azure.workload.identity/use"
+)
+
+const (
+	// awsTokenFileEnv is set by the EKS pod identity webhook to the projected token of the pod
+	awsTokenFileEnv = "AWS_WEB_IDENTITY_TOKEN_FILE"
+
+	// defaultAWSTokenFile is where the EKS pod identity webhook projects the token
+	defaultAWSTokenFile = "/var/run/secrets/eks.amazonaws.com/serviceaccount/token"
+
+	// defaultSTSEndpoint is the global AWS STS endpoint
+	defaultSTSEndpoint = "https://sts.amazonaws.com"
+)
+
+// UsesWorkloadIdentity reports whether the storage is accessed with a workload identity
+func UsesWorkloadIdentity(storage *dbaasv1.BackupStorage) bool {
+	return storage.Spec.WorkloadIdentity != nil
+}
+
+// ValidateWorkloadIdentity checks that the workload identity matches the storage type and names the cloud identity
+func ValidateWorkloadIdentity(storage *dbaasv1.BackupStorage) error {
+	identity := storage.Spec.WorkloadIdentity
+	if identity == nil {
+		return nil
+	}
+	if storage.Spec.CredentialsSecretRef != nil {
+		return fmt.Errorf("credentialsSecretRef and workloadIdentity are mutually exclusive")
+	}
+
+	switch identity.Provider {
+	case dbaasv1.WorkloadIdentityAWS:
+		if storage.Spec.Type != "s3" {
+			return fmt.Errorf("workload identity provider %s requires an s3 storage, got %s", identity.Provider, storage.Spec.Type)
+		}
+		if identity.RoleARN == "" {
+			return fmt.Errorf("workload identity provider %s requires roleARN", identity.Provider)
+		}
+	case dbaasv1.WorkloadIdentityGCP:
+		if storage.Spec.Type != "gcs" {
+			return fmt.Errorf("workload identity provider %s requires a gcs storage, got %s", identity.Provider, storage.Spec.Type)
+		}
+		if identity.GCPServiceAccount == "" {
+			return fmt.Errorf("workload identity provider %s requires gcpServiceAccount", identity.Provider)
+		}
+	case dbaasv1.WorkloadIdentityAzure:
+		if storage.Spec.Type != "azure" {
+			return fmt.Errorf("workload identity provider %s requires an azure storage, got %s", identity.Provider, storage.Spec.Type)
+		}
+		if identity.ClientID == "" {
+			return fmt.Errorf("workload identity provider %s requires clientID", identity.Provider)
+		}
+	default:
+		return fmt.Errorf("unsupported workload identity provider: %s", identity.Provider)
+	}
+	return nil
+}
+
+// ServiceAccountAnnotations returns the annotations binding a ServiceAccount to the identity of the storage
+func ServiceAccountAnnotations(storage *dbaasv1.BackupStorage) map[string]string {
+	identity := storage.Spec.WorkloadIdentity
+	if identity == nil {
+		return nil
+	}
+
+	switch identity.Provider {
+	case dbaasv1.WorkloadIdentityAWS:
+		return map[string]string{AWSRoleARNAnnotation: identity.RoleARN}
+	case dbaasv1.WorkloadIdentityGCP:
+		return map[string]string{GCPServiceAccountAnnotation: identity.GCPServiceAccount}
+	case dbaasv1.WorkloadIdentityAzure:
+		annotations := map[string]string{AzureClientIDAnnotation: identity.ClientID}
+		if identity.TenantID != "" {
+			annotations[AzureTenantIDAnnotation] = identity.TenantID
+		}
+		return annotations
+	}
+	return nil
+}
+
+// PodLabels returns the labels the pods using the identity of the storage need
+func PodLabels(storage *dbaasv1.BackupStorage) map[string]string {
+	identity := storage.Spec.WorkloadIdentity
+	if identity == nil || identity.Provider != dbaasv1.WorkloadIdentityAzure {
+		return nil
+	}
+	// The Azure webhook only injects the token into pods that opt in
+	return map[string]string{AzureUseLabel: "true"}
+}
+
+// ServiceAccountName returns the ServiceAccount used by the Jobs of the storage itself
+func ServiceAccountName(storage *dbaasv1.BackupStorage) string {
+	if identity := storage.Spec.WorkloadIdentity; identity != nil && identity.ServiceAccountName != "" {
+		return identity.ServiceAccountName
+	}
+	return storage.Name
+}
+
+// BindPodIdentity runs the pods of a template with a ServiceAccount bound to the identity of the storage
+func BindPodIdentity(template *corev1.PodTemplateSpec, serviceAccountName string, storage *dbaasv1.BackupStorage) {
+	if !UsesWorkloadIdentity(storage) {
+		return
+	}
+	template.Spec.ServiceAccountName = serviceAccountName
+	for k, v := range PodLabels(storage) {
+		if template.Labels == nil {
+			template.Labels = make(map[string]string)
+		}
+		template.Labels[k] = v
+	}
+}
+
+// TokenFile returns the token projected into the operator pod for the workload identity of the storage,
+// or an empty path when the operator does not read the storage itself
+func TokenFile(storage *dbaasv1.BackupStorage) string {
+	identity := storage.Spec.WorkloadIdentity
+	if identity == nil || identity.Provider != dbaasv1.WorkloadIdentityAWS {
+		return ""
+	}
+	if path := os.Getenv(awsTokenFileEnv); path != "" {
+		return path
+	}
+	return defaultAWSTokenFile
+}
+
+// ReadToken reads a projected ServiceAccount token and checks that it is a JWT that has not expired
+func ReadToken(path string, now time.Time) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read token file: %w", err)
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", fmt.Errorf("token file %s is empty", path)
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", fmt.Errorf("token in %s is not a JWT", path)
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", fmt.Errorf("token in %s has an invalid payload: %w", path, err)
+	}
+	claims := struct {
+		Expiry int64 `json:"exp"`
+	}{}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return "", fmt.Errorf("token in %s has an invalid payload: %w", path, err)
+	}
+	if claims.Expiry != 0 && !now.Before(time.Unix(claims.Expiry, 0)) {
+		return "", fmt.Errorf("token in %s expired at %s", path, time.Unix(claims.Expiry, 0).UTC().Format(time.RFC3339))
+	}
+	return token, nil
+}
+
+// assumeRoleResult is the subset of the AssumeRoleWithWebIdentity response used by the store
+type assumeRoleResult struct {
+	Credentials struct {
+		AccessKeyID     string `xml:"AccessKeyId"`
+		SecretAccessKey string `xml:"SecretAccessKey"`
+		SessionToken    string `xml:"SessionToken"`
+	} `xml:"AssumeRoleWithWebIdentityResult>Credentials"`
+}
+
+// webIdentityCredentials exchanges the projected token of the operator for temporary
+// credentials of the role of the storage, in the format of the credentials secret
+func webIdentityCredentials(ctx context.Context, httpClient *http.Client, storage *dbaasv1.BackupStorage) (map[string]string, error) {
+	identity := storage.Spec.WorkloadIdentity
+	token, err := ReadToken(TokenFile(storage), time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := identity.STSEndpoint
+	if endpoint == "" {
+		endpoint = defaultSTSEndpoint
+	}
+	query := url.Values{}
+	query.Set("Action", "AssumeRoleWithWebIdentity")
+	query.Set("Version", "2011-06-15")
+	query.Set("RoleArn", identity.RoleARN)
+	query.Set("RoleSessionName", "dbaas-operator")
+	query.Set("WebIdentityToken", token)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(query.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to assume role %s: %w", identity.RoleARN, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to assume role %s: unexpected status %s: %s", identity.RoleARN, resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	result := assumeRoleResult{}
+	if err := xml.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode AssumeRoleWithWebIdentity response: %w", err)
+	}
+	return map[string]string{
+		S3AccessKeyIDKey:     result.Credentials.AccessKeyID,
+		S3SecretAccessKeyKey: result.Credentials.SecretAccessKey,
+		S3SessionTokenKey:    result.Credentials.SessionToken,
+	}, nil
+}
@@ -0,0 +1,94 @@
+package backupstorage
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeToken builds an unsigned JWT with the given payload
+func fakeToken(payload string) string {
+	encode := base64.RawURLEncoding.EncodeToString
+	return encode([]byte(`{"alg":"RS256"}`)) + "." + encode([]byte(payload)) + ".signature"
+}
+
+func TestReadToken(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	valid := fakeToken(`{"sub":"system:serviceaccount:db:operator","exp":1717250400}`)
+
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		wantErr string
+	}{
+		{
+			name:    "valid",
+			content: valid + "\n",
+			want:    valid,
+		},
+		{
+			name:    "valid without expiry",
+			content: fakeToken(`{"sub":"system:serviceaccount:db:operator"}`),
+			want:    fakeToken(`{"sub":"system:serviceaccount:db:operator"}`),
+		},
+		{
+			name:    "expired",
+			content: fakeToken(`{"exp":1717243200}`),
+			wantErr: "expired at 2024-06-01T12:00:00Z",
+		},
+		{
+			name:    "empty",
+			content: "  \n",
+			wantErr: "is empty",
+		},
+		{
+			name:    "not a JWT",
+			content: "not-a-token",
+			wantErr: "is not a JWT",
+		},
+		{
+			name:    "payload not base64",
+			content: "header.!!!.signature",
+			wantErr: "invalid payload",
+		},
+		{
+			name:    "payload not JSON",
+			content: fakeToken(`not json`),
+			wantErr: "invalid payload",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "token")
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := ReadToken(path, now)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ReadToken() error = %v, want an error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReadToken() unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ReadToken() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadTokenMissingFile(t *testing.T) {
+	_, err := ReadToken(filepath.Join(t.TempDir(), "missing"), time.Now())
+	if err == nil || !strings.Contains(err.Error(), "failed to read token file") {
+		t.Fatalf("ReadToken() error = %v, want a read error", err)
+	}
+}
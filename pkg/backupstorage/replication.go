@@ -0,0 +1,188 @@
+package backupstorage
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	dbaasv1 "github.com/huynt0812/dbaas-operator/api/v1"
+)
+
+// ReplicationImage is the image running the copy between storages
+const ReplicationImage = "rclone/rclone:1.66"
+
+// remote is an rclone remote configured through environment variables
+type remote struct {
+	name    string
+	path    string
+	env     []corev1.EnvVar
+	volumes []corev1.Volume
+	mounts  []corev1.VolumeMount
+	noTLS   bool
+}
+
+// ReplicationJob builds the Job copying the content of a storage to a secondary storage.
+// Copies are incremental, so every pass only transfers the backups and WAL files added since the last one.
+func ReplicationJob(source, secondary *dbaasv1.BackupStorage) (*batchv1.Job, error) {
+	src, err := rcloneRemote("src", source)
+	if err != nil {
+		return nil, err
+	}
+	dst, err := rcloneRemote("dst", secondary)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{"copy", "--fast-list", "--checksum"}
+	if src.noTLS || dst.noTLS {
+		args = append(args, "--no-check-certificate")
+	}
+	args = append(args, src.path, dst.path)
+
+	backoffLimit := int32(1)
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: source.Namespace,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					Containers: []corev1.Container{
+						{
+							Name:         "replicate",
+							Image:        ReplicationImage,
+							Args:         args,
+							Env:          append(src.env, dst.env...),
+							VolumeMounts: append(src.mounts, dst.mounts...),
+						},
+					},
+					Volumes: append(src.volumes, dst.volumes...),
+				},
+			},
+		},
+	}
+
+	// A pod runs with a single ServiceAccount, which must carry the identities of both storages
+	for _, storage := range []*dbaasv1.BackupStorage{secondary, source} {
+		if UsesWorkloadIdentity(storage) {
+			if name := job.Spec.Template.Spec.ServiceAccountName; name != "" && name != ServiceAccountName(storage) {
+				return nil, fmt.Errorf("storages %s and %s use workload identities with different service accounts", source.Name, secondary.Name)
+			}
+			BindPodIdentity(&job.Spec.Template, ServiceAccountName(storage), storage)
+		}
+	}
+	return job, nil
+}
+
+// rcloneRemote builds the rclone remote pointing at the root of a storage
+func rcloneRemote(name string, storage *dbaasv1.BackupStorage) (*remote, error) {
+	r := &remote{name: name, noTLS: !storage.Spec.VerifyTLS}
+	prefix := "RCLONE_CONFIG_" + strings.ToUpper(name) + "_"
+	setEnv := func(key, value string) {
+		r.env = append(r.env, corev1.EnvVar{Name: prefix + key, Value: value})
+	}
+	setSecretEnv := func(key, secretKey string, optional bool) error {
+		// Workload identities are picked up by rclone from the environment injected by the cloud webhook
+		if UsesWorkloadIdentity(storage) {
+			return nil
+		}
+		if storage.Spec.CredentialsSecretRef == nil {
+			if optional {
+				return nil
+			}
+			return fmt.Errorf("storage %s requires credentialsSecretRef", storage.Name)
+		}
+		r.env = append(r.env, corev1.EnvVar{
+			Name: prefix + key,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: *storage.Spec.CredentialsSecretRef,
+					Key:                  secretKey,
+					Optional:             &optional,
+				},
+			},
+		})
+		return nil
+	}
+
+	if err := ValidateWorkloadIdentity(storage); err != nil {
+		return nil, err
+	}
+	if UsesWorkloadIdentity(storage) {
+		setEnv("ENV_AUTH", "true")
+	}
+
+	switch storage.Spec.Type {
+	case "s3":
+		if storage.Spec.S3 == nil {
+			return nil, fmt.Errorf("storage %s has no s3 configuration", storage.Name)
+		}
+		setEnv("TYPE", "s3")
+		setEnv("PROVIDER", "Other")
+		setEnv("ENDPOINT", storage.Spec.S3.Endpoint)
+		setEnv("REGION", storage.Spec.S3.Region)
+		setEnv("NO_CHECK_BUCKET", "true")
+		if err := setSecretEnv("ACCESS_KEY_ID", S3AccessKeyIDKey, false); err != nil {
+			return nil, err
+		}
+		if err := setSecretEnv("SECRET_ACCESS_KEY", S3SecretAccessKeyKey, false); err != nil {
+			return nil, err
+		}
+		if err := setSecretEnv("SESSION_TOKEN", S3SessionTokenKey, true); err != nil {
+			return nil, err
+		}
+		// Copies written to a storage follow its own server-side encryption settings
+		if encryption := storage.Spec.Encryption; encryption != nil {
+			switch encryption.Mode {
+			case dbaasv1.BackupEncryptionSSES3:
+				setEnv("SERVER_SIDE_ENCRYPTION", "AES256")
+			case dbaasv1.BackupEncryptionSSEKMS:
+				setEnv("SERVER_SIDE_ENCRYPTION", "aws:kms")
+				if encryption.KMSKeyID != "" {
+					setEnv("SSE_KMS_KEY_ID", encryption.KMSKeyID)
+				}
+			}
+		}
+		r.path = name + ":" + path.Join(storage.Spec.S3.Bucket, storage.Spec.S3.Prefix)
+	case "gcs":
+		if storage.Spec.GCS == nil {
+			return nil, fmt.Errorf("storage %s has no gcs configuration", storage.Name)
+		}
+		setEnv("TYPE", "google cloud storage")
+		setEnv("BUCKET_POLICY_ONLY", "true")
+		if err := setSecretEnv("SERVICE_ACCOUNT_CREDENTIALS", GCSApplicationCredentialsKey, false); err != nil {
+			return nil, err
+		}
+		r.path = name + ":" + path.Join(storage.Spec.GCS.Bucket, storage.Spec.GCS.Prefix)
+	case "azure":
+		if storage.Spec.Azure == nil {
+			return nil, fmt.Errorf("storage %s has no azure configuration", storage.Name)
+		}
+		setEnv("TYPE", "azureblob")
+		setEnv("ACCOUNT", storage.Spec.Azure.StorageAccount)
+		if err := setSecretEnv("KEY", AzureStorageKeyKey, false); err != nil {
+			return nil, err
+		}
+		r.path = name + ":" + path.Join(storage.Spec.Azure.Container, storage.Spec.Azure.Prefix)
+	case "nfs", "local":
+		volume, err := FileVolume(storage, name)
+		if err != nil {
+			return nil, err
+		}
+		mountPath := "/mnt/" + name
+		r.volumes = append(r.volumes, volume)
+		r.mounts = append(r.mounts, corev1.VolumeMount{Name: name, MountPath: mountPath})
+		setEnv("TYPE", "local")
+		r.path = name + ":" + mountPath
+	default:
+		return nil, fmt.Errorf("replication is not supported for storage type %s", storage.Spec.Type)
+	}
+
+	return r, nil
+}
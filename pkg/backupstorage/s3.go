@@ -0,0 +1,284 @@
+package backupstorage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	dbaasv1 "github.com/huynt0812/dbaas-operator/api/v1"
+)
+
+const (
+	s3DefaultRegion = "us-east-1"
+	s3Service       = "s3"
+	s3TimeFormat    = "20060102T150405Z"
+	s3DateFormat    = "20060102"
+	emptyPayloadSHA = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+)
+
+// s3Store implements Store for S3-compatible object storages using path-style requests
+type s3Store struct {
+	endpoint     *url.URL
+	bucket       string
+	region       string
+	accessKey    string
+	secretKey    string
+	sessionToken string
+	httpClient   *http.Client
+}
+
+// listBucketResult is the subset of the ListObjectsV2 response used by the store
+type listBucketResult struct {
+	Contents []struct {
+		Key          string    `xml:"Key"`
+		Size         int64     `xml:"Size"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+	CommonPrefixes []struct {
+		Prefix string `xml:"Prefix"`
+	} `xml:"CommonPrefixes"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+func newS3Store(spec *dbaasv1.S3StorageSpec, credentials map[string]string, verifyTLS bool) (*s3Store, error) {
+	endpoint, err := url.Parse(spec.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid s3 endpoint %q: %w", spec.Endpoint, err)
+	}
+	if endpoint.Scheme == "" || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid s3 endpoint %q: scheme and host are required", spec.Endpoint)
+	}
+
+	region := spec.Region
+	if region == "" {
+		region = s3DefaultRegion
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if !verifyTLS {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true} //nolint:gosec
+	}
+
+	return &s3Store{
+		endpoint:     endpoint,
+		bucket:       spec.Bucket,
+		region:       region,
+		accessKey:    credentials[S3AccessKeyIDKey],
+		secretKey:    credentials[S3SecretAccessKeyKey],
+		sessionToken: credentials[S3SessionTokenKey],
+		httpClient:   &http.Client{Transport: transport, Timeout: 60 * time.Second},
+	}, nil
+}
+
+// List returns all objects whose key starts with prefix, following pagination
+func (s *s3Store) List(ctx context.Context, prefix string) ([]Object, error) {
+	return s.ListAfter(ctx, prefix, "")
+}
+
+// ListAfter returns the objects whose key starts with prefix and sorts after startAfter, following pagination
+func (s *s3Store) ListAfter(ctx context.Context, prefix, startAfter string) ([]Object, error) {
+	objects, _, err := s.list(ctx, prefix, startAfter, "")
+	return objects, err
+}
+
+// ListPrefixes returns the key prefixes one level below prefix, following pagination
+func (s *s3Store) ListPrefixes(ctx context.Context, prefix string) ([]string, error) {
+	_, prefixes, err := s.list(ctx, prefix, "", "/")
+	return prefixes, err
+}
+
+// list sends ListObjectsV2 requests until the listing is complete. With a delimiter, the keys
+// containing it after the prefix are rolled up into the returned common prefixes.
+func (s *s3Store) list(ctx context.Context, prefix, startAfter, delimiter string) ([]Object, []string, error) {
+	var objects []Object
+	var prefixes []string
+	continuationToken := ""
+
+	for {
+		query := url.Values{}
+		query.Set("list-type", "2")
+		query.Set("prefix", prefix)
+		if startAfter != "" {
+			query.Set("start-after", startAfter)
+		}
+		if delimiter != "" {
+			query.Set("delimiter", delimiter)
+		}
+		if continuationToken != "" {
+			query.Set("continuation-token", continuationToken)
+		}
+
+		body, err := s.do(ctx, "", query)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to list objects with prefix %q: %w", prefix, err)
+		}
+
+		result := listBucketResult{}
+		if err := xml.Unmarshal(body, &result); err != nil {
+			return nil, nil, fmt.Errorf("failed to decode object listing: %w", err)
+		}
+
+		for _, content := range result.Contents {
+			objects = append(objects, Object{
+				Key:          content.Key,
+				Size:         content.Size,
+				LastModified: content.LastModified,
+			})
+		}
+		for _, commonPrefix := range result.CommonPrefixes {
+			prefixes = append(prefixes, commonPrefix.Prefix)
+		}
+
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return objects, prefixes, nil
+		}
+		continuationToken = result.NextContinuationToken
+	}
+}
+
+// Get returns the content of the object stored at key
+func (s *s3Store) Get(ctx context.Context, key string) ([]byte, error) {
+	body, err := s.do(ctx, key, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object %q: %w", key, err)
+	}
+	return body, nil
+}
+
+// do sends a signed GET request for key (or the bucket itself when key is empty)
+func (s *s3Store) do(ctx context.Context, key string, query url.Values) ([]byte, error) {
+	u := *s.endpoint
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + s.bucket
+	if key != "" {
+		u.Path += "/" + key
+	}
+	u.RawQuery = canonicalQuery(query)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	s.sign(req, time.Now().UTC())
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
+
+// sign adds AWS Signature Version 4 headers to an unsigned-payload GET request.
+// Anonymous requests are sent as-is when no access key is configured.
+func (s *s3Store) sign(req *http.Request, now time.Time) {
+	req.Header.Set("x-amz-date", now.Format(s3TimeFormat))
+	req.Header.Set("x-amz-content-sha256", emptyPayloadSHA)
+	if s.sessionToken != "" {
+		req.Header.Set("x-amz-security-token", s.sessionToken)
+	}
+	if s.accessKey == "" {
+		return
+	}
+
+	headers := map[string]string{
+		"host":                 req.URL.Host,
+		"x-amz-content-sha256": emptyPayloadSHA,
+		"x-amz-date":           now.Format(s3TimeFormat),
+	}
+	if s.sessionToken != "" {
+		headers["x-amz-security-token"] = s.sessionToken
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		emptyPayloadSHA,
+	}, "\n")
+
+	scope := strings.Join([]string{now.Format(s3DateFormat), s.region, s3Service, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		now.Format(s3TimeFormat),
+		scope,
+		hexSHA256([]byte(canonicalRequest)),
+	}, "\n")
+
+	signingKey := hmacSHA256([]byte("AWS4"+s.secretKey), now.Format(s3DateFormat))
+	signingKey = hmacSHA256(signingKey, s.region)
+	signingKey = hmacSHA256(signingKey, s3Service)
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKey, scope, signedHeaders, signature))
+}
+
+// canonicalQuery encodes query parameters sorted by key, as required by SigV4
+func canonicalQuery(query url.Values) string {
+	if len(query) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		for _, v := range query[k] {
+			parts = append(parts, uriEncode(k)+"="+uriEncode(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// uriEncode percent-encodes everything except the SigV4 unreserved characters
+func uriEncode(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func hexSHA256(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,159 @@
+package backupstorage
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	dbaasv1 "github.com/huynt0812/dbaas-operator/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Keys expected in the secret referenced by BackupStorageSpec.CredentialsSecretRef
+const (
+	S3AccessKeyIDKey             = "ACCESS_KEY_ID"
+	S3SecretAccessKeyKey         = "ACCESS_SECRET_KEY"
+	S3SessionTokenKey            = "ACCESS_SESSION_TOKEN"
+	GCSApplicationCredentialsKey = "APPLICATION_CREDENTIALS"
+	AzureStorageAccountKey       = "AZURE_STORAGE_ACCOUNT"
+	AzureStorageKeyKey           = "AZURE_STORAGE_KEY"
+)
+
+// Object describes a single object found in a backup storage
+type Object struct {
+	// Key is the full key of the object within the bucket
+	Key string
+
+	// Size is the object size in bytes
+	Size int64
+
+	// LastModified is when the object was last written
+	LastModified time.Time
+}
+
+// Store gives read access to the objects of a backup storage
+type Store interface {
+	// List returns all objects whose key starts with prefix
+	List(ctx context.Context, prefix string) ([]Object, error)
+
+	// ListAfter returns the objects whose key starts with prefix and sorts after startAfter
+	ListAfter(ctx context.Context, prefix, startAfter string) ([]Object, error)
+
+	// ListPrefixes returns the key prefixes one level below prefix, such as the directories of a
+	// path, each ending with a slash
+	ListPrefixes(ctx context.Context, prefix string) ([]string, error)
+
+	// Get returns the content of the object stored at key
+	Get(ctx context.Context, key string) ([]byte, error)
+}
+
+// ValidateSync checks that the operator can scan the storage for catalog sync. Only S3-compatible
+// storages are read by the operator: GCS and Azure have no client yet, and file storages are only
+// mounted into backup Jobs.
+func ValidateSync(storage *dbaasv1.BackupStorage) error {
+	if storage.Spec.Type != "s3" {
+		return fmt.Errorf("catalog sync is not supported for storage type %s, only s3 storages can be scanned", storage.Spec.Type)
+	}
+	return nil
+}
+
+// NewStore returns a Store for the given BackupStorage, reading its credentials from the cluster
+func NewStore(ctx context.Context, c client.Client, storage *dbaasv1.BackupStorage) (Store, error) {
+	credentials, err := readCredentials(ctx, c, storage)
+	if err != nil {
+		return nil, err
+	}
+
+	switch storage.Spec.Type {
+	case "s3":
+		if storage.Spec.S3 == nil {
+			return nil, fmt.Errorf("s3 configuration is required for storage type s3")
+		}
+		return newS3Store(storage.Spec.S3, credentials, storage.Spec.VerifyTLS)
+	case "gcs":
+		return nil, fmt.Errorf("GCS backup storage access not implemented yet")
+	case "azure":
+		return nil, fmt.Errorf("Azure backup storage access not implemented yet")
+	default:
+		return nil, fmt.Errorf("unsupported backup storage type: %s", storage.Spec.Type)
+	}
+}
+
+// Prefix returns the path prefix configured for the storage, without leading or trailing slashes
+func Prefix(storage *dbaasv1.BackupStorage) string {
+	var prefix string
+	switch {
+	case storage.Spec.S3 != nil:
+		prefix = storage.Spec.S3.Prefix
+	case storage.Spec.GCS != nil:
+		prefix = storage.Spec.GCS.Prefix
+	case storage.Spec.Azure != nil:
+		prefix = storage.Spec.Azure.Prefix
+	}
+	return strings.Trim(prefix, "/")
+}
+
+// DestinationPath returns the URL of the storage root as understood by backup tools
+func DestinationPath(storage *dbaasv1.BackupStorage) (string, error) {
+	var base string
+	switch storage.Spec.Type {
+	case "s3":
+		if storage.Spec.S3 == nil {
+			return "", fmt.Errorf("s3 configuration is required for storage type s3")
+		}
+		base = fmt.Sprintf("s3://%s", storage.Spec.S3.Bucket)
+	case "gcs":
+		if storage.Spec.GCS == nil {
+			return "", fmt.Errorf("gcs configuration is required for storage type gcs")
+		}
+		base = fmt.Sprintf("gs://%s", storage.Spec.GCS.Bucket)
+	case "azure":
+		if storage.Spec.Azure == nil {
+			return "", fmt.Errorf("azure configuration is required for storage type azure")
+		}
+		base = fmt.Sprintf("https://%s.blob.core.windows.net/%s", storage.Spec.Azure.StorageAccount, storage.Spec.Azure.Container)
+	default:
+		return "", fmt.Errorf("storage type %s has no object store destination", storage.Spec.Type)
+	}
+
+	if prefix := Prefix(storage); prefix != "" {
+		return base + "/" + prefix, nil
+	}
+	return base, nil
+}
+
+// readCredentials loads the credentials secret of the storage, if any,
+// or the temporary credentials of its workload identity
+func readCredentials(ctx context.Context, c client.Client, storage *dbaasv1.BackupStorage) (map[string]string, error) {
+	if UsesWorkloadIdentity(storage) {
+		if err := ValidateWorkloadIdentity(storage); err != nil {
+			return nil, err
+		}
+		if storage.Spec.WorkloadIdentity.Provider != dbaasv1.WorkloadIdentityAWS {
+			return nil, fmt.Errorf("workload identity provider %s is not supported by the operator", storage.Spec.WorkloadIdentity.Provider)
+		}
+		return webIdentityCredentials(ctx, &http.Client{Timeout: 30 * time.Second}, storage)
+	}
+
+	credentials := make(map[string]string)
+	if storage.Spec.CredentialsSecretRef == nil {
+		return credentials, nil
+	}
+
+	secret := &corev1.Secret{}
+	if err := c.Get(ctx, types.NamespacedName{
+		Name:      storage.Spec.CredentialsSecretRef.Name,
+		Namespace: storage.Namespace,
+	}, secret); err != nil {
+		return nil, fmt.Errorf("failed to get credentials secret: %w", err)
+	}
+
+	for k, v := range secret.Data {
+		credentials[k] = string(v)
+	}
+	return credentials, nil
+}
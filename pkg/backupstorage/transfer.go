@@ -0,0 +1,41 @@
+package backupstorage
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	dbaasv1 "github.com/huynt0812/dbaas-operator/api/v1"
+)
+
+// ToolsPath is where TransferToolsContainer installs the rclone binary for the other containers of a pod
+const ToolsPath = "/tools"
+
+// RcloneBinary is the path of the installed rclone binary
+const RcloneBinary = ToolsPath + "/rclone"
+
+// TransferToolsContainer returns an init container installing the rclone binary into the volume
+// mounted at ToolsPath, so that engine containers can stream dumps from and to a storage
+func TransferToolsContainer(volumeName string) corev1.Container {
+	return corev1.Container{
+		Name:         "install-rclone",
+		Image:        ReplicationImage,
+		Command:      []string{"cp", "/usr/local/bin/rclone", RcloneBinary},
+		VolumeMounts: []corev1.VolumeMount{{Name: volumeName, MountPath: ToolsPath}},
+	}
+}
+
+// ConfigureRemote configures the storage as the rclone remote called name in a container of the pod,
+// and returns the path of the storage root on that remote
+func ConfigureRemote(pod *corev1.PodSpec, container *corev1.Container, name string, storage *dbaasv1.BackupStorage) (string, error) {
+	r, err := rcloneRemote(name, storage)
+	if err != nil {
+		return "", err
+	}
+
+	container.Env = append(container.Env, r.env...)
+	if r.noTLS {
+		container.Env = append(container.Env, corev1.EnvVar{Name: "RCLONE_NO_CHECK_CERTIFICATE", Value: "true"})
+	}
+	container.VolumeMounts = append(container.VolumeMounts, r.mounts...)
+	pod.Volumes = append(pod.Volumes, r.volumes...)
+	return r.path, nil
+}
@@ -0,0 +1,72 @@
+package backupstorage
+
+import (
+	"context"
+	"path"
+	"strings"
+	"time"
+
+	dbaasv1 "github.com/huynt0812/dbaas-operator/api/v1"
+)
+
+// walNameLength is the length of a WAL segment name: timeline, log and segment as 8 hex digits each
+const walNameLength = 24
+
+// ArchivedWAL describes a WAL segment found in a backup storage
+type ArchivedWAL struct {
+	// Name is the WAL segment name, without compression or encryption extension
+	Name string
+
+	// ArchivedAt is when the segment was written to the storage
+	ArchivedAt time.Time
+}
+
+// WALPrefix returns the prefix under which barman-cloud archives the WAL files of a server,
+// laid out as <serverName>/wals/<timeline+log>/<segment>
+func WALPrefix(storage *dbaasv1.BackupStorage, serverName string) string {
+	return path.Join(Prefix(storage), serverName, "wals") + "/"
+}
+
+// LastArchivedWAL returns the most recent WAL segment archived for a server, or nil when there is none.
+// The listing starts after the segment named by after, so that periodic checks only read the files
+// archived since the previous one.
+func LastArchivedWAL(ctx context.Context, store Store, storage *dbaasv1.BackupStorage, serverName, after string) (*ArchivedWAL, error) {
+	prefix := WALPrefix(storage, serverName)
+	startAfter := ""
+	if isWALName(after) {
+		startAfter = prefix + after[:16] + "/" + after
+	}
+
+	objects, err := store.ListAfter(ctx, prefix, startAfter)
+	if err != nil {
+		return nil, err
+	}
+
+	var last *ArchivedWAL
+	for _, object := range objects {
+		name := path.Base(object.Key)
+		// Skip timeline history files, backup labels and partial segments
+		if len(name) < walNameLength || !isWALName(name[:walNameLength]) ||
+			strings.Contains(name, ".backup") || strings.Contains(name, ".partial") {
+			continue
+		}
+		name = name[:walNameLength]
+		if last == nil || name > last.Name {
+			last = &ArchivedWAL{Name: name, ArchivedAt: object.LastModified}
+		}
+	}
+	return last, nil
+}
+
+// isWALName reports whether name is a WAL segment name
+func isWALName(name string) bool {
+	if len(name) != walNameLength {
+		return false
+	}
+	for _, c := range name {
+		if !strings.ContainsRune("0123456789ABCDEF", c) {
+			return false
+		}
+	}
+	return true
+}
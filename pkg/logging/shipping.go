@@ -0,0 +1,202 @@
+package logging
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+
+	dbaasv1 "github.com/huynt0812/dbaas-operator/api/v1"
+)
+
+// Source is a database container whose logs are shipped. The pipelines tail the container log
+// files kept by the kubelet, so they are meant for the node-level collector of the cluster.
+type Source struct {
+	// Namespace and Cluster identify the database cluster
+	Namespace string
+	Cluster   string
+
+	// PodPrefix matches the database pods, named <PodPrefix>-*
+	PodPrefix string
+
+	// Container is the database container
+	Container string
+
+	// JSON reports whether the records are JSON objects to be parsed
+	JSON bool
+
+	// RecordKey, when set, is the key of the JSON records holding the fields to ship in place
+	// of the whole object. Records without it are shipped as they are.
+	RecordKey string
+}
+
+// ValidateShipping checks that the shipping target is a host:port of a supported collector
+func ValidateShipping(spec *dbaasv1.LogShippingSpec) error {
+	if spec == nil {
+		return nil
+	}
+	if spec.Type != dbaasv1.LogShippingOTel && spec.Type != dbaasv1.LogShippingFluentBit {
+		return fmt.Errorf("unsupported log shipping type: %s", spec.Type)
+	}
+	host, port, err := net.SplitHostPort(spec.Endpoint)
+	if err != nil {
+		return fmt.Errorf("invalid log shipping endpoint %q: %w", spec.Endpoint, err)
+	}
+	if host == "" {
+		return fmt.Errorf("log shipping endpoint %q has no host", spec.Endpoint)
+	}
+	if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+		return fmt.Errorf("log shipping endpoint %q has an invalid port", spec.Endpoint)
+	}
+	return nil
+}
+
+// PipelineKey returns the ConfigMap key of the pipeline of a collector type
+func PipelineKey(spec *dbaasv1.LogShippingSpec) string {
+	if spec.Type == dbaasv1.LogShippingOTel {
+		return "otel-collector.yaml"
+	}
+	return "fluent-bit.conf"
+}
+
+// Pipeline renders the collector pipeline shipping the logs of the source to the target
+func Pipeline(source Source, spec *dbaasv1.LogShippingSpec) (string, error) {
+	if err := ValidateShipping(spec); err != nil {
+		return "", err
+	}
+	if spec.Type == dbaasv1.LogShippingOTel {
+		return otelPipeline(source, spec)
+	}
+	return fluentBitPipeline(source, spec), nil
+}
+
+// attributes returns the attributes added to every record, identifying the cluster
+func attributes(source Source, spec *dbaasv1.LogShippingSpec) map[string]string {
+	attrs := map[string]string{
+		"dbaas.cluster":   source.Cluster,
+		"dbaas.namespace": source.Namespace,
+	}
+	for k, v := range spec.Attributes {
+		attrs[k] = v
+	}
+	return attrs
+}
+
+// fluentBitPipeline renders a Fluent Bit configuration forwarding the records to the target
+func fluentBitPipeline(source Source, spec *dbaasv1.LogShippingSpec) string {
+	host, port, _ := net.SplitHostPort(spec.Endpoint)
+	tag := fmt.Sprintf("dbaas.%s.%s", source.Namespace, source.Cluster)
+
+	var b strings.Builder
+	section := func(name string, entries ...[2]string) {
+		fmt.Fprintf(&b, "[%s]\n", name)
+		for _, entry := range entries {
+			fmt.Fprintf(&b, "    %-16s %s\n", entry[0], entry[1])
+		}
+		b.WriteString("\n")
+	}
+
+	section("INPUT",
+		[2]string{"Name", "tail"},
+		[2]string{"Tag", tag},
+		[2]string{"Path", fmt.Sprintf("/var/log/containers/%s-*_%s_%s-*.log", source.PodPrefix, source.Namespace, source.Container)},
+		[2]string{"multiline.parser", "docker, cri"},
+		[2]string{"Refresh_Interval", "10"},
+	)
+	if source.JSON {
+		section("FILTER",
+			[2]string{"Name", "parser"},
+			[2]string{"Match", tag},
+			[2]string{"Key_Name", "log"},
+			[2]string{"Parser", "json"},
+			[2]string{"Reserve_Data", "On"},
+		)
+		if source.RecordKey != "" {
+			section("FILTER",
+				[2]string{"Name", "nest"},
+				[2]string{"Match", tag},
+				[2]string{"Operation", "lift"},
+				[2]string{"Nested_under", source.RecordKey},
+			)
+		}
+	}
+	attrs := attributes(source, spec)
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	entries := [][2]string{{"Name", "modify"}, {"Match", tag}}
+	for _, k := range keys {
+		entries = append(entries, [2]string{"Add", fmt.Sprintf("%s %s", k, attrs[k])})
+	}
+	section("FILTER", entries...)
+
+	tls := "On"
+	if spec.Insecure {
+		tls = "Off"
+	}
+	section("OUTPUT",
+		[2]string{"Name", "forward"},
+		[2]string{"Match", tag},
+		[2]string{"Host", host},
+		[2]string{"Port", port},
+		[2]string{"tls", tls},
+	)
+	return b.String()
+}
+
+// otelPipeline renders an OpenTelemetry collector configuration exporting the records over OTLP/gRPC
+func otelPipeline(source Source, spec *dbaasv1.LogShippingSpec) (string, error) {
+	name := fmt.Sprintf("%s-%s", source.Namespace, source.Cluster)
+
+	operators := []map[string]interface{}{{"type": "container"}}
+	if source.JSON {
+		operators = append(operators, map[string]interface{}{"type": "json_parser", "parse_from": "body", "parse_to": "attributes"})
+		if source.RecordKey != "" {
+			field := "attributes." + source.RecordKey
+			operators = append(operators, map[string]interface{}{"type": "move", "if": field + " != nil", "from": field, "to": "body"})
+		}
+	}
+
+	var actions []map[string]interface{}
+	for k, v := range attributes(source, spec) {
+		actions = append(actions, map[string]interface{}{"key": k, "value": v, "action": "upsert"})
+	}
+	sort.Slice(actions, func(i, j int) bool { return actions[i]["key"].(string) < actions[j]["key"].(string) })
+
+	config := map[string]interface{}{
+		"receivers": map[string]interface{}{
+			"filelog/" + name: map[string]interface{}{
+				"include":   []string{fmt.Sprintf("/var/log/pods/%s_%s-*/%s/*.log", source.Namespace, source.PodPrefix, source.Container)},
+				"operators": operators,
+			},
+		},
+		"processors": map[string]interface{}{
+			"attributes/" + name: map[string]interface{}{"actions": actions},
+		},
+		"exporters": map[string]interface{}{
+			"otlp/" + name: map[string]interface{}{
+				"endpoint": spec.Endpoint,
+				"tls":      map[string]bool{"insecure": spec.Insecure},
+			},
+		},
+		"service": map[string]interface{}{
+			"pipelines": map[string]interface{}{
+				"logs/" + name: map[string]interface{}{
+					"receivers":  []string{"filelog/" + name},
+					"processors": []string{"attributes/" + name},
+					"exporters":  []string{"otlp/" + name},
+				},
+			},
+		},
+	}
+	data, err := yaml.Marshal(config)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
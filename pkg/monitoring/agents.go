@@ -0,0 +1,163 @@
+package monitoring
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"sigs.k8s.io/yaml"
+
+	dbaasv1 "github.com/huynt0812/dbaas-operator/api/v1"
+)
+
+// newRelicDiscovery is the discovery command of the New Relic infrastructure agent on Kubernetes
+const newRelicDiscovery = "/var/db/newrelic-infra/nri-discovery-kubernetes --tls --port 10250"
+
+// AgentTarget is the database a Datadog or New Relic agent collects metrics from
+type AgentTarget struct {
+	// Cluster and Namespace identify the database cluster
+	Cluster   string
+	Namespace string
+
+	// Selector selects the database pods
+	Selector map[string]string
+
+	// Container is the name of the database container in the pods
+	Container string
+
+	// Port is the database port
+	Port int32
+
+	// Username is the database user of the agent
+	Username string
+
+	// CredentialsSecret holds the password of the database user under the "password" key
+	CredentialsSecret string
+
+	// DatadogCheck is the name of the Datadog check of the engine
+	DatadogCheck string
+
+	// DatadogInstance holds the engine-specific settings of the Datadog check instance
+	DatadogInstance map[string]interface{}
+
+	// NewRelicIntegration is the name of the New Relic integration of the engine
+	NewRelicIntegration string
+
+	// NewRelicEnv holds the engine-specific settings of the New Relic integration
+	NewRelicEnv map[string]string
+}
+
+// DatadogAnnotations returns the Autodiscovery annotations of the database pods. The password
+// is resolved by the secret backend of the agent, which must be allowed to read the secret.
+func DatadogAnnotations(target AgentTarget, spec *dbaasv1.DatadogConfigSpec) (map[string]string, error) {
+	if spec == nil {
+		spec = &dbaasv1.DatadogConfigSpec{}
+	}
+
+	tags := []string{
+		fmt.Sprintf("dbaas_cluster:%s", target.Cluster),
+		fmt.Sprintf("dbaas_namespace:%s", target.Namespace),
+	}
+	tags = append(tags, spec.Tags...)
+
+	instance := map[string]interface{}{
+		"host":     "%%host%%",
+		"port":     target.Port,
+		"username": target.Username,
+		"password": fmt.Sprintf("ENC[k8s_secret@%s/%s/password]", target.Namespace, target.CredentialsSecret),
+		"tags":     tags,
+	}
+	for k, v := range target.DatadogInstance {
+		instance[k] = v
+	}
+	if spec.MinCollectionInterval > 0 {
+		instance["min_collection_interval"] = spec.MinCollectionInterval
+	}
+	if spec.DatabaseMonitoring {
+		instance["dbm"] = true
+	}
+
+	checks, err := json.Marshal(map[string]interface{}{
+		target.DatadogCheck: map[string]interface{}{
+			"init_config": map[string]interface{}{},
+			"instances":   []interface{}{instance},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{
+		fmt.Sprintf("ad.datadoghq.com/%s.checks", target.Container): string(checks),
+	}, nil
+}
+
+// NewRelicIntegrationKey returns the file name of the integration config of the target
+func NewRelicIntegrationKey(target AgentTarget) string {
+	return fmt.Sprintf("%s-%s.yaml", target.NewRelicIntegration, target.Cluster)
+}
+
+// NewRelicIntegration renders the integration config of the New Relic infrastructure agent,
+// discovering the database pods by label and reading the password from the credentials secret
+func NewRelicIntegration(target AgentTarget, spec *dbaasv1.NewRelicConfigSpec) (string, error) {
+	if spec == nil {
+		spec = &dbaasv1.NewRelicConfigSpec{}
+	}
+
+	match := make(map[string]string, len(target.Selector))
+	for k, v := range target.Selector {
+		match["label."+k] = v
+	}
+
+	env := map[string]string{
+		"HOSTNAME": "${discovery.ip}",
+		"PORT":     fmt.Sprint(target.Port),
+		"USERNAME": target.Username,
+		"PASSWORD": "${credentials}",
+	}
+	for k, v := range target.NewRelicEnv {
+		env[k] = v
+	}
+	if spec.CollectionList != "" {
+		env["COLLECTION_LIST"] = spec.CollectionList
+	}
+
+	labels := map[string]string{
+		"dbaas_cluster":   target.Cluster,
+		"dbaas_namespace": target.Namespace,
+	}
+	for k, v := range spec.Labels {
+		labels[k] = v
+	}
+
+	integration := map[string]interface{}{
+		"name":   target.NewRelicIntegration,
+		"env":    env,
+		"labels": labels,
+	}
+	if spec.Interval != "" {
+		integration["interval"] = spec.Interval
+	}
+
+	config := map[string]interface{}{
+		"discovery": map[string]interface{}{
+			"command": map[string]interface{}{
+				"exec":  newRelicDiscovery,
+				"match": match,
+			},
+		},
+		"variables": map[string]interface{}{
+			"credentials": map[string]interface{}{
+				"kubernetes": map[string]string{
+					"namespace": target.Namespace,
+					"name":      target.CredentialsSecret,
+					"key":       "password",
+				},
+			},
+		},
+		"integrations": []interface{}{integration},
+	}
+	data, err := yaml.Marshal(config)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
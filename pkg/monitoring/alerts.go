@@ -0,0 +1,210 @@
+package monitoring
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	"github.com/prometheus/common/model"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	dbaasv1 "github.com/huynt0812/dbaas-operator/api/v1"
+)
+
+// AlertRule is a rule of the built-in catalog of an engine. The expression refers to the cluster
+// with $NAMESPACE, $CLUSTER and $PODS (a regex matching the pods and volumes of the cluster),
+// and to the threshold with $THRESHOLD.
+type AlertRule struct {
+	Name        string
+	Expr        string
+	Threshold   string
+	For         string
+	Severity    string
+	Summary     string
+	Description string
+}
+
+// alertCatalogs holds the built-in rules of each engine type
+var alertCatalogs = map[string][]AlertRule{
+	"postgresql": {
+		{
+			Name:        "InstanceDown",
+			Expr:        `up{namespace="$NAMESPACE",pod=~"$PODS"} == 0 or cnpg_collector_up{namespace="$NAMESPACE",pod=~"$PODS"} == 0`,
+			For:         "1m",
+			Severity:    "critical",
+			Summary:     "PostgreSQL instance {{ $labels.pod }} is down",
+			Description: "The instance {{ $labels.pod }} of cluster $CLUSTER has not been reachable for 1 minute.",
+		},
+		{
+			Name:        "ReplicationLag",
+			Expr:        `max by (pod) (cnpg_pg_replication_lag{namespace="$NAMESPACE",pod=~"$PODS"}) > $THRESHOLD`,
+			Threshold:   "30",
+			For:         "5m",
+			Severity:    "warning",
+			Summary:     "PostgreSQL replica {{ $labels.pod }} is lagging",
+			Description: "The replica {{ $labels.pod }} of cluster $CLUSTER is {{ $value }}s behind the primary (threshold $THRESHOLDs).",
+		},
+		{
+			Name: "DiskNearlyFull",
+			Expr: `kubelet_volume_stats_used_bytes{namespace="$NAMESPACE",persistentvolumeclaim=~"$PODS(-wal)?"}` +
+				` / kubelet_volume_stats_capacity_bytes{namespace="$NAMESPACE",persistentvolumeclaim=~"$PODS(-wal)?"} > $THRESHOLD`,
+			Threshold:   "0.85",
+			For:         "5m",
+			Severity:    "warning",
+			Summary:     "PostgreSQL volume {{ $labels.persistentvolumeclaim }} is nearly full",
+			Description: "The volume {{ $labels.persistentvolumeclaim }} of cluster $CLUSTER is {{ $value | humanizePercentage }} full (threshold $THRESHOLD).",
+		},
+		{
+			Name:        "BackupStale",
+			Expr:        `dbaas_backup_age_seconds{dbaas_namespace="$NAMESPACE",dbaas_cluster="$CLUSTER"} > $THRESHOLD`,
+			Threshold:   "90000",
+			For:         "5m",
+			Severity:    "warning",
+			Summary:     "PostgreSQL cluster $CLUSTER has no recent backup",
+			Description: "The last successful backup of cluster $CLUSTER is {{ $value | humanizeDuration }} old (threshold $THRESHOLDs).",
+		},
+		{
+			Name: "ConnectionsSaturated",
+			Expr: `sum by (pod) (cnpg_backends_total{namespace="$NAMESPACE",pod=~"$PODS"})` +
+				` / max by (pod) (cnpg_pg_settings_setting{name="max_connections",namespace="$NAMESPACE",pod=~"$PODS"}) > $THRESHOLD`,
+			Threshold:   "0.8",
+			For:         "5m",
+			Severity:    "warning",
+			Summary:     "PostgreSQL instance {{ $labels.pod }} is running out of connections",
+			Description: "The instance {{ $labels.pod }} of cluster $CLUSTER uses {{ $value | humanizePercentage }} of max_connections (threshold $THRESHOLD).",
+		},
+	},
+}
+
+// invalidLabelChars matches the characters not allowed in Prometheus label names
+var invalidLabelChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// AlertCatalog returns the built-in rules of an engine type
+func AlertCatalog(engine string) []AlertRule {
+	return alertCatalogs[engine]
+}
+
+// AlertRulesName returns the name of the PrometheusRule of the cluster
+func AlertRulesName(cluster *dbaasv1.DatabaseCluster) string {
+	return cluster.Name + "-alerts"
+}
+
+// ValidateAlerting checks that the overrides name rules of the catalog of the engine with usable values
+func ValidateAlerting(alerting *dbaasv1.AlertingSpec, engine string) error {
+	catalog := AlertCatalog(engine)
+	if len(catalog) == 0 {
+		return configErrorf("no built-in alerting rules for engine %s", engine)
+	}
+	known := make(map[string]bool, len(catalog))
+	for _, rule := range catalog {
+		known[rule.Name] = true
+	}
+
+	for _, override := range alerting.Rules {
+		if !known[override.Name] {
+			return configErrorf("unknown alerting rule %s", override.Name)
+		}
+		if override.Threshold != "" {
+			if _, err := strconv.ParseFloat(override.Threshold, 64); err != nil {
+				return configErrorf("alerting rule %s: invalid threshold %q", override.Name, override.Threshold)
+			}
+		}
+		if override.For != "" {
+			if _, err := model.ParseDuration(override.For); err != nil {
+				return configErrorf("alerting rule %s: invalid duration %q: %w", override.Name, override.For, err)
+			}
+		}
+	}
+	return nil
+}
+
+// PrometheusRule builds the PrometheusRule of the cluster from the catalog of its engine and the
+// overrides of the alerting spec. It returns nil when alerting is disabled.
+func PrometheusRule(cluster *dbaasv1.DatabaseCluster, alerting *dbaasv1.AlertingSpec) (*monitoringv1.PrometheusRule, error) {
+	if alerting == nil || !alerting.Enabled {
+		return nil, nil
+	}
+	engine := cluster.Spec.Engine.Type
+	if err := ValidateAlerting(alerting, engine); err != nil {
+		return nil, err
+	}
+
+	overrides := make(map[string]dbaasv1.AlertRuleOverride, len(alerting.Rules))
+	for _, override := range alerting.Rules {
+		overrides[override.Name] = override
+	}
+
+	// Routing labels are taken from the cluster so that alerts reach the team owning it
+	labels := map[string]string{
+		"namespace": cluster.Namespace,
+		"cluster":   cluster.Name,
+		"engine":    engine,
+	}
+	for _, key := range alerting.RoutingLabels {
+		if value, ok := cluster.Labels[key]; ok {
+			labels[invalidLabelChars.ReplaceAllString(key, "_")] = value
+		}
+	}
+
+	var rules []monitoringv1.Rule
+	for _, rule := range AlertCatalog(engine) {
+		override := overrides[rule.Name]
+		if override.Disabled {
+			continue
+		}
+		if override.Threshold != "" {
+			rule.Threshold = override.Threshold
+		}
+		if override.For != "" {
+			rule.For = override.For
+		}
+		if override.Severity != "" {
+			rule.Severity = override.Severity
+		}
+
+		replacer := strings.NewReplacer(
+			"$NAMESPACE", cluster.Namespace,
+			"$CLUSTER", cluster.Name,
+			"$PODS", regexp.QuoteMeta(cluster.Name)+"-[0-9]+",
+			"$THRESHOLD", rule.Threshold,
+		)
+		ruleLabels := map[string]string{"severity": rule.Severity}
+		for k, v := range labels {
+			ruleLabels[k] = v
+		}
+		duration := monitoringv1.Duration(rule.For)
+		rules = append(rules, monitoringv1.Rule{
+			Alert:  rule.Name,
+			Expr:   intstr.FromString(replacer.Replace(rule.Expr)),
+			For:    &duration,
+			Labels: ruleLabels,
+			Annotations: map[string]string{
+				"summary":     replacer.Replace(rule.Summary),
+				"description": replacer.Replace(rule.Description),
+			},
+		})
+	}
+
+	objectLabels := map[string]string{
+		"dbaas.io/cluster": cluster.Name,
+		"dbaas.io/engine":  engine,
+	}
+	for k, v := range alerting.AdditionalLabels {
+		objectLabels[k] = v
+	}
+	return &monitoringv1.PrometheusRule{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      AlertRulesName(cluster),
+			Namespace: cluster.Namespace,
+			Labels:    objectLabels,
+		},
+		Spec: monitoringv1.PrometheusRuleSpec{
+			Groups: []monitoringv1.RuleGroup{
+				{Name: fmt.Sprintf("dbaas-%s-%s", engine, cluster.Name), Rules: rules},
+			},
+		},
+	}, nil
+}
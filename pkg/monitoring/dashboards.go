@@ -0,0 +1,127 @@
+package monitoring
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	dbaasv1 "github.com/huynt0812/dbaas-operator/api/v1"
+)
+
+const (
+	// defaultDashboardLabel is the label watched by the Grafana sidecar by default
+	defaultDashboardLabel = "grafana_dashboard"
+
+	// grafanaFolderAnnotation sets the folder of the dashboards of a ConfigMap
+	grafanaFolderAnnotation = "grafana_folder"
+)
+
+// Dashboard is a Grafana dashboard of a cluster
+type Dashboard struct {
+	// UID is the Grafana UID of the dashboard, unique per cluster
+	UID string
+
+	// Title is the title of the dashboard
+	Title string
+
+	// JSON is the dashboard model
+	JSON string
+}
+
+// DashboardPanel is a time series panel of a dashboard
+type DashboardPanel struct {
+	Title  string
+	Expr   string
+	Legend string
+	Unit   string
+}
+
+// slugChars matches the characters Grafana drops from dashboard slugs
+var slugChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// DashboardUID returns a Grafana UID for a dashboard of the cluster, short enough for the 40
+// characters Grafana allows and stable across reconciles
+func DashboardUID(cluster *dbaasv1.DatabaseCluster, kind string) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s/%s/%s", cluster.Namespace, cluster.Name, kind)))
+	return fmt.Sprintf("dbaas-%x", sum[:8])
+}
+
+// BuildDashboard renders a dashboard laying the panels out on two columns. The panels query the
+// Prometheus data source selected by the datasource variable of the dashboard.
+func BuildDashboard(uid, title string, tags []string, panels []DashboardPanel) (Dashboard, error) {
+	models := make([]map[string]interface{}, 0, len(panels))
+	for i, panel := range panels {
+		models = append(models, map[string]interface{}{
+			"id":         i + 1,
+			"type":       "timeseries",
+			"title":      panel.Title,
+			"datasource": map[string]string{"type": "prometheus", "uid": "${datasource}"},
+			"gridPos":    map[string]int{"h": 8, "w": 12, "x": (i % 2) * 12, "y": (i / 2) * 8},
+			"fieldConfig": map[string]interface{}{
+				"defaults":  map[string]string{"unit": panel.Unit},
+				"overrides": []interface{}{},
+			},
+			"targets": []map[string]string{
+				{"refId": "A", "expr": panel.Expr, "legendFormat": panel.Legend},
+			},
+		})
+	}
+
+	model := map[string]interface{}{
+		"uid":           uid,
+		"title":         title,
+		"tags":          tags,
+		"editable":      false,
+		"schemaVersion": 39,
+		"refresh":       "30s",
+		"time":          map[string]string{"from": "now-6h", "to": "now"},
+		"templating": map[string]interface{}{
+			"list": []map[string]interface{}{
+				{"name": "datasource", "label": "Data source", "type": "datasource", "query": "prometheus"},
+			},
+		},
+		"panels": models,
+	}
+	data, err := json.MarshalIndent(model, "", "  ")
+	if err != nil {
+		return Dashboard{}, err
+	}
+	return Dashboard{UID: uid, Title: title, JSON: string(data)}, nil
+}
+
+// DashboardLabels returns the labels the Grafana sidecar selects the dashboard ConfigMaps with
+func DashboardLabels(grafana *dbaasv1.GrafanaSpec) map[string]string {
+	label, value := grafana.DashboardLabel, grafana.DashboardLabelValue
+	if label == "" {
+		label = defaultDashboardLabel
+	}
+	if value == "" {
+		value = "1"
+	}
+	return map[string]string{label: value}
+}
+
+// DashboardAnnotations returns the annotations of the dashboard ConfigMaps
+func DashboardAnnotations(grafana *dbaasv1.GrafanaSpec) map[string]string {
+	if grafana.Folder == "" {
+		return nil
+	}
+	return map[string]string{grafanaFolderAnnotation: grafana.Folder}
+}
+
+// DashboardFile returns the ConfigMap key of a dashboard
+func DashboardFile(dashboard Dashboard) string {
+	return dashboard.UID + ".json"
+}
+
+// DashboardURL returns the link to a dashboard in Grafana, or an empty string without a Grafana URL
+func DashboardURL(grafana *dbaasv1.GrafanaSpec, dashboard Dashboard) string {
+	if grafana.URL == "" {
+		return ""
+	}
+	slug := strings.Trim(slugChars.ReplaceAllString(strings.ToLower(dashboard.Title), "-"), "-")
+	return fmt.Sprintf("%s/d/%s/%s", strings.TrimSuffix(grafana.URL, "/"), url.PathEscape(dashboard.UID), slug)
+}
@@ -0,0 +1,101 @@
+package monitoring
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	dbaasv1 "github.com/huynt0812/dbaas-operator/api/v1"
+)
+
+const (
+	// DefaultInsightsRefreshInterval is used when RefreshInterval is not set
+	DefaultInsightsRefreshInterval = 15 * time.Minute
+
+	// InsightsQueryLength is the number of characters of statement text kept in the summary
+	InsightsQueryLength = 64
+
+	// insightsSummaryJSON and insightsSummaryText are the keys of the summary ConfigMap
+	insightsSummaryJSON = "summary.json"
+	insightsSummaryText = "summary.txt"
+)
+
+// Statement is an entry of the slow statements summary. Providers print the summary as one JSON
+// statement per line, ordered by total execution time, so that it can be cut between statements.
+type Statement struct {
+	QueryID     string  `json:"queryId"`
+	Database    string  `json:"database"`
+	Query       string  `json:"query"`
+	Calls       int64   `json:"calls"`
+	TotalTimeMs float64 `json:"totalTimeMs"`
+	MeanTimeMs  float64 `json:"meanTimeMs"`
+	Rows        int64   `json:"rows"`
+}
+
+// InsightsTopN returns the number of statements in the summary, 0 when the summary is disabled
+func InsightsTopN(spec *dbaasv1.QueryInsightsSpec) int32 {
+	if spec == nil || !spec.Enabled {
+		return 0
+	}
+	return spec.TopN
+}
+
+// InsightsRefreshInterval returns how often the summary is refreshed
+func InsightsRefreshInterval(spec *dbaasv1.QueryInsightsSpec) time.Duration {
+	if spec.RefreshInterval == nil || spec.RefreshInterval.Duration <= 0 {
+		return DefaultInsightsRefreshInterval
+	}
+	return spec.RefreshInterval.Duration
+}
+
+// InsightsConfigMapName returns the name of the ConfigMap holding the summary of the cluster
+func InsightsConfigMapName(cluster *dbaasv1.DatabaseCluster) string {
+	return cluster.Name + "-query-insights"
+}
+
+// ParseStatements parses the summary printed by a provider
+func ParseStatements(output string) ([]Statement, error) {
+	var statements []Statement
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		var statement Statement
+		if err := json.Unmarshal([]byte(line), &statement); err != nil {
+			return nil, fmt.Errorf("invalid statements summary: %w", err)
+		}
+		statements = append(statements, statement)
+	}
+	return statements, nil
+}
+
+// InsightsSummary renders the statements as the data of the summary ConfigMap: the statements
+// as JSON for tools, and as a table for people reading the ConfigMap
+func InsightsSummary(statements []Statement) (map[string]string, error) {
+	if statements == nil {
+		statements = []Statement{}
+	}
+	data, err := json.MarshalIndent(statements, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "RANK\tDATABASE\tCALLS\tTOTAL (ms)\tMEAN (ms)\tROWS\tQUERY")
+	for i, statement := range statements {
+		fmt.Fprintf(w, "%d\t%s\t%d\t%.1f\t%.2f\t%d\t%s\n", i+1, statement.Database, statement.Calls,
+			statement.TotalTimeMs, statement.MeanTimeMs, statement.Rows, statement.Query)
+	}
+	if err := w.Flush(); err != nil {
+		return nil, err
+	}
+
+	return map[string]string{
+		insightsSummaryJSON: string(data),
+		insightsSummaryText: b.String(),
+	}, nil
+}
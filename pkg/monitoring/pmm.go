@@ -0,0 +1,218 @@
+package monitoring
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	dbaasv1 "github.com/huynt0812/dbaas-operator/api/v1"
+)
+
+const (
+	// DefaultPMMImage is the PMM client image used when the MonitoringConfig does not set one
+	DefaultPMMImage = "percona/pmm-client:2"
+
+	// defaultPMMServerPort is the HTTPS port of the PMM server
+	defaultPMMServerPort = int32(443)
+
+	// defaultPMMServerUser is the PMM server user when none is configured
+	defaultPMMServerUser = "admin"
+
+	// pmmAgentPort is the port of the local API of pmm-agent
+	pmmAgentPort = 7777
+
+	// pmmAgentConfigFile is written by pmm-agent during the registration with the server
+	pmmAgentConfigFile = "/usr/local/percona/pmm2/config/pmm-agent.yaml"
+)
+
+// PMMSettings is the PMM server a cluster registers with, merged from the PMM section of
+// the cluster and the pmm MonitoringConfig it references
+type PMMSettings struct {
+	// ServerHost is the PMM server host
+	ServerHost string
+
+	// ServerPort is the PMM server port
+	ServerPort int32
+
+	// ServerUser is the PMM server username
+	ServerUser string
+
+	// PasswordSecretRef is the key holding the PMM server password
+	PasswordSecretRef *corev1.SecretKeySelector
+
+	// InsecureTLS skips the verification of the PMM server certificate
+	InsecureTLS bool
+
+	// Image is the PMM client image
+	Image string
+
+	// Resources are the compute resources of the PMM client
+	Resources corev1.ResourceRequirements
+}
+
+// PMMService is the database service the PMM client registers with the server
+type PMMService struct {
+	// Type is the pmm-admin service type (postgresql, mysql, mongodb)
+	Type string
+
+	// Host and Port are where the client connects to the database
+	Host string
+	Port int32
+
+	// ServiceName is the name of the service in PMM
+	ServiceName string
+
+	// ClusterName groups the services of a cluster in PMM
+	ClusterName string
+
+	// NodeName is the PMM node the client registers as. It must stay the same across restarts,
+	// as the client re-registers on every start and replaces the node of the same name.
+	NodeName string
+
+	// CredentialsSecret holds the username and password keys of the database user of the client
+	CredentialsSecret string
+}
+
+// ResolvePMM merges the PMM section of the cluster with its pmm MonitoringConfig. It returns
+// nil when the cluster is not monitored by PMM.
+func ResolvePMM(cluster *dbaasv1.DatabaseCluster, config *dbaasv1.MonitoringConfig) (*PMMSettings, error) {
+	spec := cluster.Spec.Monitoring
+	if spec == nil || !spec.Enabled {
+		return nil, nil
+	}
+	if config != nil && config.Spec.Type != "pmm" {
+		config = nil
+	}
+	if config == nil && spec.PMM == nil {
+		return nil, nil
+	}
+
+	settings := &PMMSettings{
+		ServerPort: defaultPMMServerPort,
+		ServerUser: defaultPMMServerUser,
+		Image:      DefaultPMMImage,
+	}
+	if config != nil {
+		if config.Spec.PMM == nil {
+			return nil, configErrorf("MonitoringConfig %s has no pmm section", config.Name)
+		}
+		settings.ServerHost = config.Spec.PMM.ServerHost
+		if config.Spec.PMM.ServerPort != 0 {
+			settings.ServerPort = config.Spec.PMM.ServerPort
+		}
+		if config.Spec.PMM.ServerUser != "" {
+			settings.ServerUser = config.Spec.PMM.ServerUser
+		}
+		if config.Spec.PMM.Image != "" {
+			settings.Image = config.Spec.PMM.Image
+		}
+		settings.Resources = config.Spec.PMM.Resources
+		settings.InsecureTLS = config.Spec.PMM.InsecureTLS
+		if config.Spec.CredentialsSecretRef != nil {
+			settings.PasswordSecretRef = &corev1.SecretKeySelector{
+				LocalObjectReference: *config.Spec.CredentialsSecretRef,
+				Key:                  "password",
+			}
+		}
+	}
+
+	// The PMM section of the cluster overrides the shared config
+	if spec.PMM != nil {
+		if spec.PMM.ServerHost != "" {
+			settings.ServerHost = spec.PMM.ServerHost
+		}
+		if spec.PMM.ServerUser != "" {
+			settings.ServerUser = spec.PMM.ServerUser
+		}
+		if spec.PMM.ServerPasswordSecretRef != nil {
+			settings.PasswordSecretRef = spec.PMM.ServerPasswordSecretRef
+		}
+	}
+
+	if settings.ServerHost == "" {
+		return nil, configErrorf("PMM server host is not set")
+	}
+	if settings.PasswordSecretRef == nil {
+		return nil, configErrorf("PMM server password secret is not set")
+	}
+	return settings, nil
+}
+
+// PMMServerAddress returns the host:port the PMM client connects to
+func PMMServerAddress(settings *PMMSettings) string {
+	return fmt.Sprintf("%s:%d", settings.ServerHost, settings.ServerPort)
+}
+
+// PMMEndpoint returns the URL of the PMM server
+func PMMEndpoint(settings *PMMSettings) string {
+	return "https://" + PMMServerAddress(settings)
+}
+
+// PMMClientContainer builds the PMM client container, which registers its node with the server
+// on start and adds the database service. It is meant to run as a sidecar of the database pods,
+// or next to them for engines whose pods do not accept extra containers. Without a sidecar the
+// node is the client pod itself, so PMM shows no host metrics of the database instances.
+func PMMClientContainer(settings *PMMSettings, service PMMService) corev1.Container {
+	secretEnv := func(name, secret, key string) corev1.EnvVar {
+		return corev1.EnvVar{
+			Name: name,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: secret}, Key: key},
+			},
+		}
+	}
+
+	addService := strings.Join([]string{
+		"pmm-admin add", service.Type,
+		"--skip-connection-check",
+		"--username=$(DB_USER)",
+		"--password=$(DB_PASSWORD)",
+		fmt.Sprintf("--host=%s", service.Host),
+		fmt.Sprintf("--port=%d", service.Port),
+		fmt.Sprintf("--service-name=%s", service.ServiceName),
+		fmt.Sprintf("--cluster=%s", service.ClusterName),
+	}, " ")
+
+	container := corev1.Container{
+		Name:      "pmm-client",
+		Image:     settings.Image,
+		Resources: settings.Resources,
+		Ports: []corev1.ContainerPort{
+			{Name: "pmm-agent", ContainerPort: pmmAgentPort},
+		},
+		Env: []corev1.EnvVar{
+			secretEnv("DB_USER", service.CredentialsSecret, "username"),
+			secretEnv("DB_PASSWORD", service.CredentialsSecret, "password"),
+			{Name: "PMM_AGENT_SERVER_ADDRESS", Value: PMMServerAddress(settings)},
+			{Name: "PMM_AGENT_SERVER_USERNAME", Value: settings.ServerUser},
+			{
+				Name:      "PMM_AGENT_SERVER_PASSWORD",
+				ValueFrom: &corev1.EnvVarSource{SecretKeyRef: settings.PasswordSecretRef},
+			},
+			{Name: "PMM_AGENT_LISTEN_ADDRESS", Value: "0.0.0.0"},
+			{Name: "PMM_AGENT_LISTEN_PORT", Value: fmt.Sprint(pmmAgentPort)},
+			{Name: "PMM_AGENT_CONFIG_FILE", Value: pmmAgentConfigFile},
+			{Name: "PMM_AGENT_SETUP", Value: "1"},
+			{Name: "PMM_AGENT_SETUP_FORCE", Value: "1"},
+			{Name: "PMM_AGENT_SETUP_NODE_TYPE", Value: "container"},
+			{Name: "PMM_AGENT_SETUP_NODE_NAME", Value: service.NodeName},
+			{Name: "PMM_AGENT_SETUP_METRICS_MODE", Value: "push"},
+			{Name: "PMM_AGENT_PRERUN_SCRIPT", Value: "pmm-admin status --wait=10s && " + addService},
+		},
+		// The client is ready once pmm-agent is connected to the server
+		ReadinessProbe: &corev1.Probe{
+			ProbeHandler: corev1.ProbeHandler{
+				Exec: &corev1.ExecAction{
+					Command: []string{"sh", "-c", fmt.Sprintf(`curl -sf http://127.0.0.1:%d/local/Status | grep -q '"connected":true'`, pmmAgentPort)},
+				},
+			},
+			PeriodSeconds:    10,
+			FailureThreshold: 3,
+		},
+	}
+	if settings.InsecureTLS {
+		container.Env = append(container.Env, corev1.EnvVar{Name: "PMM_AGENT_SERVER_INSECURE_TLS", Value: "1"})
+	}
+	return container
+}
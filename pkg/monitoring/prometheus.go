@@ -0,0 +1,222 @@
+package monitoring
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	"github.com/prometheus/common/model"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dbaasv1 "github.com/huynt0812/dbaas-operator/api/v1"
+)
+
+// Target describes the metrics endpoint exposed by the pods of a database cluster
+type Target struct {
+	// Name is the name of the monitor objects
+	Name string
+
+	// Namespace is the namespace of the cluster
+	Namespace string
+
+	// Labels are set on the monitor objects, next to the additional labels of the config
+	Labels map[string]string
+
+	// Selector selects the pods exporting metrics
+	Selector map[string]string
+
+	// PortName is the name of the container port serving the metrics
+	PortName string
+
+	// Port is the number of the container port serving the metrics
+	Port int32
+
+	// Path is the HTTP path of the metrics
+	Path string
+}
+
+// ConfigError reports a monitoring configuration that cannot be used as is. Retrying does not help,
+// so providers skip the objects it describes and the status of the cluster reports it.
+type ConfigError struct {
+	Err error
+}
+
+func (e *ConfigError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+// configErrorf formats a ConfigError
+func configErrorf(format string, args ...interface{}) error {
+	return &ConfigError{Err: fmt.Errorf(format, args...)}
+}
+
+// IsConfigError reports whether err comes from an unusable monitoring configuration rather than
+// from the API server
+func IsConfigError(err error) bool {
+	var configErr *ConfigError
+	return errors.As(err, &configErr)
+}
+
+// ResolveConfig returns the MonitoringConfig referenced by the monitoring spec of the cluster, if any
+func ResolveConfig(ctx context.Context, c client.Client, cluster *dbaasv1.DatabaseCluster) (*dbaasv1.MonitoringConfig, error) {
+	spec := cluster.Spec.Monitoring
+	if spec == nil || spec.MonitoringConfigRef == nil {
+		return nil, nil
+	}
+
+	config := &dbaasv1.MonitoringConfig{}
+	if err := c.Get(ctx, types.NamespacedName{Name: spec.MonitoringConfigRef.Name, Namespace: cluster.Namespace}, config); apierrors.IsNotFound(err) {
+		return nil, configErrorf("MonitoringConfig %s not found", spec.MonitoringConfigRef.Name)
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get MonitoringConfig %s: %w", spec.MonitoringConfigRef.Name, err)
+	}
+	return config, nil
+}
+
+// ValidatePrometheusConfig checks the scrape settings of a prometheus MonitoringConfig
+func ValidatePrometheusConfig(config *dbaasv1.PrometheusConfigSpec) error {
+	var interval, timeout model.Duration
+	var err error
+	if config.Interval != "" {
+		if interval, err = model.ParseDuration(config.Interval); err != nil {
+			return configErrorf("invalid scrape interval %q: %w", config.Interval, err)
+		}
+	}
+	if config.ScrapeTimeout != "" {
+		if timeout, err = model.ParseDuration(config.ScrapeTimeout); err != nil {
+			return configErrorf("invalid scrape timeout %q: %w", config.ScrapeTimeout, err)
+		}
+	}
+	if interval != 0 && timeout > interval {
+		return configErrorf("scrape timeout %s is longer than the scrape interval %s", config.ScrapeTimeout, config.Interval)
+	}
+	return nil
+}
+
+// PodMonitor builds a PodMonitor scraping the pods of the target
+func PodMonitor(target Target, config *dbaasv1.PrometheusConfigSpec) *monitoringv1.PodMonitor {
+	return &monitoringv1.PodMonitor{
+		ObjectMeta: objectMeta(target, config),
+		Spec: monitoringv1.PodMonitorSpec{
+			Selector: metav1.LabelSelector{MatchLabels: target.Selector},
+			PodMetricsEndpoints: []monitoringv1.PodMetricsEndpoint{
+				{
+					Port:                 target.PortName,
+					Path:                 target.Path,
+					Interval:             monitoringv1.Duration(config.Interval),
+					ScrapeTimeout:        monitoringv1.Duration(config.ScrapeTimeout),
+					RelabelConfigs:       relabelConfigs(config.Relabelings),
+					MetricRelabelConfigs: relabelConfigs(config.MetricRelabelings),
+				},
+			},
+		},
+	}
+}
+
+// ServiceMonitor builds a ServiceMonitor scraping the metrics Service of the target
+func ServiceMonitor(target Target, config *dbaasv1.PrometheusConfigSpec) *monitoringv1.ServiceMonitor {
+	return &monitoringv1.ServiceMonitor{
+		ObjectMeta: objectMeta(target, config),
+		Spec: monitoringv1.ServiceMonitorSpec{
+			Selector: metav1.LabelSelector{MatchLabels: MetricsService(target).Labels},
+			Endpoints: []monitoringv1.Endpoint{
+				{
+					Port:                 target.PortName,
+					Path:                 target.Path,
+					Interval:             monitoringv1.Duration(config.Interval),
+					ScrapeTimeout:        monitoringv1.Duration(config.ScrapeTimeout),
+					RelabelConfigs:       relabelConfigs(config.Relabelings),
+					MetricRelabelConfigs: relabelConfigs(config.MetricRelabelings),
+				},
+			},
+		},
+	}
+}
+
+// MetricsService builds the headless Service selected by the ServiceMonitor of the target,
+// with one endpoint per pod so that every instance is scraped
+func MetricsService(target Target) *corev1.Service {
+	labels := map[string]string{"dbaas.io/metrics": target.Name}
+	for k, v := range target.Labels {
+		labels[k] = v
+	}
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      MetricsServiceName(target),
+			Namespace: target.Namespace,
+			Labels:    labels,
+		},
+		Spec: corev1.ServiceSpec{
+			ClusterIP: corev1.ClusterIPNone,
+			Selector:  target.Selector,
+			Ports: []corev1.ServicePort{
+				{
+					Name:       target.PortName,
+					Port:       target.Port,
+					TargetPort: intstr.FromString(target.PortName),
+				},
+			},
+		},
+	}
+}
+
+// MetricsServiceName returns the name of the metrics Service of the target
+func MetricsServiceName(target Target) string {
+	return target.Name + "-metrics"
+}
+
+// MetricsEndpoint returns the URL of the metrics served behind the metrics Service of the target
+func MetricsEndpoint(target Target) string {
+	return fmt.Sprintf("http://%s.%s.svc:%d%s", MetricsServiceName(target), target.Namespace, target.Port, target.Path)
+}
+
+// objectMeta returns the metadata of a monitor, the additional labels of the config selecting it for a Prometheus
+func objectMeta(target Target, config *dbaasv1.PrometheusConfigSpec) metav1.ObjectMeta {
+	labels := make(map[string]string, len(target.Labels)+len(config.AdditionalLabels))
+	for k, v := range config.AdditionalLabels {
+		labels[k] = v
+	}
+	for k, v := range target.Labels {
+		labels[k] = v
+	}
+	return metav1.ObjectMeta{
+		Name:      target.Name,
+		Namespace: target.Namespace,
+		Labels:    labels,
+	}
+}
+
+// relabelConfigs converts relabeling rules to the prometheus-operator format
+func relabelConfigs(rules []dbaasv1.RelabelConfig) []*monitoringv1.RelabelConfig {
+	if len(rules) == 0 {
+		return nil
+	}
+	configs := make([]*monitoringv1.RelabelConfig, 0, len(rules))
+	for _, rule := range rules {
+		config := &monitoringv1.RelabelConfig{
+			TargetLabel: rule.TargetLabel,
+			Regex:       rule.Regex,
+			Replacement: rule.Replacement,
+			Action:      rule.Action,
+		}
+		for _, label := range rule.SourceLabels {
+			config.SourceLabels = append(config.SourceLabels, monitoringv1.LabelName(label))
+		}
+		if rule.Separator != "" {
+			separator := rule.Separator
+			config.Separator = &separator
+		}
+		configs = append(configs, config)
+	}
+	return configs
+}
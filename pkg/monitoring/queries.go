@@ -0,0 +1,129 @@
+package monitoring
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	dbaasv1 "github.com/huynt0812/dbaas-operator/api/v1"
+)
+
+// Usages of the columns of a custom query
+const (
+	UsageGauge   = "GAUGE"
+	UsageCounter = "COUNTER"
+	UsageLabel   = "LABEL"
+	UsageDiscard = "DISCARD"
+)
+
+// metricNamePattern matches the names usable in Prometheus metric and label names
+var metricNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// CustomQueries returns the custom queries of the cluster: those of its MonitoringConfig,
+// then those of the cluster, a query of the cluster replacing the config query of the same name
+func CustomQueries(cluster *dbaasv1.DatabaseCluster, config *dbaasv1.MonitoringConfig) []dbaasv1.CustomQuery {
+	spec := cluster.Spec.Monitoring
+	if spec == nil || !spec.Enabled {
+		return nil
+	}
+
+	var queries []dbaasv1.CustomQuery
+	index := make(map[string]int)
+	add := func(query dbaasv1.CustomQuery) {
+		if i, ok := index[query.Name]; ok {
+			queries[i] = query
+			return
+		}
+		index[query.Name] = len(queries)
+		queries = append(queries, query)
+	}
+	if config != nil {
+		for _, query := range config.Spec.CustomQueries {
+			add(query)
+		}
+	}
+	for _, query := range spec.CustomQueries {
+		add(query)
+	}
+	return queries
+}
+
+// ValidateCustomQueries checks that the queries are single read-only statements exporting at
+// least one metric under valid and unique names
+func ValidateCustomQueries(queries []dbaasv1.CustomQuery) error {
+	names := make(map[string]bool)
+	for _, query := range queries {
+		if !metricNamePattern.MatchString(query.Name) {
+			return fmt.Errorf("custom query name %q is not a valid metric name", query.Name)
+		}
+		if names[query.Name] {
+			return fmt.Errorf("custom query %s is defined twice", query.Name)
+		}
+		names[query.Name] = true
+
+		if err := validateStatement(query.Query); err != nil {
+			return fmt.Errorf("custom query %s: %w", query.Name, err)
+		}
+		for _, database := range query.Databases {
+			if database == "" {
+				return fmt.Errorf("custom query %s has an empty database name", query.Name)
+			}
+		}
+
+		columns := make(map[string]bool)
+		exported := false
+		for _, metric := range query.Metrics {
+			if !metricNamePattern.MatchString(metric.Column) {
+				return fmt.Errorf("custom query %s: column %q is not a valid metric name", query.Name, metric.Column)
+			}
+			if columns[metric.Column] {
+				return fmt.Errorf("custom query %s maps column %s twice", query.Name, metric.Column)
+			}
+			columns[metric.Column] = true
+
+			switch metric.Usage {
+			case "", UsageGauge, UsageCounter:
+				exported = true
+			case UsageLabel, UsageDiscard:
+			default:
+				return fmt.Errorf("custom query %s: unsupported usage %s for column %s", query.Name, metric.Usage, metric.Column)
+			}
+		}
+		if !exported {
+			return fmt.Errorf("custom query %s exports no GAUGE or COUNTER column", query.Name)
+		}
+	}
+	return nil
+}
+
+// validateStatement accepts a single SELECT or WITH statement, ignoring quoted text
+func validateStatement(query string) error {
+	statement := strings.TrimSpace(query)
+	statement = strings.TrimSpace(strings.TrimSuffix(statement, ";"))
+	if statement == "" {
+		return fmt.Errorf("query is empty")
+	}
+
+	keyword := strings.ToUpper(strings.Fields(statement)[0])
+	if keyword != "SELECT" && keyword != "WITH" {
+		return fmt.Errorf("query must be a SELECT or WITH statement, got %s", keyword)
+	}
+
+	var quote rune
+	for _, r := range statement {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == ';':
+			return fmt.Errorf("query must be a single statement")
+		}
+	}
+	if quote != 0 {
+		return fmt.Errorf("query has an unterminated quote")
+	}
+	return nil
+}
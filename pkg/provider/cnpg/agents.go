@@ -0,0 +1,164 @@
+package cnpg
+
+import (
+	"context"
+	"fmt"
+
+	cnpgv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	dbaasv1 "github.com/huynt0812/dbaas-operator/api/v1"
+	"github.com/huynt0812/dbaas-operator/pkg/monitoring"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// agentConfig returns the datadog or newrelic MonitoringConfig referenced by the cluster, if any
+func agentConfig(ctx context.Context, c client.Client, cluster *dbaasv1.DatabaseCluster) (*dbaasv1.MonitoringConfig, error) {
+	if cluster.Spec.Monitoring == nil || !cluster.Spec.Monitoring.Enabled {
+		return nil, nil
+	}
+	config, err := monitoring.ResolveConfig(ctx, c, cluster)
+	if err != nil || config == nil {
+		return nil, err
+	}
+	if config.Spec.Type != "datadog" && config.Spec.Type != "newrelic" {
+		return nil, nil
+	}
+	return config, nil
+}
+
+// agentCredentialsSecret returns the secret holding the database credentials of the agent
+func agentCredentialsSecret(cluster *dbaasv1.DatabaseCluster, config *dbaasv1.MonitoringConfig) string {
+	if config.Spec.CredentialsSecretRef != nil {
+		return config.Spec.CredentialsSecretRef.Name
+	}
+	return monitoringSecretName(cluster)
+}
+
+// newRelicConfigMapName returns the name of the ConfigMap holding the New Relic integration of the cluster
+func newRelicConfigMapName(cluster *dbaasv1.DatabaseCluster) string {
+	return fmt.Sprintf("%s-newrelic", cluster.Name)
+}
+
+// agentTarget describes the instances of the cluster to the Datadog and New Relic agents
+func agentTarget(cluster *dbaasv1.DatabaseCluster, username, secretName string) monitoring.AgentTarget {
+	return monitoring.AgentTarget{
+		Cluster:   cluster.Name,
+		Namespace: cluster.Namespace,
+		Selector: map[string]string{
+			"cnpg.io/cluster": cluster.Name,
+			"cnpg.io/podRole": "instance",
+		},
+		Container:           "postgres",
+		Port:                5432,
+		Username:            username,
+		CredentialsSecret:   secretName,
+		DatadogCheck:        "postgres",
+		DatadogInstance:     map[string]interface{}{"dbname": "postgres"},
+		NewRelicIntegration: "nri-postgresql",
+		NewRelicEnv: map[string]string{
+			"DATABASE":                 "postgres",
+			"ENABLE_SSL":               "true",
+			"TRUST_SERVER_CERTIFICATE": "true",
+		},
+	}
+}
+
+// reconcileAgent creates the monitoring user of a Datadog or New Relic agent and renders what
+// the agent needs to find the instances: Autodiscovery annotations on the pods for Datadog,
+// an integration ConfigMap for New Relic
+func (a *CNPGApplier) reconcileAgent(config *dbaasv1.MonitoringConfig) error {
+	ctx := context.TODO()
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      newRelicConfigMapName(a.cluster),
+			Namespace: a.cluster.Namespace,
+		},
+	}
+	if config == nil || config.Spec.Type != "newrelic" {
+		if err := client.IgnoreNotFound(a.client.Delete(ctx, configMap)); err != nil {
+			return err
+		}
+	}
+	if config == nil {
+		return nil
+	}
+
+	secretName := agentCredentialsSecret(a.cluster, config)
+	username, err := a.monitoringRole(secretName)
+	if err != nil {
+		return err
+	}
+	target := agentTarget(a.cluster, username, secretName)
+
+	switch config.Spec.Type {
+	case "datadog":
+		annotations, err := monitoring.DatadogAnnotations(target, config.Spec.Datadog)
+		if err != nil {
+			return err
+		}
+		// CNPG propagates the inherited metadata to the instance pods
+		if a.cnpgCluster.Spec.InheritedMetadata == nil {
+			a.cnpgCluster.Spec.InheritedMetadata = &cnpgv1.EmbeddedObjectMetadata{}
+		}
+		if a.cnpgCluster.Spec.InheritedMetadata.Annotations == nil {
+			a.cnpgCluster.Spec.InheritedMetadata.Annotations = make(map[string]string)
+		}
+		for k, v := range annotations {
+			a.cnpgCluster.Spec.InheritedMetadata.Annotations[k] = v
+		}
+	case "newrelic":
+		integration, err := monitoring.NewRelicIntegration(target, config.Spec.NewRelic)
+		if err != nil {
+			return err
+		}
+		if _, err := controllerutil.CreateOrUpdate(ctx, a.client, configMap, func() error {
+			configMap.Labels = map[string]string{
+				"dbaas.io/cluster": a.cluster.Name,
+				"dbaas.io/engine":  a.cluster.Spec.Engine.Type,
+			}
+			configMap.Data = map[string]string{monitoring.NewRelicIntegrationKey(target): integration}
+			return controllerutil.SetControllerReference(a.cluster, configMap, a.scheme)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// agentStatus reports whether the monitoring user of the agent exists in the database
+func (p *CNPGProvider) agentStatus(ctx context.Context, cluster *dbaasv1.DatabaseCluster, cnpgCluster *cnpgv1.Cluster, config *dbaasv1.MonitoringConfig) *dbaasv1.MonitoringStatus {
+	status := &dbaasv1.MonitoringStatus{Enabled: true}
+
+	secretName := agentCredentialsSecret(cluster, config)
+	secret := &corev1.Secret{}
+	if err := p.client.Get(ctx, types.NamespacedName{Name: secretName, Namespace: cluster.Namespace}, secret); err != nil {
+		status.Message = fmt.Sprintf("failed to get monitoring credentials %s: %v", secretName, err)
+		return status
+	}
+	username, err := monitoringUsername(secret)
+	if err != nil {
+		status.Message = err.Error()
+		return status
+	}
+
+	if reasons := cnpgCluster.Status.ManagedRolesStatus.CannotReconcile[username]; len(reasons) > 0 {
+		status.Message = fmt.Sprintf("monitoring user %s cannot be reconciled: %v", username, reasons)
+		return status
+	}
+	reconciled := false
+	for _, role := range cnpgCluster.Status.ManagedRolesStatus.ByStatus[cnpgv1.RoleStatusReconciled] {
+		reconciled = reconciled || role == username
+	}
+	switch {
+	case !reconciled:
+		status.Message = fmt.Sprintf("monitoring user %s is not created yet", username)
+	case cnpgCluster.Status.ReadyInstances == 0:
+		status.Message = "no instance is ready to be monitored"
+	default:
+		status.Ready = true
+	}
+	return status
+}
@@ -1,18 +1,28 @@
 package cnpg
 
 import (
+	"context"
 	"fmt"
+	"time"
 
 	cnpgv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
 	dbaasv1 "github.com/huynt0812/dbaas-operator/api/v1"
+	"github.com/huynt0812/dbaas-operator/pkg/backupstorage"
+	"github.com/huynt0812/dbaas-operator/pkg/monitoring"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
 
+// recoverySourceName is the external cluster a restored cluster recovers from
+const recoverySourceName = "origin"
+
 // CNPGApplier implements the Applier interface for CloudNativePG
 type CNPGApplier struct {
 	cluster      *dbaasv1.DatabaseCluster
@@ -102,6 +112,10 @@ func (a *CNPGApplier) Engine() (runtime.Object, error) {
 		}
 	}
 
+	// Logical backups and restores run as the superuser, CNPG only keeps its credentials when access is enabled
+	enableSuperuserAccess := true
+	a.cnpgCluster.Spec.EnableSuperuserAccess = &enableSuperuserAccess
+
 	// Apply custom configuration from spec.config
 	if len(a.cluster.Spec.Config) > 0 {
 		postgresqlConfig := make(map[string]string)
@@ -113,6 +127,16 @@ func (a *CNPGApplier) Engine() (runtime.Object, error) {
 		}
 	}
 
+	// Apply the logging section
+	if err := a.logging(); err != nil {
+		return nil, err
+	}
+
+	// Load pg_stat_statements for query insights
+	if err := a.queryInsights(); err != nil {
+		return nil, err
+	}
+
 	// Set owner reference
 	if err := controllerutil.SetControllerReference(a.cluster, a.cnpgCluster, a.scheme); err != nil {
 		return nil, err
@@ -123,39 +147,64 @@ func (a *CNPGApplier) Engine() (runtime.Object, error) {
 
 // Proxy applies proxy configuration
 func (a *CNPGApplier) Proxy() (runtime.Object, error) {
-	if a.cluster.Spec.Proxy != nil && a.cluster.Spec.Proxy.Enabled {
-		// CNPG uses PgBouncer pooler
-		if a.cluster.Spec.Proxy.Type == "pgbouncer" || a.cluster.Spec.Proxy.Type == "" {
-			a.cnpgCluster.Spec.Managed = &cnpgv1.ManagedConfiguration{
-				Roles: []cnpgv1.RoleConfiguration{
-					{
-						Name: "app",
-					},
-				},
-			}
+	if err := a.reconcileEndpoints(); err != nil {
+		return nil, err
+	}
 
-			// Note: Full PgBouncer integration would require additional configuration
-			// This is a simplified example
-			// pooler instances would be configured here
-		}
+	// CNPG runs PgBouncer through Pooler objects
+	pooler, err := a.reconcilePoolers()
+	if err != nil || pooler == nil {
+		return nil, err
 	}
-	return nil, nil
+	return pooler, nil
 }
 
 // Monitoring applies monitoring configuration
 func (a *CNPGApplier) Monitoring() (runtime.Object, error) {
 	if a.cluster.Spec.Monitoring != nil && a.cluster.Spec.Monitoring.Enabled {
-		// Enable monitoring in CNPG
+		// CNPG manages its own PodMonitor unless the monitors come from a MonitoringConfig
 		a.cnpgCluster.Spec.Monitoring = &cnpgv1.MonitoringConfiguration{
-			EnablePodMonitor: true,
+			EnablePodMonitor: a.cluster.Spec.Monitoring.MonitoringConfigRef == nil,
 		}
+	}
+
+	if err := a.reconcileCustomQueries(); err != nil {
+		return nil, err
+	}
 
-		// If PMM is configured, set up PMM sidecar
-		if a.cluster.Spec.Monitoring.PMM != nil {
-			// PMM would be configured as a sidecar container
-			// This is simplified - actual implementation would add PMM container
+	// An unusable monitoring configuration does not block the database, the monitoring status
+	// reports it and the objects of the last usable configuration are kept
+	ctx := context.TODO()
+	settings, err := pmmSettings(ctx, a.client, a.cluster)
+	if err != nil && !monitoring.IsConfigError(err) {
+		return nil, err
+	} else if err == nil {
+		if err := a.reconcilePMMClient(settings); err != nil && !monitoring.IsConfigError(err) {
+			return nil, err
+		}
+	}
+	agent, err := agentConfig(ctx, a.client, a.cluster)
+	if err != nil && !monitoring.IsConfigError(err) {
+		return nil, err
+	} else if err == nil {
+		if err := a.reconcileAgent(agent); err != nil && !monitoring.IsConfigError(err) {
+			return nil, err
+		}
+	}
+	prometheus, err := prometheusConfig(ctx, a.client, a.cluster)
+	if err != nil && !monitoring.IsConfigError(err) {
+		return nil, err
+	} else if err == nil {
+		if err := a.reconcileMonitors(prometheus); err != nil {
+			return nil, err
 		}
 	}
+	if err := a.reconcileAlertRules(); err != nil {
+		return nil, err
+	}
+	if err := a.reconcileDashboards(); err != nil {
+		return nil, err
+	}
 	return nil, nil
 }
 
@@ -192,35 +241,166 @@ func (a *CNPGApplier) PodSchedulingPolicy() error {
 // Backup applies backup configuration
 func (a *CNPGApplier) Backup() error {
 	if a.cluster.Spec.Backup != nil && a.cluster.Spec.Backup.Enabled {
+		// Hooks run around Backup OpsRequests only, the controller reports scheduled
+		// backups taken without them in the BackupHooksApplied condition
 		backup := &cnpgv1.BackupConfiguration{
 			RetentionPolicy: a.cluster.Spec.Backup.RetentionPolicy,
 		}
 
 		if a.cluster.Spec.Backup.BackupStorageRef != nil {
-			// Would fetch BackupStorage CR and configure barman object store
-			// This is simplified
-			backup.BarmanObjectStore = &cnpgv1.BarmanObjectStoreConfiguration{
-				DestinationPath: "s3://backups/",
-				ServerName:      a.cluster.Name,
+			// Point barman at the referenced BackupStorage
+			storage, err := getBackupStorage(context.TODO(), a.client, a.cluster.Namespace, a.cluster.Spec.Backup.BackupStorageRef.Name)
+			if err != nil {
+				return err
+			}
+			if err := a.bindWorkloadIdentity(storage); err != nil {
+				return err
+			}
+			// File-based storages are written by backup Jobs instead of barman
+			if !backupstorage.IsFileStorage(storage) {
+				objectStore, err := barmanObjectStore(storage, archiveServerName(a.cluster))
+				if err != nil {
+					return err
+				}
+				backup.BarmanObjectStore = objectStore
 			}
 		}
 
+		if a.cluster.Spec.Backup.VolumeSnapshot != nil {
+			backup.VolumeSnapshot = volumeSnapshotConfiguration(a.cluster.Spec.Backup.VolumeSnapshot)
+		}
+
 		a.cnpgCluster.Spec.Backup = backup
 	}
-	return nil
+
+	return a.scheduledBackup()
+}
+
+// scheduledBackup creates, updates or removes the objects running the scheduled backups of the cluster:
+// a CNPG ScheduledBackup, or a CronJob for logical backups and file-based storages
+func (a *CNPGApplier) scheduledBackup() error {
+	ctx := context.TODO()
+	scheduled := &cnpgv1.ScheduledBackup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      a.cluster.Name,
+			Namespace: a.cluster.Namespace,
+		},
+	}
+	cronJob := &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-backup", a.cluster.Name),
+			Namespace: a.cluster.Namespace,
+		},
+	}
+
+	spec := a.cluster.Spec.Backup
+	if spec == nil || !spec.Enabled || spec.Schedule == "" {
+		if err := a.client.Delete(ctx, cronJob); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+		return client.IgnoreNotFound(a.client.Delete(ctx, scheduled))
+	}
+
+	// Logical backups and backups to file-based storages are taken by Jobs
+	var jobSpec *batchv1.JobSpec
+	if backupMethod(spec.Method) == dbaasv1.BackupMethodLogical {
+		if spec.BackupStorageRef == nil {
+			return fmt.Errorf("backupStorageRef is required for scheduled %s backups", dbaasv1.BackupMethodLogical)
+		}
+		storage, err := getBackupStorage(ctx, a.client, a.cluster.Namespace, spec.BackupStorageRef.Name)
+		if err != nil {
+			return err
+		}
+		logicalSpec, err := logicalBackupJobSpec(a.cluster, storage, logicalBackupSpec(a.cluster, nil))
+		if err != nil {
+			return err
+		}
+		jobSpec = &logicalSpec
+	}
+
+	var method cnpgv1.BackupMethod
+	if jobSpec == nil {
+		var err error
+		if method, err = cnpgBackupMethod(spec.Method); err != nil {
+			return err
+		}
+		switch method {
+		case cnpgv1.BackupMethodBarmanObjectStore:
+			if spec.BackupStorageRef == nil {
+				return fmt.Errorf("backupStorageRef is required for scheduled %s backups", dbaasv1.BackupMethodObjectStore)
+			}
+			fileStorage, err := fileBackupStorage(ctx, a.client, a.cluster)
+			if err != nil {
+				return err
+			}
+			if fileStorage != nil {
+				fileSpec, err := fileBackupJobSpec(a.cluster, fileStorage)
+				if err != nil {
+					return err
+				}
+				jobSpec = &fileSpec
+			}
+		case cnpgv1.BackupMethodVolumeSnapshot:
+			if spec.VolumeSnapshot == nil {
+				return fmt.Errorf("volumeSnapshot is required for scheduled %s backups", dbaasv1.BackupMethodVolumeSnapshot)
+			}
+		}
+	}
+
+	if jobSpec != nil {
+		if err := client.IgnoreNotFound(a.client.Delete(ctx, scheduled)); err != nil {
+			return err
+		}
+		_, err := controllerutil.CreateOrUpdate(ctx, a.client, cronJob, func() error {
+			cronJob.Labels = jobSpec.Template.Labels
+			cronJob.Spec.Schedule = spec.Schedule
+			cronJob.Spec.ConcurrencyPolicy = batchv1.ForbidConcurrent
+			cronJob.Spec.JobTemplate.Labels = jobSpec.Template.Labels
+			cronJob.Spec.JobTemplate.Spec = *jobSpec
+			return controllerutil.SetControllerReference(a.cluster, cronJob, a.scheme)
+		})
+		return err
+	}
+
+	if err := a.client.Delete(ctx, cronJob); client.IgnoreNotFound(err) != nil {
+		return err
+	}
+	_, err := controllerutil.CreateOrUpdate(ctx, a.client, scheduled, func() error {
+		scheduled.Labels = map[string]string{
+			"dbaas.io/cluster": a.cluster.Name,
+			"dbaas.io/engine":  a.cluster.Spec.Engine.Type,
+		}
+		scheduled.Spec.Schedule = cnpgSchedule(spec.Schedule)
+		scheduled.Spec.Cluster = cnpgv1.LocalObjectReference{Name: a.cluster.Name}
+		scheduled.Spec.Method = method
+		return controllerutil.SetControllerReference(a.cluster, scheduled, a.scheme)
+	})
+	return err
 }
 
 // DataSource applies data source configuration (restore, clone)
 func (a *CNPGApplier) DataSource() error {
+	if source := bootstrapBackup(a.cluster); source != nil {
+		// The backup only matters when the cluster is bootstrapped. Once the CNPG Cluster
+		// exists it keeps its recovery settings, so the backup may be deleted afterwards.
+		existing := &cnpgv1.Cluster{}
+		err := a.client.Get(context.TODO(), types.NamespacedName{Name: a.cnpgCluster.Name, Namespace: a.cnpgCluster.Namespace}, existing)
+		if err == nil {
+			a.cnpgCluster.Spec.Bootstrap = existing.Spec.Bootstrap
+			a.cnpgCluster.Spec.ExternalClusters = existing.Spec.ExternalClusters
+			return nil
+		} else if !errors.IsNotFound(err) {
+			return err
+		}
+
+		a.cnpgCluster.Spec.Bootstrap = &cnpgv1.BootstrapConfiguration{
+			Recovery: &cnpgv1.BootstrapRecovery{},
+		}
+		return a.backupRecovery(source)
+	}
+
 	if a.cluster.Spec.DataSource != nil {
-		if a.cluster.Spec.DataSource.BackupSource != nil {
-			// Configure bootstrap from backup
-			// Note: CNPG v1.23 has different BackupSource structure
-			// This is a simplified placeholder - full implementation would use proper CNPG API
-			a.cnpgCluster.Spec.Bootstrap = &cnpgv1.BootstrapConfiguration{
-				Recovery: &cnpgv1.BootstrapRecovery{},
-			}
-		} else if a.cluster.Spec.DataSource.CloneSource != nil {
+		if a.cluster.Spec.DataSource.CloneSource != nil {
 			// Configure bootstrap from clone
 			// Note: Simplified - actual implementation would be more complex
 			a.cnpgCluster.Spec.Bootstrap = &cnpgv1.BootstrapConfiguration{
@@ -241,6 +421,223 @@ func (a *CNPGApplier) DataSource() error {
 	return nil
 }
 
+// bootstrapBackup returns the backup the cluster is bootstrapped from: the one of its latest
+// in-place restore, recorded in status, or the one of its data source
+func bootstrapBackup(cluster *dbaasv1.DatabaseCluster) *dbaasv1.BackupSourceSpec {
+	if restore := cluster.Status.Restore; restore != nil {
+		return &dbaasv1.BackupSourceSpec{BackupName: restore.BackupName, PointInTime: restore.PointInTime}
+	}
+	if cluster.Spec.DataSource != nil {
+		return cluster.Spec.DataSource.BackupSource
+	}
+	return nil
+}
+
+// backupRecovery bootstraps the cluster from the backup named by the data source, looked up
+// as a DatabaseBackup, then as a CNPG Backup, then as a backup ID in the given storage
+func (a *CNPGApplier) backupRecovery(source *dbaasv1.BackupSourceSpec) error {
+	ctx := context.TODO()
+	key := types.NamespacedName{Name: source.BackupName, Namespace: a.cluster.Namespace}
+
+	backup := &dbaasv1.DatabaseBackup{}
+	err := a.client.Get(ctx, key, backup)
+	if err == nil {
+		if backup.Status.Phase != dbaasv1.DatabaseBackupPhaseSucceeded {
+			return fmt.Errorf("backup %s is not completed", backup.Name)
+		}
+		if source.PointInTime != nil && backup.Status.CompletedAt != nil && source.PointInTime.Before(backup.Status.CompletedAt) {
+			return fmt.Errorf("point in time %s is before the end of backup %s", source.PointInTime.Format(time.RFC3339), backup.Name)
+		}
+		if backup.Spec.Method == dbaasv1.BackupMethodLogical {
+			// Dumps are loaded into a running cluster, there is nothing to bootstrap from
+			return fmt.Errorf("logical backup %s can only be restored with a Restore OpsRequest", backup.Name)
+		}
+		// Backups taken as volume snapshots are restored by provisioning the volumes from the snapshots
+		if backup.Spec.Method == dbaasv1.BackupMethodVolumeSnapshot {
+			return a.snapshotRecovery(backup.Name, backup.Status.Snapshots)
+		}
+		return a.objectStoreRecovery(backup)
+	} else if !errors.IsNotFound(err) {
+		return err
+	}
+
+	cnpgBackup := &cnpgv1.Backup{}
+	err = a.client.Get(ctx, key, cnpgBackup)
+	if err == nil {
+		return a.cnpgBackupRecovery(cnpgBackup)
+	} else if !errors.IsNotFound(err) {
+		return err
+	}
+
+	if source.BackupStorageRef == nil {
+		return fmt.Errorf("backup %s not found", source.BackupName)
+	}
+	if source.ServerName == "" {
+		return fmt.Errorf("serverName is required to restore backup %s from storage %s", source.BackupName, source.BackupStorageRef.Name)
+	}
+	storage, err := a.recoveryStorage(source.BackupStorageRef.Name)
+	if err != nil {
+		return err
+	}
+	if backupstorage.IsFileStorage(storage) {
+		return a.fileRecovery(source.BackupName)
+	}
+	objectStore, err := barmanObjectStore(storage, source.ServerName)
+	if err != nil {
+		return err
+	}
+	return a.recoverFromObjectStore(objectStore, source.BackupName)
+}
+
+// snapshotRecovery provisions the volumes of the cluster from the snapshots of a backup
+func (a *CNPGApplier) snapshotRecovery(backupName string, snapshots []dbaasv1.BackupSnapshot) error {
+	if bootstrapBackup(a.cluster).PointInTime != nil {
+		return fmt.Errorf("point-in-time recovery is not supported from volume snapshot backup %s", backupName)
+	}
+	source, err := snapshotDataSource(backupName, snapshots)
+	if err != nil {
+		return err
+	}
+	a.cnpgCluster.Spec.Bootstrap.Recovery.VolumeSnapshots = source
+	return nil
+}
+
+// objectStoreRecovery bootstraps the cluster from a backup in an object store.
+// The backup is read from its own storage unless the data source points at another one, such as a secondary copy.
+func (a *CNPGApplier) objectStoreRecovery(backup *dbaasv1.DatabaseBackup) error {
+	source := bootstrapBackup(a.cluster)
+
+	storageName := ""
+	if backup.Spec.BackupStorageRef != nil {
+		storageName = backup.Spec.BackupStorageRef.Name
+	}
+	if source.BackupStorageRef != nil {
+		storageName = source.BackupStorageRef.Name
+	}
+	if storageName == "" {
+		return fmt.Errorf("backup %s has no backup storage", backup.Name)
+	}
+
+	storage, err := a.recoveryStorage(storageName)
+	if err != nil {
+		return err
+	}
+	if backupstorage.IsFileStorage(storage) {
+		return a.fileRecovery(backup.Status.BackupID)
+	}
+
+	serverName := backup.Status.ServerName
+	if serverName == "" {
+		serverName = backup.Spec.ClusterName
+	}
+	objectStore, err := barmanObjectStore(storage, serverName)
+	if err != nil {
+		return err
+	}
+	return a.recoverFromObjectStore(objectStore, backup.Status.BackupID)
+}
+
+// cnpgBackupRecovery bootstraps the cluster from a CNPG Backup that has no DatabaseBackup,
+// reading it with the object store configuration of the cluster it was taken from
+func (a *CNPGApplier) cnpgBackupRecovery(cnpgBackup *cnpgv1.Backup) error {
+	if cnpgBackup.Status.Phase != cnpgv1.BackupPhaseCompleted {
+		return fmt.Errorf("backup %s is not completed", cnpgBackup.Name)
+	}
+	if cnpgBackup.Spec.Method == cnpgv1.BackupMethodVolumeSnapshot {
+		return a.snapshotRecovery(cnpgBackup.Name, backupSnapshots(cnpgBackup))
+	}
+
+	origin := &cnpgv1.Cluster{}
+	if err := a.client.Get(context.TODO(), types.NamespacedName{Name: cnpgBackup.Spec.Cluster.Name, Namespace: cnpgBackup.Namespace}, origin); err != nil {
+		return fmt.Errorf("failed to get cluster %s of backup %s: %w", cnpgBackup.Spec.Cluster.Name, cnpgBackup.Name, err)
+	}
+	if origin.Spec.Backup == nil || origin.Spec.Backup.BarmanObjectStore == nil {
+		return fmt.Errorf("cluster %s of backup %s has no object store", origin.Name, cnpgBackup.Name)
+	}
+
+	objectStore := origin.Spec.Backup.BarmanObjectStore.DeepCopy()
+	if cnpgBackup.Status.DestinationPath != "" {
+		objectStore.DestinationPath = cnpgBackup.Status.DestinationPath
+	}
+	objectStore.ServerName = cnpgBackup.Status.ServerName
+	if objectStore.ServerName == "" {
+		objectStore.ServerName = origin.Name
+	}
+	return a.recoverFromObjectStore(objectStore, cnpgBackup.Status.BackupID)
+}
+
+// recoveryStorage fetches the storage a backup is restored from
+func (a *CNPGApplier) recoveryStorage(name string) (*dbaasv1.BackupStorage, error) {
+	storage, err := getBackupStorage(context.TODO(), a.client, a.cluster.Namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.bindWorkloadIdentity(storage); err != nil {
+		return nil, err
+	}
+	return storage, nil
+}
+
+// bindWorkloadIdentity runs the instances of the cluster with a ServiceAccount bound to the
+// workload identity of the storage, so barman reads and writes it without static keys
+func (a *CNPGApplier) bindWorkloadIdentity(storage *dbaasv1.BackupStorage) error {
+	if !backupstorage.UsesWorkloadIdentity(storage) {
+		return nil
+	}
+	if err := backupstorage.ValidateWorkloadIdentity(storage); err != nil {
+		return err
+	}
+
+	// CNPG creates the ServiceAccount of the instances, named after the cluster
+	if a.cnpgCluster.Spec.ServiceAccountTemplate == nil {
+		a.cnpgCluster.Spec.ServiceAccountTemplate = &cnpgv1.ServiceAccountTemplate{}
+	}
+	metadata := &a.cnpgCluster.Spec.ServiceAccountTemplate.Metadata
+	if metadata.Annotations == nil {
+		metadata.Annotations = make(map[string]string)
+	}
+	for k, v := range backupstorage.ServiceAccountAnnotations(storage) {
+		if existing, ok := metadata.Annotations[k]; ok && existing != v {
+			return fmt.Errorf("storage %s needs a %s annotation other than the one of the other storages of the cluster", storage.Name, k)
+		}
+		metadata.Annotations[k] = v
+	}
+
+	if labels := backupstorage.PodLabels(storage); len(labels) > 0 {
+		if a.cnpgCluster.Spec.InheritedMetadata == nil {
+			a.cnpgCluster.Spec.InheritedMetadata = &cnpgv1.EmbeddedObjectMetadata{}
+		}
+		if a.cnpgCluster.Spec.InheritedMetadata.Labels == nil {
+			a.cnpgCluster.Spec.InheritedMetadata.Labels = make(map[string]string)
+		}
+		for k, v := range labels {
+			a.cnpgCluster.Spec.InheritedMetadata.Labels[k] = v
+		}
+	}
+	return nil
+}
+
+// recoverFromObjectStore points the recovery at an external cluster reading the object store,
+// targeting the given backup and the point in time of the data source
+func (a *CNPGApplier) recoverFromObjectStore(objectStore *cnpgv1.BarmanObjectStoreConfiguration, backupID string) error {
+	a.cnpgCluster.Spec.ExternalClusters = append(a.cnpgCluster.Spec.ExternalClusters, cnpgv1.ExternalCluster{
+		Name:              recoverySourceName,
+		BarmanObjectStore: objectStore,
+	})
+
+	recovery := a.cnpgCluster.Spec.Bootstrap.Recovery
+	recovery.Source = recoverySourceName
+
+	target := &cnpgv1.RecoveryTarget{BackupID: backupID}
+	if pointInTime := bootstrapBackup(a.cluster).PointInTime; pointInTime != nil {
+		target.TargetTime = pointInTime.UTC().Format(time.RFC3339)
+	}
+	if target.BackupID != "" || target.TargetTime != "" {
+		recovery.RecoveryTarget = target
+	}
+	return nil
+}
+
 // DataImport applies data import configuration
 func (a *CNPGApplier) DataImport() error {
 	// Data import would be handled through custom logic
@@ -0,0 +1,230 @@
+package cnpg
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	cnpgv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	dbaasv1 "github.com/huynt0812/dbaas-operator/api/v1"
+	"github.com/huynt0812/dbaas-operator/pkg/backupstorage"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// volumeSnapshotAPIGroup is the API group of CSI VolumeSnapshots
+const volumeSnapshotAPIGroup = "snapshot.storage.k8s.io"
+
+// backupMethod returns the effective backup method, defaulting to the object store
+func backupMethod(method dbaasv1.BackupMethod) dbaasv1.BackupMethod {
+	if method == "" {
+		return dbaasv1.BackupMethodObjectStore
+	}
+	return method
+}
+
+// cnpgBackupMethod maps a DBaaS backup method to the CNPG one
+func cnpgBackupMethod(method dbaasv1.BackupMethod) (cnpgv1.BackupMethod, error) {
+	switch backupMethod(method) {
+	case dbaasv1.BackupMethodObjectStore:
+		return cnpgv1.BackupMethodBarmanObjectStore, nil
+	case dbaasv1.BackupMethodVolumeSnapshot:
+		return cnpgv1.BackupMethodVolumeSnapshot, nil
+	default:
+		return "", fmt.Errorf("backup method %s is not supported by the cnpg provider", method)
+	}
+}
+
+// cnpgSchedule converts a standard 5-field cron schedule to the 6-field format used by CNPG
+func cnpgSchedule(schedule string) string {
+	if len(strings.Fields(schedule)) == 5 {
+		return "0 " + schedule
+	}
+	return schedule
+}
+
+// volumeSnapshotConfiguration builds the CNPG snapshot configuration of a cluster
+func volumeSnapshotConfiguration(spec *dbaasv1.VolumeSnapshotBackupSpec) *cnpgv1.VolumeSnapshotConfiguration {
+	return &cnpgv1.VolumeSnapshotConfiguration{
+		ClassName:    spec.ClassName,
+		WalClassName: spec.WALClassName,
+		Online:       spec.Online,
+	}
+}
+
+// snapshotDataSource builds the CNPG recovery source from the snapshots of a backup
+func snapshotDataSource(backupName string, snapshots []dbaasv1.BackupSnapshot) (*cnpgv1.DataSource, error) {
+	apiGroup := volumeSnapshotAPIGroup
+	ref := func(name string) corev1.TypedLocalObjectReference {
+		return corev1.TypedLocalObjectReference{APIGroup: &apiGroup, Kind: "VolumeSnapshot", Name: name}
+	}
+
+	source := &cnpgv1.DataSource{}
+	for _, snapshot := range snapshots {
+		switch snapshot.Type {
+		case dbaasv1.BackupSnapshotTypeData:
+			source.Storage = ref(snapshot.Name)
+		case dbaasv1.BackupSnapshotTypeWAL:
+			walRef := ref(snapshot.Name)
+			source.WalStorage = &walRef
+		case dbaasv1.BackupSnapshotTypeTablespace:
+			if source.TablespaceStorage == nil {
+				source.TablespaceStorage = make(map[string]corev1.TypedLocalObjectReference)
+			}
+			source.TablespaceStorage[snapshot.TablespaceName] = ref(snapshot.Name)
+		}
+	}
+
+	if source.Storage.Name == "" {
+		return nil, fmt.Errorf("backup %s has no data volume snapshot", backupName)
+	}
+	return source, nil
+}
+
+// syncBackupRecords mirrors the CNPG Backups of a cluster into DatabaseBackups
+func (p *CNPGProvider) syncBackupRecords(ctx context.Context, cluster *dbaasv1.DatabaseCluster) error {
+	backups := &cnpgv1.BackupList{}
+	if err := p.client.List(ctx, backups, client.InNamespace(cluster.Namespace)); err != nil {
+		return err
+	}
+
+	var storage *dbaasv1.BackupStorage
+	if cluster.Spec.Backup != nil && cluster.Spec.Backup.BackupStorageRef != nil {
+		s, err := getBackupStorage(ctx, p.client, cluster.Namespace, cluster.Spec.Backup.BackupStorageRef.Name)
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		storage = s
+	}
+	if storage != nil {
+		if backupstorage.IsFileStorage(storage) {
+			if err := p.syncJobBackupRecords(ctx, cluster, storage, fileBackupLabel, dbaasv1.BackupMethodObjectStore); err != nil {
+				return err
+			}
+		}
+		if err := p.syncJobBackupRecords(ctx, cluster, storage, logicalBackupLabel, dbaasv1.BackupMethodLogical); err != nil {
+			return err
+		}
+	}
+
+	for i := range backups.Items {
+		if backups.Items[i].Spec.Cluster.Name != cluster.Name {
+			continue
+		}
+		if err := p.syncBackupRecord(ctx, cluster, storage, &backups.Items[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// syncBackupRecord creates or refreshes the DatabaseBackup of a single CNPG Backup
+func (p *CNPGProvider) syncBackupRecord(ctx context.Context, cluster *dbaasv1.DatabaseCluster, storage *dbaasv1.BackupStorage, cnpgBackup *cnpgv1.Backup) error {
+	method := dbaasv1.BackupMethodObjectStore
+	if cnpgBackup.Spec.Method == cnpgv1.BackupMethodVolumeSnapshot {
+		method = dbaasv1.BackupMethodVolumeSnapshot
+	}
+
+	backup := &dbaasv1.DatabaseBackup{}
+	err := p.client.Get(ctx, types.NamespacedName{Name: cnpgBackup.Name, Namespace: cnpgBackup.Namespace}, backup)
+	if errors.IsNotFound(err) {
+		backup = &dbaasv1.DatabaseBackup{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      cnpgBackup.Name,
+				Namespace: cnpgBackup.Namespace,
+				Labels: map[string]string{
+					"dbaas.io/cluster": cluster.Name,
+					"dbaas.io/engine":  cluster.Spec.Engine.Type,
+				},
+			},
+			Spec: dbaasv1.DatabaseBackupSpec{
+				ClusterName: cluster.Name,
+				EngineType:  cluster.Spec.Engine.Type,
+				Method:      method,
+			},
+		}
+		if method == dbaasv1.BackupMethodObjectStore && storage != nil {
+			backup.Spec.BackupStorageRef = &corev1.LocalObjectReference{Name: storage.Name}
+		}
+		if err := controllerutil.SetOwnerReference(cnpgBackup, backup, p.scheme); err != nil {
+			return err
+		}
+		if err := p.client.Create(ctx, backup); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+
+	if backup.Spec.ReadOnly {
+		return nil
+	}
+
+	// The record is garbage collected with the CNPG Backup it mirrors, records created
+	// before the reference was set get it on their next refresh
+	owners := len(backup.OwnerReferences)
+	if err := controllerutil.SetOwnerReference(cnpgBackup, backup, p.scheme); err != nil {
+		return err
+	}
+	if len(backup.OwnerReferences) != owners {
+		if err := p.client.Update(ctx, backup); err != nil {
+			return err
+		}
+	}
+
+	previous := backup.Status.DeepCopy()
+	status := &backup.Status
+	switch cnpgBackup.Status.Phase {
+	case cnpgv1.BackupPhaseCompleted:
+		status.Phase = dbaasv1.DatabaseBackupPhaseSucceeded
+	case cnpgv1.BackupPhaseFailed, cnpgv1.BackupPhaseWalArchivingFailing:
+		status.Phase = dbaasv1.DatabaseBackupPhaseFailed
+	case "", cnpgv1.BackupPhasePending:
+		status.Phase = dbaasv1.DatabaseBackupPhasePending
+	default:
+		status.Phase = dbaasv1.DatabaseBackupPhaseRunning
+	}
+	status.BackupID = cnpgBackup.Status.BackupID
+	status.DestinationPath = cnpgBackup.Status.DestinationPath
+	status.ServerName = cnpgBackup.Status.ServerName
+	if status.EngineVersion == "" {
+		status.EngineVersion = cluster.Spec.Engine.Version
+	}
+	status.StartedAt = cnpgBackup.Status.StartedAt
+	status.CompletedAt = cnpgBackup.Status.StoppedAt
+	status.Message = cnpgBackup.Status.Error
+
+	status.Snapshots = backupSnapshots(cnpgBackup)
+
+	if method == dbaasv1.BackupMethodObjectStore && storage != nil && status.StartedAt != nil {
+		status.Encryption = backupstorage.KeyAt(storage, status.StartedAt.Time)
+	}
+
+	if equality.Semantic.DeepEqual(previous, status) {
+		return nil
+	}
+	return p.client.Status().Update(ctx, backup)
+}
+
+// backupSnapshots lists the volume snapshots taken by a CNPG Backup
+func backupSnapshots(cnpgBackup *cnpgv1.Backup) []dbaasv1.BackupSnapshot {
+	var snapshots []dbaasv1.BackupSnapshot
+	for _, element := range cnpgBackup.Status.BackupSnapshotStatus.Elements {
+		snapshot := dbaasv1.BackupSnapshot{Name: element.Name, TablespaceName: element.TablespaceName}
+		switch element.Type {
+		case "PG_DATA":
+			snapshot.Type = dbaasv1.BackupSnapshotTypeData
+		case "PG_WAL":
+			snapshot.Type = dbaasv1.BackupSnapshotTypeWAL
+		default:
+			snapshot.Type = dbaasv1.BackupSnapshotTypeTablespace
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	return snapshots
+}
@@ -0,0 +1,66 @@
+package cnpg
+
+import (
+	"fmt"
+
+	dbaasv1 "github.com/huynt0812/dbaas-operator/api/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ClientContainer returns a psql-capable container connected to the cluster primary as the application user
+func (p *CNPGProvider) ClientContainer(cluster *dbaasv1.DatabaseCluster) (*corev1.Container, error) {
+	return clientContainer(cluster), nil
+}
+
+// StatsClientContainer returns a psql-capable container connected to the cluster primary as the
+// monitoring user, whose pg_monitor membership grants pg_read_all_stats
+func (p *CNPGProvider) StatsClientContainer(cluster *dbaasv1.DatabaseCluster) (*corev1.Container, error) {
+	return credentialsClientContainer(cluster, monitoringSecretName(cluster)), nil
+}
+
+// clientContainer builds a container of the PostgreSQL image with the libpq environment
+// pointing at the primary of the cluster as the application user
+func clientContainer(cluster *dbaasv1.DatabaseCluster) *corev1.Container {
+	// CNPG stores the application user credentials in the <cluster>-app secret
+	return credentialsClientContainer(cluster, fmt.Sprintf("%s-app", cluster.Name))
+}
+
+// superuserClientContainer builds a container of the PostgreSQL image with the libpq environment
+// pointing at the primary of the cluster as the postgres superuser
+func superuserClientContainer(cluster *dbaasv1.DatabaseCluster) *corev1.Container {
+	// CNPG stores the superuser credentials in the <cluster>-superuser secret when superuser access is enabled
+	return credentialsClientContainer(cluster, fmt.Sprintf("%s-superuser", cluster.Name))
+}
+
+// credentialsClientContainer builds a container of the PostgreSQL image with the libpq environment
+// pointing at the primary of the cluster as the user of the given basic-auth secret
+func credentialsClientContainer(cluster *dbaasv1.DatabaseCluster, secretName string) *corev1.Container {
+	appSecret := corev1.LocalObjectReference{Name: secretName}
+
+	return &corev1.Container{
+		Name:  "psql",
+		Image: fmt.Sprintf("ghcr.io/cloudnative-pg/postgresql:%s", cluster.Spec.Engine.Version),
+		Env: []corev1.EnvVar{
+			{Name: "PGHOST", Value: fmt.Sprintf("%s-rw.%s.svc", cluster.Name, cluster.Namespace)},
+			{Name: "PGPORT", Value: "5432"},
+			{Name: "PGDATABASE", Value: "app"},
+			{
+				Name: "PGUSER",
+				ValueFrom: &corev1.EnvVarSource{
+					SecretKeyRef: &corev1.SecretKeySelector{LocalObjectReference: appSecret, Key: "username"},
+				},
+			},
+			{
+				Name: "PGPASSWORD",
+				ValueFrom: &corev1.EnvVarSource{
+					SecretKeyRef: &corev1.SecretKeySelector{LocalObjectReference: appSecret, Key: "password"},
+				},
+			},
+		},
+	}
+}
+
+// QueryCommand returns a psql invocation printing unaligned, tuples-only output
+func (p *CNPGProvider) QueryCommand(sqlEnvVar string) string {
+	return fmt.Sprintf(`psql -X -A -t -v ON_ERROR_STOP=1 -c "$%s"`, sqlEnvVar)
+}
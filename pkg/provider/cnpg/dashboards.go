@@ -0,0 +1,156 @@
+package cnpg
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	dbaasv1 "github.com/huynt0812/dbaas-operator/api/v1"
+	"github.com/huynt0812/dbaas-operator/pkg/monitoring"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// dashboardsConfigMapName returns the name of the ConfigMap holding the dashboards of the cluster
+func dashboardsConfigMapName(cluster *dbaasv1.DatabaseCluster) string {
+	return fmt.Sprintf("%s-dashboards", cluster.Name)
+}
+
+// grafanaConfig returns the Grafana section of the MonitoringConfig referenced by the cluster,
+// or nil when monitoring is disabled or the config does not enable Grafana
+func grafanaConfig(ctx context.Context, c client.Client, cluster *dbaasv1.DatabaseCluster) (*dbaasv1.GrafanaSpec, error) {
+	if cluster.Spec.Monitoring == nil || !cluster.Spec.Monitoring.Enabled {
+		return nil, nil
+	}
+	config, err := monitoring.ResolveConfig(ctx, c, cluster)
+	if err != nil || config == nil {
+		return nil, err
+	}
+	if config.Spec.Grafana == nil || !config.Spec.Grafana.Enabled {
+		return nil, nil
+	}
+	return config.Spec.Grafana, nil
+}
+
+// reconcileDashboards provisions the Grafana dashboards of the cluster in a ConfigMap watched by
+// the Grafana sidecar, or removes it when Grafana is not enabled. The dashboards of an unusable
+// MonitoringConfig are kept, the monitoring status reports the config.
+func (a *CNPGApplier) reconcileDashboards() error {
+	ctx := context.TODO()
+	configMap := &corev1.ConfigMap{}
+	configMap.Name, configMap.Namespace = dashboardsConfigMapName(a.cluster), a.cluster.Namespace
+
+	grafana, err := grafanaConfig(ctx, a.client, a.cluster)
+	if monitoring.IsConfigError(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if grafana == nil {
+		return client.IgnoreNotFound(a.client.Delete(ctx, configMap))
+	}
+
+	dashboards, err := clusterDashboards(a.cluster)
+	if err != nil {
+		return fmt.Errorf("failed to build dashboards: %w", err)
+	}
+	_, err = controllerutil.CreateOrUpdate(ctx, a.client, configMap, func() error {
+		configMap.Labels = map[string]string{
+			"dbaas.io/cluster": a.cluster.Name,
+			"dbaas.io/engine":  a.cluster.Spec.Engine.Type,
+		}
+		for k, v := range monitoring.DashboardLabels(grafana) {
+			configMap.Labels[k] = v
+		}
+		configMap.Annotations = monitoring.DashboardAnnotations(grafana)
+		configMap.Data = make(map[string]string, len(dashboards))
+		for _, dashboard := range dashboards {
+			configMap.Data[monitoring.DashboardFile(dashboard)] = dashboard.JSON
+		}
+		return controllerutil.SetControllerReference(a.cluster, configMap, a.scheme)
+	})
+	return err
+}
+
+// dashboardURL returns the link to the overview dashboard of the cluster, or an empty string
+// when Grafana is not enabled or its URL is not known
+func (p *CNPGProvider) dashboardURL(ctx context.Context, cluster *dbaasv1.DatabaseCluster) string {
+	grafana, err := grafanaConfig(ctx, p.client, cluster)
+	if err != nil || grafana == nil {
+		return ""
+	}
+	dashboards, err := clusterDashboards(cluster)
+	if err != nil || len(dashboards) == 0 {
+		return ""
+	}
+	return monitoring.DashboardURL(grafana, dashboards[0])
+}
+
+// clusterDashboards returns the overview dashboard of a PostgreSQL cluster, built on the metrics
+// of the CNPG exporter, kubelet volume stats and the backup metrics of the operator
+func clusterDashboards(cluster *dbaasv1.DatabaseCluster) ([]monitoring.Dashboard, error) {
+	pods := fmt.Sprintf(`namespace="%s",pod=~"%s-[0-9]+"`, cluster.Namespace, regexp.QuoteMeta(cluster.Name))
+	volumes := fmt.Sprintf(`namespace="%s",persistentvolumeclaim=~"%s-[0-9]+(-wal)?"`, cluster.Namespace, regexp.QuoteMeta(cluster.Name))
+	backups := fmt.Sprintf(`dbaas_namespace="%s",dbaas_cluster="%s"`, cluster.Namespace, cluster.Name)
+
+	panels := []monitoring.DashboardPanel{
+		{
+			Title:  "Instances up",
+			Expr:   fmt.Sprintf("cnpg_collector_up{%s}", pods),
+			Legend: "{{pod}}",
+			Unit:   "short",
+		},
+		{
+			Title:  "Replication lag",
+			Expr:   fmt.Sprintf("max by (pod) (cnpg_pg_replication_lag{%s})", pods),
+			Legend: "{{pod}}",
+			Unit:   "s",
+		},
+		{
+			Title:  "Connections",
+			Expr:   fmt.Sprintf("sum by (pod, state) (cnpg_backends_total{%s})", pods),
+			Legend: "{{pod}} {{state}}",
+			Unit:   "short",
+		},
+		{
+			Title:  "Transactions",
+			Expr:   fmt.Sprintf("sum by (pod) (rate(cnpg_pg_stat_database_xact_commit{%[1]s}[5m]) + rate(cnpg_pg_stat_database_xact_rollback{%[1]s}[5m]))", pods),
+			Legend: "{{pod}}",
+			Unit:   "ops",
+		},
+		{
+			Title:  "Database size",
+			Expr:   fmt.Sprintf("max by (datname) (cnpg_pg_database_size_bytes{%s})", pods),
+			Legend: "{{datname}}",
+			Unit:   "bytes",
+		},
+		{
+			Title:  "Volume usage",
+			Expr:   fmt.Sprintf("kubelet_volume_stats_used_bytes{%[1]s} / kubelet_volume_stats_capacity_bytes{%[1]s}", volumes),
+			Legend: "{{persistentvolumeclaim}}",
+			Unit:   "percentunit",
+		},
+		{
+			Title:  "Last backup age",
+			Expr:   fmt.Sprintf("dbaas_backup_age_seconds{%s}", backups),
+			Legend: "age",
+			Unit:   "s",
+		},
+		{
+			Title:  "WAL archiving failures",
+			Expr:   fmt.Sprintf("increase(cnpg_pg_stat_archiver_failed_count{%s}[1h])", pods),
+			Legend: "{{pod}}",
+			Unit:   "short",
+		},
+	}
+
+	title := fmt.Sprintf("PostgreSQL / %s / %s", cluster.Namespace, cluster.Name)
+	tags := []string{"dbaas", strings.ToLower(cluster.Spec.Engine.Type)}
+	dashboard, err := monitoring.BuildDashboard(monitoring.DashboardUID(cluster, "overview"), title, tags, panels)
+	if err != nil {
+		return nil, err
+	}
+	return []monitoring.Dashboard{dashboard}, nil
+}
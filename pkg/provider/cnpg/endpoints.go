@@ -0,0 +1,79 @@
+package cnpg
+
+import (
+	"context"
+	"fmt"
+
+	dbaasv1 "github.com/huynt0812/dbaas-operator/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// endpointLabel marks the endpoint services of a cluster, with the cluster name as value
+	endpointLabel = "dbaas.io/endpoint-of"
+
+	// endpointSwapLabel records on an endpoint service the cluster it was repointed to
+	endpointSwapLabel = "dbaas.io/endpoint-swapped-to"
+)
+
+// serviceSuffixes are the suffixes of the services CNPG creates for a cluster, which the endpoint services mirror
+var serviceSuffixes = []string{"rw", "ro", "r"}
+
+// endpointServiceName returns the name of the endpoint service of a cluster with the given suffix
+func endpointServiceName(clusterName, suffix string) string {
+	return fmt.Sprintf("%s-db-%s", clusterName, suffix)
+}
+
+// endpointSelector selects the instances of a CNPG Cluster the way the CNPG service with the given suffix does
+func endpointSelector(clusterName, suffix string) map[string]string {
+	selector := map[string]string{"cnpg.io/cluster": clusterName}
+	switch suffix {
+	case "rw":
+		selector["role"] = "primary"
+	case "ro":
+		selector["role"] = "replica"
+	default:
+		selector["cnpg.io/podRole"] = "instance"
+	}
+	return selector
+}
+
+// reconcileEndpoints maintains the services clients connect to. They select the instances of the
+// cluster like the CNPG services do, but are owned by the DatabaseCluster, so an out-of-place
+// restore can repoint them to another cluster without touching the services CNPG manages.
+func (a *CNPGApplier) reconcileEndpoints() error {
+	for _, suffix := range serviceSuffixes {
+		service := &corev1.Service{}
+		service.Name = endpointServiceName(a.cluster.Name, suffix)
+		service.Namespace = a.cluster.Namespace
+		_, err := controllerutil.CreateOrUpdate(context.TODO(), a.client, service, func() error {
+			if service.Labels == nil {
+				service.Labels = make(map[string]string)
+			}
+			service.Labels[endpointLabel] = a.cluster.Name
+
+			// A swapped service keeps selecting the cluster it was repointed to
+			selected := a.cluster.Name
+			if swapped := service.Labels[endpointSwapLabel]; swapped != "" {
+				selected = swapped
+			}
+			service.Spec.Type = corev1.ServiceTypeClusterIP
+			service.Spec.Selector = endpointSelector(selected, suffix)
+			service.Spec.Ports = []corev1.ServicePort{
+				{Name: "postgres", Port: 5432, TargetPort: intstr.FromInt32(5432), Protocol: corev1.ProtocolTCP},
+			}
+			return controllerutil.SetControllerReference(a.cluster, service, a.scheme)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// endpointHost returns the in-cluster host name of the endpoint service of a cluster with the given suffix
+func endpointHost(cluster *dbaasv1.DatabaseCluster, suffix string) string {
+	return fmt.Sprintf("%s.%s.svc.cluster.local", endpointServiceName(cluster.Name, suffix), cluster.Namespace)
+}
@@ -0,0 +1,343 @@
+package cnpg
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	dbaasv1 "github.com/huynt0812/dbaas-operator/api/v1"
+	"github.com/huynt0812/dbaas-operator/pkg/backupstorage"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// fileBackupLabel marks the Jobs and DatabaseBackups of file-based backups, with the cluster name as value
+	fileBackupLabel = "dbaas.io/file-backup"
+
+	// postgresUID is the user the CNPG PostgreSQL images run as
+	postgresUID = int64(26)
+)
+
+// fileBackupScript takes a self-contained base backup into the mounted storage,
+// records it in a backup.info file and applies the retention policy
+const fileBackupScript = `set -u
+id="$(date -u +%Y%m%dT%H%M%S)"
+dir="/backup/${SERVER_NAME}/base/${id}"
+mkdir -p "${dir}"
+begin="$(date -u '+%Y-%m-%d %H:%M:%S+00:00')"
+if ! pg_basebackup -D "${dir}" -F tar -z -X stream -c fast -w; then
+  rm -rf "${dir}"
+  echo "pg_basebackup failed" > /dev/termination-log
+  exit 1
+fi
+end="$(date -u '+%Y-%m-%d %H:%M:%S+00:00')"
+size="$(du -sb "${dir}" | cut -f1)"
+cat > "${dir}/backup.info" <<EOF
+backup_id=${id}
+begin_time=${begin}
+end_time=${end}
+size=${size}
+version=${ENGINE_VERSION}
+status=DONE
+EOF
+cp "${dir}/backup.info" /dev/termination-log
+if [ -n "${RETENTION_DAYS:-}" ]; then
+  find "/backup/${SERVER_NAME}/base" -mindepth 1 -maxdepth 1 -type d -mtime "+${RETENTION_DAYS}" ! -name "${id}" -exec rm -rf {} +
+fi
+`
+
+// fileBackupStorage returns the BackupStorage of the cluster when it is file-based, nil otherwise
+func fileBackupStorage(ctx context.Context, c client.Client, cluster *dbaasv1.DatabaseCluster) (*dbaasv1.BackupStorage, error) {
+	if cluster.Spec.Backup == nil || cluster.Spec.Backup.BackupStorageRef == nil {
+		return nil, nil
+	}
+	storage, err := getBackupStorage(ctx, c, cluster.Namespace, cluster.Spec.Backup.BackupStorageRef.Name)
+	if err != nil {
+		return nil, err
+	}
+	if !backupstorage.IsFileStorage(storage) {
+		return nil, nil
+	}
+	return storage, nil
+}
+
+// fileBackupJobSpec builds a Job running pg_basebackup against the primary,
+// authenticating as the streaming replication user with its client certificate
+func fileBackupJobSpec(cluster *dbaasv1.DatabaseCluster, storage *dbaasv1.BackupStorage) (batchv1.JobSpec, error) {
+	volume, err := backupstorage.FileVolume(storage, "backup")
+	if err != nil {
+		return batchv1.JobSpec{}, err
+	}
+
+	env := []corev1.EnvVar{
+		{Name: "SERVER_NAME", Value: cluster.Name},
+		{Name: "ENGINE_VERSION", Value: cluster.Spec.Engine.Version},
+		{Name: "PGHOST", Value: fmt.Sprintf("%s-rw.%s.svc", cluster.Name, cluster.Namespace)},
+		{Name: "PGUSER", Value: "streaming_replica"},
+		{Name: "PGSSLMODE", Value: "verify-full"},
+		{Name: "PGSSLCERT", Value: "/certs/tls.crt"},
+		{Name: "PGSSLKEY", Value: "/certs/tls.key"},
+		{Name: "PGSSLROOTCERT", Value: "/ca/ca.crt"},
+	}
+	if policy := cluster.Spec.Backup.RetentionPolicy; policy != "" {
+		days, err := backupstorage.RetentionDays(policy)
+		if err != nil {
+			return batchv1.JobSpec{}, err
+		}
+		env = append(env, corev1.EnvVar{Name: "RETENTION_DAYS", Value: strconv.Itoa(days)})
+	}
+
+	// libpq accepts a root-owned key readable by the group
+	keyMode := int32(0640)
+	uid := postgresUID
+	backoffLimit := int32(1)
+	labels := map[string]string{
+		"dbaas.io/cluster": cluster.Name,
+		"dbaas.io/engine":  cluster.Spec.Engine.Type,
+		fileBackupLabel:    cluster.Name,
+	}
+
+	return batchv1.JobSpec{
+		BackoffLimit: &backoffLimit,
+		Template: corev1.PodTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{Labels: labels},
+			Spec: corev1.PodSpec{
+				RestartPolicy: corev1.RestartPolicyNever,
+				SecurityContext: &corev1.PodSecurityContext{
+					RunAsUser:  &uid,
+					RunAsGroup: &uid,
+					FSGroup:    &uid,
+				},
+				Containers: []corev1.Container{
+					{
+						Name:                     "backup",
+						Image:                    fmt.Sprintf("ghcr.io/cloudnative-pg/postgresql:%s", cluster.Spec.Engine.Version),
+						Command:                  []string{"sh", "-c", fileBackupScript},
+						Env:                      env,
+						TerminationMessagePolicy: corev1.TerminationMessageReadFile,
+						VolumeMounts: []corev1.VolumeMount{
+							{Name: "backup", MountPath: backupstorage.FileMountPath},
+							{Name: "certs", MountPath: "/certs", ReadOnly: true},
+							{Name: "ca", MountPath: "/ca", ReadOnly: true},
+						},
+					},
+				},
+				Volumes: []corev1.Volume{
+					volume,
+					{
+						Name: "certs",
+						VolumeSource: corev1.VolumeSource{
+							Secret: &corev1.SecretVolumeSource{
+								SecretName:  fmt.Sprintf("%s-replication", cluster.Name),
+								DefaultMode: &keyMode,
+							},
+						},
+					},
+					{
+						Name: "ca",
+						VolumeSource: corev1.VolumeSource{
+							Secret: &corev1.SecretVolumeSource{
+								SecretName: fmt.Sprintf("%s-ca", cluster.Name),
+								Items:      []corev1.KeyToPath{{Key: "ca.crt", Path: "ca.crt"}},
+							},
+						},
+					},
+				},
+			},
+		},
+	}, nil
+}
+
+// fileBackupJob builds a one-off file-based backup Job
+func fileBackupJob(cluster *dbaasv1.DatabaseCluster, storage *dbaasv1.BackupStorage, name string) (*batchv1.Job, error) {
+	spec, err := fileBackupJobSpec(cluster, storage)
+	if err != nil {
+		return nil, err
+	}
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: cluster.Namespace,
+			Labels:    spec.Template.Labels,
+		},
+		Spec: spec,
+	}, nil
+}
+
+// syncJobBackupRecords mirrors the backup Jobs of a cluster carrying the given label into DatabaseBackups
+// and drops the records and Jobs of backups removed by the retention policy
+func (p *CNPGProvider) syncJobBackupRecords(ctx context.Context, cluster *dbaasv1.DatabaseCluster, storage *dbaasv1.BackupStorage, label string, method dbaasv1.BackupMethod) error {
+	jobs := &batchv1.JobList{}
+	if err := p.client.List(ctx, jobs,
+		client.InNamespace(cluster.Namespace),
+		client.MatchingLabels{label: cluster.Name},
+	); err != nil {
+		return err
+	}
+
+	for i := range jobs.Items {
+		if err := p.syncJobBackupRecord(ctx, cluster, storage, &jobs.Items[i], label, method); err != nil {
+			return err
+		}
+	}
+
+	if cluster.Spec.Backup.RetentionPolicy == "" {
+		return nil
+	}
+	days, err := backupstorage.RetentionDays(cluster.Spec.Backup.RetentionPolicy)
+	if err != nil {
+		return err
+	}
+
+	backups := &dbaasv1.DatabaseBackupList{}
+	if err := p.client.List(ctx, backups,
+		client.InNamespace(cluster.Namespace),
+		client.MatchingLabels{label: cluster.Name},
+	); err != nil {
+		return err
+	}
+	expiry := time.Now().AddDate(0, 0, -days)
+	for i := range backups.Items {
+		backup := &backups.Items[i]
+		if backup.Status.CompletedAt == nil || !backup.Status.CompletedAt.Time.Before(expiry) {
+			continue
+		}
+		// The files of the backup were removed by the retention policy of a later backup Job.
+		// Its Job goes first, the next sync would otherwise bring the record back.
+		job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: backup.Name, Namespace: backup.Namespace}}
+		if err := p.client.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground)); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+		if err := p.client.Delete(ctx, backup); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// syncJobBackupRecord creates or refreshes the DatabaseBackup of a single backup Job
+func (p *CNPGProvider) syncJobBackupRecord(ctx context.Context, cluster *dbaasv1.DatabaseCluster, storage *dbaasv1.BackupStorage, job *batchv1.Job, label string, method dbaasv1.BackupMethod) error {
+	backup := &dbaasv1.DatabaseBackup{}
+	err := p.client.Get(ctx, types.NamespacedName{Name: job.Name, Namespace: job.Namespace}, backup)
+	if errors.IsNotFound(err) {
+		backup = &dbaasv1.DatabaseBackup{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      job.Name,
+				Namespace: job.Namespace,
+				Labels: map[string]string{
+					"dbaas.io/cluster": cluster.Name,
+					"dbaas.io/engine":  cluster.Spec.Engine.Type,
+					label:              cluster.Name,
+				},
+			},
+			Spec: dbaasv1.DatabaseBackupSpec{
+				ClusterName:      cluster.Name,
+				EngineType:       cluster.Spec.Engine.Type,
+				Method:           method,
+				BackupStorageRef: &corev1.LocalObjectReference{Name: storage.Name},
+			},
+		}
+		if err := p.client.Create(ctx, backup); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+
+	previous := backup.Status.DeepCopy()
+	status := &backup.Status
+	status.ServerName = cluster.Name
+	status.DestinationPath = backupstorage.Location(storage)
+	if status.EngineVersion == "" {
+		status.EngineVersion = cluster.Spec.Engine.Version
+	}
+	if job.Status.StartTime != nil && status.StartedAt == nil {
+		status.StartedAt = job.Status.StartTime
+	}
+
+	message, finished, failed, err := jobTerminationMessage(ctx, p.client, job)
+	if err != nil {
+		return err
+	}
+	switch {
+	case !finished && job.Status.Active > 0:
+		status.Phase = dbaasv1.DatabaseBackupPhaseRunning
+	case !finished:
+		status.Phase = dbaasv1.DatabaseBackupPhasePending
+	case failed:
+		status.Phase = dbaasv1.DatabaseBackupPhaseFailed
+		status.Message = message
+	default:
+		entry, err := backupstorage.ParseBackupInfo([]byte(message))
+		if err != nil {
+			return err
+		}
+		status.Phase = dbaasv1.DatabaseBackupPhaseSucceeded
+		status.BackupID = entry.BackupID
+		if entry.BeginTime != nil {
+			status.StartedAt = &metav1.Time{Time: *entry.BeginTime}
+		}
+		if entry.EndTime != nil {
+			status.CompletedAt = &metav1.Time{Time: *entry.EndTime}
+		}
+		status.Size = resource.NewQuantity(entry.Size, resource.BinarySI)
+		status.Databases = entry.Databases
+		status.Message = ""
+	}
+
+	if equality.Semantic.DeepEqual(previous, status) {
+		return nil
+	}
+	return p.client.Status().Update(ctx, backup)
+}
+
+// jobTerminationMessage returns the termination message of the last pod of a finished Job
+func jobTerminationMessage(ctx context.Context, c client.Client, job *batchv1.Job) (message string, finished, failed bool, err error) {
+	for _, condition := range job.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case batchv1.JobComplete:
+			finished = true
+		case batchv1.JobFailed:
+			finished, failed = true, true
+			message = condition.Message
+		}
+	}
+	if !finished {
+		return "", false, false, nil
+	}
+
+	pods := &corev1.PodList{}
+	if err := c.List(ctx, pods,
+		client.InNamespace(job.Namespace),
+		client.MatchingLabels{"job-name": job.Name},
+	); err != nil {
+		return "", true, failed, err
+	}
+
+	var latest time.Time
+	for _, pod := range pods.Items {
+		for _, containerStatus := range pod.Status.ContainerStatuses {
+			terminated := containerStatus.State.Terminated
+			if terminated == nil || terminated.Message == "" {
+				continue
+			}
+			// A failed Job keeps its failed pods, a completed one the successful pod last
+			if (terminated.ExitCode == 0) == !failed && !terminated.FinishedAt.Time.Before(latest) {
+				message = terminated.Message
+				latest = terminated.FinishedAt.Time
+			}
+		}
+	}
+	return message, true, failed, nil
+}
@@ -0,0 +1,283 @@
+package cnpg
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	cnpgv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	dbaasv1 "github.com/huynt0812/dbaas-operator/api/v1"
+	"github.com/huynt0812/dbaas-operator/pkg/backupstorage"
+	snapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v7/apis/volumesnapshot/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// fileRestoreLabel marks the volume, Job and VolumeSnapshot a file backup is unpacked with, with the cluster name as value
+const fileRestoreLabel = "dbaas.io/file-restore"
+
+// fileRestoreScript unpacks the tarballs written by fileBackupScript into an empty PGDATA.
+// The backup carries its WAL, so PostgreSQL reaches a consistent state from backup_label alone.
+const fileRestoreScript = `set -u
+src="/backup/${SERVER_NAME}/base/${BACKUP_ID}"
+for archive in "${src}"/*.tar.gz; do
+  case "$(basename "${archive}")" in
+    base.tar.gz|pg_wal.tar.gz) ;;
+    *)
+      echo "backup ${BACKUP_ID} has tablespace archive $(basename "${archive}"), which cannot be restored" > /dev/termination-log
+      exit 1
+      ;;
+  esac
+done
+rm -rf "${PGDATA}"
+mkdir -p "${PGDATA}/pg_wal"
+if ! tar -xzf "${src}/base.tar.gz" -C "${PGDATA}" || ! tar -xzf "${src}/pg_wal.tar.gz" -C "${PGDATA}/pg_wal"; then
+  echo "failed to unpack backup ${BACKUP_ID} from ${src}" > /dev/termination-log
+  exit 1
+fi
+chmod 0700 "${PGDATA}"
+`
+
+// fileBackupSource is a backup in a file storage that a cluster is bootstrapped from
+type fileBackupSource struct {
+	storage    *dbaasv1.BackupStorage
+	serverName string
+	backupID   string
+}
+
+// fileRestoreName names the volume, Job and VolumeSnapshot a file backup is unpacked with
+func fileRestoreName(cluster *dbaasv1.DatabaseCluster, backupID string) string {
+	return fmt.Sprintf("%s-restore-%s", cluster.Name, strings.ToLower(backupID))
+}
+
+// resolveFileBackup returns the backup in a file storage the cluster is bootstrapped from,
+// nil when it is bootstrapped in another way. It looks up the backup the way backupRecovery does.
+func resolveFileBackup(ctx context.Context, c client.Client, cluster *dbaasv1.DatabaseCluster) (*fileBackupSource, error) {
+	source := bootstrapBackup(cluster)
+	if source == nil {
+		return nil, nil
+	}
+
+	storageName := ""
+	if source.BackupStorageRef != nil {
+		storageName = source.BackupStorageRef.Name
+	}
+	result := &fileBackupSource{serverName: source.ServerName, backupID: source.BackupName}
+
+	backup := &dbaasv1.DatabaseBackup{}
+	err := c.Get(ctx, types.NamespacedName{Name: source.BackupName, Namespace: cluster.Namespace}, backup)
+	if err == nil {
+		if backup.Spec.Method == dbaasv1.BackupMethodLogical || backup.Spec.Method == dbaasv1.BackupMethodVolumeSnapshot {
+			return nil, nil
+		}
+		if storageName == "" && backup.Spec.BackupStorageRef != nil {
+			storageName = backup.Spec.BackupStorageRef.Name
+		}
+		result.serverName = backup.Status.ServerName
+		if result.serverName == "" {
+			result.serverName = backup.Spec.ClusterName
+		}
+		result.backupID = backup.Status.BackupID
+	} else if !errors.IsNotFound(err) {
+		return nil, err
+	} else {
+		cnpgBackup := &cnpgv1.Backup{}
+		err := c.Get(ctx, types.NamespacedName{Name: source.BackupName, Namespace: cluster.Namespace}, cnpgBackup)
+		if err == nil {
+			return nil, nil
+		} else if !errors.IsNotFound(err) {
+			return nil, err
+		}
+	}
+	if storageName == "" || result.serverName == "" || result.backupID == "" {
+		return nil, nil
+	}
+
+	storage, err := getBackupStorage(ctx, c, cluster.Namespace, storageName)
+	if err != nil {
+		return nil, err
+	}
+	if !backupstorage.IsFileStorage(storage) {
+		return nil, nil
+	}
+	result.storage = storage
+	return result, nil
+}
+
+// prepareFileRestore unpacks the file backup a cluster is bootstrapped from into a volume and snapshots it,
+// as CNPG can only bootstrap from an object store or from volume snapshots. It returns the seconds
+// to wait before the CNPG Cluster can be created, zero once the snapshot is ready or when there is nothing to prepare.
+func (p *CNPGProvider) prepareFileRestore(ctx context.Context, cluster *dbaasv1.DatabaseCluster) (int, error) {
+	backup, err := resolveFileBackup(ctx, p.client, cluster)
+	if err != nil || backup == nil {
+		return 0, err
+	}
+	name := fileRestoreName(cluster, backup.backupID)
+	labels := map[string]string{
+		"dbaas.io/cluster": cluster.Name,
+		"dbaas.io/engine":  cluster.Spec.Engine.Type,
+		fileRestoreLabel:   cluster.Name,
+	}
+
+	// The backup is unpacked into a volume of the size of the cluster's data volumes
+	pvc := &corev1.PersistentVolumeClaim{}
+	err = p.client.Get(ctx, types.NamespacedName{Name: name, Namespace: cluster.Namespace}, pvc)
+	if errors.IsNotFound(err) {
+		pvc = &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: cluster.Namespace, Labels: labels},
+			Spec: corev1.PersistentVolumeClaimSpec{
+				AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+				StorageClassName: cluster.Spec.Storage.StorageClassName,
+				Resources: corev1.VolumeResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceStorage: cluster.Spec.Storage.Size},
+				},
+			},
+		}
+		if err := controllerutil.SetControllerReference(cluster, pvc, p.scheme); err != nil {
+			return 0, err
+		}
+		if err := p.client.Create(ctx, pvc); err != nil {
+			return 0, err
+		}
+	} else if err != nil {
+		return 0, err
+	}
+
+	job := &batchv1.Job{}
+	err = p.client.Get(ctx, types.NamespacedName{Name: name, Namespace: cluster.Namespace}, job)
+	if errors.IsNotFound(err) {
+		job, err = fileRestoreJob(cluster, backup, name, labels)
+		if err != nil {
+			return 0, err
+		}
+		if err := controllerutil.SetControllerReference(cluster, job, p.scheme); err != nil {
+			return 0, err
+		}
+		return 5, p.client.Create(ctx, job)
+	} else if err != nil {
+		return 0, err
+	}
+	message, finished, failed, err := jobTerminationMessage(ctx, p.client, job)
+	if err != nil {
+		return 0, err
+	}
+	if failed {
+		return 0, fmt.Errorf("failed to unpack backup %s: %s", backup.backupID, message)
+	}
+	if !finished {
+		return 5, nil
+	}
+
+	snapshot := &snapshotv1.VolumeSnapshot{}
+	err = p.client.Get(ctx, types.NamespacedName{Name: name, Namespace: cluster.Namespace}, snapshot)
+	if errors.IsNotFound(err) {
+		claimName := pvc.Name
+		snapshot = &snapshotv1.VolumeSnapshot{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: cluster.Namespace, Labels: labels},
+			Spec: snapshotv1.VolumeSnapshotSpec{
+				Source: snapshotv1.VolumeSnapshotSource{PersistentVolumeClaimName: &claimName},
+			},
+		}
+		if cluster.Spec.Backup != nil && cluster.Spec.Backup.VolumeSnapshot != nil {
+			snapshot.Spec.VolumeSnapshotClassName = &cluster.Spec.Backup.VolumeSnapshot.ClassName
+		}
+		if err := controllerutil.SetControllerReference(cluster, snapshot, p.scheme); err != nil {
+			return 0, err
+		}
+		return 5, p.client.Create(ctx, snapshot)
+	} else if err != nil {
+		return 0, err
+	}
+	if snapshot.Status != nil && snapshot.Status.Error != nil && snapshot.Status.Error.Message != nil {
+		return 0, fmt.Errorf("failed to snapshot unpacked backup %s: %s", backup.backupID, *snapshot.Status.Error.Message)
+	}
+	if snapshot.Status == nil || snapshot.Status.ReadyToUse == nil || !*snapshot.Status.ReadyToUse {
+		return 5, nil
+	}
+	return 0, nil
+}
+
+// fileRestoreJob builds the Job unpacking a file backup into the restore volume
+func fileRestoreJob(cluster *dbaasv1.DatabaseCluster, backup *fileBackupSource, name string, labels map[string]string) (*batchv1.Job, error) {
+	volume, err := backupstorage.FileVolume(backup.storage, "backup")
+	if err != nil {
+		return nil, err
+	}
+
+	uid := postgresUID
+	backoffLimit := int32(1)
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: cluster.Namespace, Labels: labels},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					SecurityContext: &corev1.PodSecurityContext{
+						RunAsUser:  &uid,
+						RunAsGroup: &uid,
+						FSGroup:    &uid,
+					},
+					Containers: []corev1.Container{
+						{
+							Name:    "restore",
+							Image:   fmt.Sprintf("ghcr.io/cloudnative-pg/postgresql:%s", cluster.Spec.Engine.Version),
+							Command: []string{"sh", "-c", fileRestoreScript},
+							Env: []corev1.EnvVar{
+								{Name: "SERVER_NAME", Value: backup.serverName},
+								{Name: "BACKUP_ID", Value: backup.backupID},
+								// CNPG keeps PGDATA in a directory of the data volume
+								{Name: "PGDATA", Value: "/var/lib/postgresql/data/pgdata"},
+							},
+							TerminationMessagePolicy: corev1.TerminationMessageReadFile,
+							VolumeMounts: []corev1.VolumeMount{
+								{Name: "backup", MountPath: backupstorage.FileMountPath, ReadOnly: true},
+								{Name: "data", MountPath: "/var/lib/postgresql/data"},
+							},
+						},
+					},
+					Volumes: []corev1.Volume{
+						volume,
+						{
+							Name: "data",
+							VolumeSource: corev1.VolumeSource{
+								PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: name},
+							},
+						},
+					},
+				},
+			},
+		},
+	}, nil
+}
+
+// cleanupFileRestore deletes the volume, Job and VolumeSnapshot a file backup was unpacked with
+// once the cluster bootstrapped from it is ready
+func (p *CNPGProvider) cleanupFileRestore(ctx context.Context, cluster *dbaasv1.DatabaseCluster) error {
+	selector := []client.DeleteAllOfOption{client.InNamespace(cluster.Namespace), client.MatchingLabels{fileRestoreLabel: cluster.Name}}
+	if err := p.client.DeleteAllOf(ctx, &batchv1.Job{}, append(selector, client.PropagationPolicy(metav1.DeletePropagationBackground))...); err != nil {
+		return err
+	}
+	if err := p.client.DeleteAllOf(ctx, &snapshotv1.VolumeSnapshot{}, selector...); err != nil {
+		return err
+	}
+	return p.client.DeleteAllOf(ctx, &corev1.PersistentVolumeClaim{}, selector...)
+}
+
+// fileRecovery provisions the volumes of the cluster from the snapshot of the unpacked file backup
+func (a *CNPGApplier) fileRecovery(backupID string) error {
+	if bootstrapBackup(a.cluster).PointInTime != nil {
+		return fmt.Errorf("point-in-time recovery is not supported from file backup %s", backupID)
+	}
+	apiGroup := volumeSnapshotAPIGroup
+	a.cnpgCluster.Spec.Bootstrap.Recovery.VolumeSnapshots = &cnpgv1.DataSource{
+		Storage: corev1.TypedLocalObjectReference{APIGroup: &apiGroup, Kind: "VolumeSnapshot", Name: fileRestoreName(a.cluster, backupID)},
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package cnpg
+
+import (
+	"fmt"
+
+	"github.com/huynt0812/dbaas-operator/pkg/monitoring"
+)
+
+// insightsParameters are the pg_stat_statements settings of query insights. CNPG manages
+// pg_stat_statements as soon as one of its parameters is set: it adds the library to
+// shared_preload_libraries and creates the extension in every database.
+var insightsParameters = map[string]string{
+	"pg_stat_statements.max":   "5000",
+	"pg_stat_statements.track": "top",
+}
+
+// topStatementsSQL prints the statements by total execution time, one JSON object per line. The
+// statements of other roles are only readable by members of pg_read_all_stats, which the
+// monitoring user the summary runs as is through pg_monitor.
+const topStatementsSQL = `SELECT row_to_json(t) FROM (
+  SELECT s.queryid::text AS "queryId", d.datname AS "database",
+    left(regexp_replace(s.query, '\s+', ' ', 'g'), %d) AS "query", s.calls AS "calls",
+    round(s.total_exec_time::numeric, 1) AS "totalTimeMs", round(s.mean_exec_time::numeric, 2) AS "meanTimeMs",
+    s.rows AS "rows"
+  FROM pg_stat_statements s JOIN pg_database d ON d.oid = s.dbid
+  ORDER BY s.total_exec_time DESC LIMIT %d
+) t ORDER BY t."totalTimeMs" DESC`
+
+// queryInsights loads pg_stat_statements when query insights are enabled and adds the monitoring
+// user the summary is read with. Parameters set in spec.config win over the defaults of query insights.
+func (a *CNPGApplier) queryInsights() error {
+	spec := a.cluster.Spec.QueryInsights
+	if spec == nil || !spec.Enabled {
+		return nil
+	}
+	if a.cnpgCluster.Spec.PostgresConfiguration.Parameters == nil {
+		a.cnpgCluster.Spec.PostgresConfiguration.Parameters = make(map[string]string)
+	}
+	for k, v := range insightsParameters {
+		if _, set := a.cnpgCluster.Spec.PostgresConfiguration.Parameters[k]; !set {
+			a.cnpgCluster.Spec.PostgresConfiguration.Parameters[k] = v
+		}
+	}
+	if monitoring.InsightsTopN(spec) == 0 {
+		return nil
+	}
+	_, err := a.monitoringRole(monitoringSecretName(a.cluster))
+	return err
+}
+
+// TopStatementsSQL returns the query reading the slowest statements from pg_stat_statements
+func (p *CNPGProvider) TopStatementsSQL(limit int32) (string, error) {
+	if limit <= 0 {
+		return "", fmt.Errorf("invalid number of statements: %d", limit)
+	}
+	return fmt.Sprintf(topStatementsSQL, monitoring.InsightsQueryLength, limit), nil
+}
@@ -0,0 +1,110 @@
+package cnpg
+
+import (
+	"context"
+	"fmt"
+
+	dbaasv1 "github.com/huynt0812/dbaas-operator/api/v1"
+	"github.com/huynt0812/dbaas-operator/pkg/logging"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// logLevels maps the levels of the logging section to log_min_messages
+var logLevels = map[string]string{
+	"debug":   "debug1",
+	"info":    "info",
+	"notice":  "notice",
+	"warning": "warning",
+	"error":   "error",
+}
+
+// csvRecordKey is the key of the JSON records of CNPG holding the csvlog columns of a row
+const csvRecordKey = "record"
+
+// logShippingConfigMapName returns the name of the ConfigMap holding the log shipping pipeline of the cluster
+func logShippingConfigMapName(cluster *dbaasv1.DatabaseCluster) string {
+	return fmt.Sprintf("%s-log-shipping", cluster.Name)
+}
+
+// logging translates the logging section of the cluster into PostgreSQL parameters, which
+// spec.config overrides, and renders the shipping pipeline. CNPG instance pods do not accept
+// sidecars and print their csvlog rows wrapped in JSON on stdout, so the pipeline is written to a
+// ConfigMap for the node-level Fluent Bit or OpenTelemetry collector tailing the container logs.
+func (a *CNPGApplier) logging() error {
+	ctx := context.TODO()
+	spec := a.cluster.Spec.Logging
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      logShippingConfigMapName(a.cluster),
+			Namespace: a.cluster.Namespace,
+		},
+	}
+	if spec == nil || spec.Shipping == nil {
+		if err := client.IgnoreNotFound(a.client.Delete(ctx, configMap)); err != nil {
+			return err
+		}
+	}
+	if spec == nil {
+		return nil
+	}
+
+	// CNPG sets log_destination to csvlog itself, the format only selects what is shipped
+	if spec.Format != "" && spec.Format != "json" && spec.Format != "csv" {
+		return fmt.Errorf("unsupported log format: %s", spec.Format)
+	}
+
+	parameters := make(map[string]string)
+	if spec.Level != "" {
+		level, ok := logLevels[spec.Level]
+		if !ok {
+			return fmt.Errorf("unsupported log level: %s", spec.Level)
+		}
+		parameters["log_min_messages"] = level
+		parameters["log_min_error_statement"] = level
+	}
+	if spec.SlowQueryThreshold != nil {
+		if spec.SlowQueryThreshold.Duration < 0 {
+			return fmt.Errorf("slow query threshold must not be negative")
+		}
+		parameters["log_min_duration_statement"] = fmt.Sprintf("%dms", spec.SlowQueryThreshold.Milliseconds())
+	}
+	if a.cnpgCluster.Spec.PostgresConfiguration.Parameters == nil {
+		a.cnpgCluster.Spec.PostgresConfiguration.Parameters = make(map[string]string)
+	}
+	for k, v := range parameters {
+		if _, set := a.cnpgCluster.Spec.PostgresConfiguration.Parameters[k]; !set {
+			a.cnpgCluster.Spec.PostgresConfiguration.Parameters[k] = v
+		}
+	}
+
+	if spec.Shipping == nil {
+		return nil
+	}
+	recordKey := ""
+	if spec.Format == "csv" {
+		recordKey = csvRecordKey
+	}
+	pipeline, err := logging.Pipeline(logging.Source{
+		Namespace: a.cluster.Namespace,
+		Cluster:   a.cluster.Name,
+		PodPrefix: a.cluster.Name,
+		Container: "postgres",
+		JSON:      true,
+		RecordKey: recordKey,
+	}, spec.Shipping)
+	if err != nil {
+		return err
+	}
+	_, err = controllerutil.CreateOrUpdate(ctx, a.client, configMap, func() error {
+		configMap.Labels = map[string]string{
+			"dbaas.io/cluster": a.cluster.Name,
+			"dbaas.io/engine":  a.cluster.Spec.Engine.Type,
+		}
+		configMap.Data = map[string]string{logging.PipelineKey(spec.Shipping): pipeline}
+		return controllerutil.SetControllerReference(a.cluster, configMap, a.scheme)
+	})
+	return err
+}
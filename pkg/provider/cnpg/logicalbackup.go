@@ -0,0 +1,303 @@
+package cnpg
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	dbaasv1 "github.com/huynt0812/dbaas-operator/api/v1"
+	"github.com/huynt0812/dbaas-operator/pkg/backupstorage"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// logicalBackupLabel marks the Jobs and DatabaseBackups of logical backups, with the cluster name as value
+	logicalBackupLabel = "dbaas.io/logical-backup"
+
+	// logicalRestoreLabel marks the Jobs loading a logical backup, with the OpsRequest name as value
+	logicalRestoreLabel = "dbaas.io/logical-restore"
+
+	// defaultLogicalDatabase is the application database created when bootstrapping a cluster
+	defaultLogicalDatabase = "app"
+
+	// defaultCompressionLevel is the gzip level used when none is configured
+	defaultCompressionLevel = 6
+)
+
+// logicalBackupScript streams a pg_dump of every database into the storage, one custom-format
+// archive per database next to a backup.info file, and applies the retention policy
+const logicalBackupScript = `set -uo pipefail
+id="$(date -u +%Y%m%dT%H%M%S)"
+root="${STORAGE_ROOT}/${SERVER_NAME}/logical"
+dir="${root}/${id}"
+begin="$(date -u '+%Y-%m-%d %H:%M:%S+00:00')"
+tables=()
+for table in ${TABLES:-}; do
+  tables+=(-t "${table}")
+done
+for db in ${DATABASES}; do
+  if ! pg_dump -Fc -Z "${COMPRESSION_LEVEL}" "${tables[@]}" -d "${db}" | "${RCLONE}" rcat "${dir}/${db}.dump"; then
+    "${RCLONE}" purge "${dir}" > /dev/null 2>&1
+    echo "logical backup of database ${db} failed" > /dev/termination-log
+    exit 1
+  fi
+done
+end="$(date -u '+%Y-%m-%d %H:%M:%S+00:00')"
+size="$("${RCLONE}" size --json "${dir}" | sed -n 's/.*"bytes":\([0-9]*\).*/\1/p')"
+info="backup_id=${id}
+begin_time=${begin}
+end_time=${end}
+size=${size:-0}
+version=${ENGINE_VERSION}
+databases=${DATABASES}
+status=DONE"
+printf '%s\n' "${info}" | "${RCLONE}" rcat "${dir}/backup.info"
+printf '%s\n' "${info}" > /dev/termination-log
+if [ -n "${RETENTION_DAYS:-}" ]; then
+  "${RCLONE}" delete --min-age "${RETENTION_DAYS}d" "${root}"
+  "${RCLONE}" rmdirs --leave-root "${root}"
+fi
+`
+
+// logicalRestoreScript streams the archives of a logical backup from the storage into pg_restore
+const logicalRestoreScript = `set -uo pipefail
+args=(--clean --if-exists --no-owner --no-privileges)
+for schema in ${SCHEMAS:-}; do
+  args+=(-n "${schema}")
+done
+for table in ${TABLES:-}; do
+  args+=(-t "${table}")
+done
+for db in ${DATABASES}; do
+  if ! "${RCLONE}" cat "${SOURCE}/${db}.dump" | pg_restore "${args[@]}" -d "${db}"; then
+    echo "restore of database ${db} failed" > /dev/termination-log
+    exit 1
+  fi
+done
+`
+
+// logicalBackupSpec returns the logical backup configuration of a backup, the override of the operation
+// taking precedence over the configuration of the cluster
+func logicalBackupSpec(cluster *dbaasv1.DatabaseCluster, override *dbaasv1.LogicalBackupSpec) *dbaasv1.LogicalBackupSpec {
+	if override != nil {
+		return override
+	}
+	if cluster.Spec.Backup != nil && cluster.Spec.Backup.Logical != nil {
+		return cluster.Spec.Backup.Logical
+	}
+	return &dbaasv1.LogicalBackupSpec{}
+}
+
+// shellList joins names passed to the scripts through the environment, rejecting names they cannot split
+func shellList(kind string, names []string) (string, error) {
+	for _, name := range names {
+		if name == "" || strings.ContainsAny(name, " \t\n'\"$`\\") {
+			return "", fmt.Errorf("invalid %s name %q", kind, name)
+		}
+	}
+	return strings.Join(names, " "), nil
+}
+
+// transferPod builds the pod of a logical backup or restore Job: the engine container
+// runs the script with rclone installed next to it and the storage configured as a remote.
+// The pod runs with the ServiceAccount of the instances of the cluster, bound to the workload identity of the storage.
+func transferPod(cluster *dbaasv1.DatabaseCluster, container *corev1.Container, storage *dbaasv1.BackupStorage, remote, script string, labels map[string]string) (corev1.PodTemplateSpec, string, error) {
+	pod := corev1.PodSpec{
+		RestartPolicy:  corev1.RestartPolicyNever,
+		InitContainers: []corev1.Container{backupstorage.TransferToolsContainer("tools")},
+		Volumes: []corev1.Volume{
+			{Name: "tools", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+		},
+	}
+
+	container.Command = []string{"bash", "-c", script}
+	container.TerminationMessagePolicy = corev1.TerminationMessageReadFile
+	container.Env = append(container.Env, corev1.EnvVar{Name: "RCLONE", Value: backupstorage.RcloneBinary})
+	container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{Name: "tools", MountPath: backupstorage.ToolsPath})
+	root, err := backupstorage.ConfigureRemote(&pod, container, remote, storage)
+	if err != nil {
+		return corev1.PodTemplateSpec{}, "", err
+	}
+	pod.Containers = []corev1.Container{*container}
+
+	template := corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{Labels: labels},
+		Spec:       pod,
+	}
+	backupstorage.BindPodIdentity(&template, cluster.Name, storage)
+	return template, root, nil
+}
+
+// logicalBackupJobSpec builds a Job dumping the selected databases of the cluster into the storage
+func logicalBackupJobSpec(cluster *dbaasv1.DatabaseCluster, storage *dbaasv1.BackupStorage, spec *dbaasv1.LogicalBackupSpec) (batchv1.JobSpec, error) {
+	databases := spec.Databases
+	if len(databases) == 0 {
+		databases = []string{defaultLogicalDatabase}
+	}
+	databaseList, err := shellList("database", databases)
+	if err != nil {
+		return batchv1.JobSpec{}, err
+	}
+	tableList := ""
+	if len(spec.Tables) > 0 {
+		if tableList, err = shellList("table", spec.Tables); err != nil {
+			return batchv1.JobSpec{}, err
+		}
+	}
+
+	level := int32(defaultCompressionLevel)
+	if spec.CompressionLevel != nil {
+		level = *spec.CompressionLevel
+	}
+	if spec.Compression == dbaasv1.LogicalBackupCompressionNone {
+		level = 0
+	}
+
+	// Dumps must read the objects of every owner
+	container := superuserClientContainer(cluster)
+	container.Name = "dump"
+	container.Env = append(container.Env,
+		corev1.EnvVar{Name: "SERVER_NAME", Value: cluster.Name},
+		corev1.EnvVar{Name: "ENGINE_VERSION", Value: cluster.Spec.Engine.Version},
+		corev1.EnvVar{Name: "DATABASES", Value: databaseList},
+		corev1.EnvVar{Name: "TABLES", Value: tableList},
+		corev1.EnvVar{Name: "COMPRESSION_LEVEL", Value: strconv.Itoa(int(level))},
+	)
+	if cluster.Spec.Backup != nil && cluster.Spec.Backup.RetentionPolicy != "" {
+		days, err := backupstorage.RetentionDays(cluster.Spec.Backup.RetentionPolicy)
+		if err != nil {
+			return batchv1.JobSpec{}, err
+		}
+		container.Env = append(container.Env, corev1.EnvVar{Name: "RETENTION_DAYS", Value: strconv.Itoa(days)})
+	}
+
+	labels := map[string]string{
+		"dbaas.io/cluster": cluster.Name,
+		"dbaas.io/engine":  cluster.Spec.Engine.Type,
+		logicalBackupLabel: cluster.Name,
+	}
+	template, root, err := transferPod(cluster, container, storage, "dst", logicalBackupScript, labels)
+	if err != nil {
+		return batchv1.JobSpec{}, err
+	}
+	template.Spec.Containers[0].Env = append(template.Spec.Containers[0].Env, corev1.EnvVar{Name: "STORAGE_ROOT", Value: root})
+
+	backoffLimit := int32(1)
+	return batchv1.JobSpec{
+		BackoffLimit: &backoffLimit,
+		Template:     template,
+	}, nil
+}
+
+// logicalBackupJob builds a one-off logical backup Job
+func logicalBackupJob(cluster *dbaasv1.DatabaseCluster, storage *dbaasv1.BackupStorage, spec *dbaasv1.LogicalBackupSpec, name string) (*batchv1.Job, error) {
+	jobSpec, err := logicalBackupJobSpec(cluster, storage, spec)
+	if err != nil {
+		return nil, err
+	}
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: cluster.Namespace,
+			Labels:    jobSpec.Template.Labels,
+		},
+		Spec: jobSpec,
+	}, nil
+}
+
+// logicalRestoreJobName returns the name of the Job loading a logical backup for a Restore OpsRequest
+func logicalRestoreJobName(ops *dbaasv1.OpsRequest) string {
+	return fmt.Sprintf("%s-restore", ops.Name)
+}
+
+// logicalRestoreJob builds a Job loading the selected content of a logical backup into the destination cluster
+func logicalRestoreJob(ctx context.Context, c client.Client, destination *dbaasv1.DatabaseCluster, backup *dbaasv1.DatabaseBackup, ops *dbaasv1.OpsRequest) (*batchv1.Job, error) {
+	if backup.Status.Phase != dbaasv1.DatabaseBackupPhaseSucceeded {
+		return nil, fmt.Errorf("backup %s is not completed", backup.Name)
+	}
+	if backup.Spec.BackupStorageRef == nil {
+		return nil, fmt.Errorf("backup %s has no backup storage", backup.Name)
+	}
+	storage, err := getBackupStorage(ctx, c, backup.Namespace, backup.Spec.BackupStorageRef.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	spec := ops.Spec.Restore.Logical
+	if spec == nil {
+		spec = &dbaasv1.LogicalRestoreSpec{}
+	}
+	databases := backup.Status.Databases
+	if len(spec.Databases) > 0 {
+		for _, database := range spec.Databases {
+			found := false
+			for _, dumped := range backup.Status.Databases {
+				found = found || dumped == database
+			}
+			if !found {
+				return nil, fmt.Errorf("database %s is not part of backup %s", database, backup.Name)
+			}
+		}
+		databases = spec.Databases
+	}
+	databaseList, err := shellList("database", databases)
+	if err != nil {
+		return nil, err
+	}
+	schemaList, tableList := "", ""
+	if len(spec.Schemas) > 0 {
+		if schemaList, err = shellList("schema", spec.Schemas); err != nil {
+			return nil, err
+		}
+	}
+	if len(spec.Tables) > 0 {
+		if tableList, err = shellList("table", spec.Tables); err != nil {
+			return nil, err
+		}
+	}
+
+	serverName := backup.Status.ServerName
+	if serverName == "" {
+		serverName = backup.Spec.ClusterName
+	}
+
+	// Restores drop and recreate the objects of every owner
+	container := superuserClientContainer(destination)
+	container.Name = "restore"
+	container.Env = append(container.Env,
+		corev1.EnvVar{Name: "DATABASES", Value: databaseList},
+		corev1.EnvVar{Name: "SCHEMAS", Value: schemaList},
+		corev1.EnvVar{Name: "TABLES", Value: tableList},
+	)
+
+	labels := map[string]string{
+		"dbaas.io/cluster":  destination.Name,
+		"dbaas.io/engine":   destination.Spec.Engine.Type,
+		logicalRestoreLabel: ops.Name,
+	}
+	template, root, err := transferPod(destination, container, storage, "src", logicalRestoreScript, labels)
+	if err != nil {
+		return nil, err
+	}
+	template.Spec.Containers[0].Env = append(template.Spec.Containers[0].Env, corev1.EnvVar{
+		Name:  "SOURCE",
+		Value: fmt.Sprintf("%s/%s/logical/%s", root, serverName, backup.Status.BackupID),
+	})
+
+	backoffLimit := int32(0)
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      logicalRestoreJobName(ops),
+			Namespace: destination.Namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template:     template,
+		},
+	}, nil
+}
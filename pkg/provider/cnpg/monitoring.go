@@ -0,0 +1,257 @@
+package cnpg
+
+import (
+	"context"
+	"fmt"
+
+	cnpgv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	dbaasv1 "github.com/huynt0812/dbaas-operator/api/v1"
+	"github.com/huynt0812/dbaas-operator/pkg/monitoring"
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// metricsPortName is the name of the exporter port of the CNPG instance pods
+	metricsPortName = "metrics"
+
+	// metricsPort is the port of the CNPG exporter
+	metricsPort = int32(9187)
+)
+
+// metricsTarget returns the metrics endpoint of the instance pods of the cluster
+func metricsTarget(cluster *dbaasv1.DatabaseCluster) monitoring.Target {
+	return monitoring.Target{
+		Name:      cluster.Name,
+		Namespace: cluster.Namespace,
+		Labels: map[string]string{
+			"dbaas.io/cluster": cluster.Name,
+			"dbaas.io/engine":  cluster.Spec.Engine.Type,
+		},
+		Selector: map[string]string{
+			"cnpg.io/cluster": cluster.Name,
+			"cnpg.io/podRole": "instance",
+		},
+		PortName: metricsPortName,
+		Port:     metricsPort,
+		Path:     "/metrics",
+	}
+}
+
+// prometheusConfig returns the Prometheus configuration of the MonitoringConfig referenced by the
+// cluster, or nil when monitoring is disabled, no config is referenced or the config is not usable
+func prometheusConfig(ctx context.Context, c client.Client, cluster *dbaasv1.DatabaseCluster) (*dbaasv1.PrometheusConfigSpec, error) {
+	if cluster.Spec.Monitoring == nil || !cluster.Spec.Monitoring.Enabled {
+		return nil, nil
+	}
+	config, err := monitoring.ResolveConfig(ctx, c, cluster)
+	if err != nil || config == nil {
+		return nil, err
+	}
+	if config.Spec.Type != "prometheus" {
+		return nil, nil
+	}
+	prometheus := config.Spec.Prometheus
+	if prometheus == nil {
+		prometheus = &dbaasv1.PrometheusConfigSpec{ServiceMonitorEnabled: true}
+	}
+	if err := monitoring.ValidatePrometheusConfig(prometheus); err != nil {
+		return nil, fmt.Errorf("MonitoringConfig %s: %w", config.Name, err)
+	}
+	return prometheus, nil
+}
+
+// reconcileMonitors creates the PodMonitor or the ServiceMonitor requested by the Prometheus
+// configuration of the cluster and removes the monitor objects it no longer needs
+func (a *CNPGApplier) reconcileMonitors(config *dbaasv1.PrometheusConfigSpec) error {
+	ctx := context.TODO()
+	target := metricsTarget(a.cluster)
+
+	podMonitor := &monitoringv1.PodMonitor{}
+	podMonitor.Name, podMonitor.Namespace = target.Name, target.Namespace
+	serviceMonitor := &monitoringv1.ServiceMonitor{}
+	serviceMonitor.Name, serviceMonitor.Namespace = target.Name, target.Namespace
+	service := &corev1.Service{}
+	service.Name, service.Namespace = monitoring.MetricsServiceName(target), target.Namespace
+
+	var keep client.Object
+	switch {
+	case config == nil:
+	case config.PodMonitorEnabled:
+		desired := monitoring.PodMonitor(target, config)
+		_, err := controllerutil.CreateOrUpdate(ctx, a.client, podMonitor, func() error {
+			podMonitor.Labels = desired.Labels
+			podMonitor.Spec = desired.Spec
+			return controllerutil.SetControllerReference(a.cluster, podMonitor, a.scheme)
+		})
+		// Without the prometheus-operator CRDs there is nothing to create, the status reports it
+		if meta.IsNoMatchError(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		keep = podMonitor
+	case config.ServiceMonitorEnabled:
+		desired := monitoring.ServiceMonitor(target, config)
+		_, err := controllerutil.CreateOrUpdate(ctx, a.client, serviceMonitor, func() error {
+			serviceMonitor.Labels = desired.Labels
+			serviceMonitor.Spec = desired.Spec
+			return controllerutil.SetControllerReference(a.cluster, serviceMonitor, a.scheme)
+		})
+		if meta.IsNoMatchError(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		desiredService := monitoring.MetricsService(target)
+		if _, err := controllerutil.CreateOrUpdate(ctx, a.client, service, func() error {
+			service.Labels = desiredService.Labels
+			// The ClusterIP of a Service is immutable, keep the one set at creation
+			service.Spec.ClusterIP = desiredService.Spec.ClusterIP
+			service.Spec.Selector = desiredService.Spec.Selector
+			service.Spec.Ports = desiredService.Spec.Ports
+			return controllerutil.SetControllerReference(a.cluster, service, a.scheme)
+		}); err != nil {
+			return err
+		}
+		keep = serviceMonitor
+	}
+
+	if keep != serviceMonitor {
+		if err := deleteMonitor(ctx, a.client, serviceMonitor); err != nil {
+			return err
+		}
+		if err := client.IgnoreNotFound(a.client.Delete(ctx, service)); err != nil {
+			return err
+		}
+	}
+	if keep != podMonitor {
+		if err := deleteMonitor(ctx, a.client, podMonitor); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteMonitor deletes a prometheus-operator object, ignoring clusters without the prometheus-operator CRDs
+func deleteMonitor(ctx context.Context, c client.Client, obj client.Object) error {
+	err := c.Delete(ctx, obj)
+	if meta.IsNoMatchError(err) {
+		return nil
+	}
+	return client.IgnoreNotFound(err)
+}
+
+// monitoringStatus reports whether the monitor objects of the cluster exist and have targets to scrape
+func (p *CNPGProvider) monitoringStatus(ctx context.Context, cluster *dbaasv1.DatabaseCluster, cnpgCluster *cnpgv1.Cluster) *dbaasv1.MonitoringStatus {
+	status := &dbaasv1.MonitoringStatus{Enabled: true}
+
+	settings, err := pmmSettings(ctx, p.client, cluster)
+	if err != nil {
+		status.Message = err.Error()
+		return status
+	}
+	if settings != nil {
+		return p.pmmStatus(ctx, cluster, settings)
+	}
+
+	if cluster.Spec.Monitoring.MonitoringConfigRef == nil {
+		// Without a MonitoringConfig, CNPG manages its own PodMonitor
+		status.Ready = cnpgCluster.Status.ReadyInstances > 0
+		if !status.Ready {
+			status.Message = "no instance is ready to export metrics"
+		}
+		return status
+	}
+
+	config, err := monitoring.ResolveConfig(ctx, p.client, cluster)
+	if err != nil {
+		status.Message = err.Error()
+		return status
+	}
+	if config.Spec.Type == "datadog" || config.Spec.Type == "newrelic" {
+		return p.agentStatus(ctx, cluster, cnpgCluster, config)
+	}
+	if config.Spec.Type != "prometheus" {
+		status.Message = fmt.Sprintf("monitoring type %s is not supported by the %s engine", config.Spec.Type, cluster.Spec.Engine.Type)
+		return status
+	}
+	prometheus, err := prometheusConfig(ctx, p.client, cluster)
+	if err != nil {
+		status.Message = err.Error()
+		return status
+	}
+
+	target := metricsTarget(cluster)
+	var monitor client.Object
+	var kind string
+	switch {
+	case prometheus.PodMonitorEnabled:
+		monitor, kind = &monitoringv1.PodMonitor{}, "PodMonitor"
+	case prometheus.ServiceMonitorEnabled:
+		monitor, kind = &monitoringv1.ServiceMonitor{}, "ServiceMonitor"
+		status.Endpoint = monitoring.MetricsEndpoint(target)
+	default:
+		status.Message = fmt.Sprintf("MonitoringConfig %s enables neither a PodMonitor nor a ServiceMonitor", config.Name)
+		return status
+	}
+
+	err = p.client.Get(ctx, types.NamespacedName{Name: target.Name, Namespace: target.Namespace}, monitor)
+	switch {
+	case meta.IsNoMatchError(err):
+		status.Message = "Prometheus operator CRDs are not installed"
+	case err != nil:
+		status.Message = fmt.Sprintf("failed to get %s %s: %v", kind, target.Name, err)
+	case cnpgCluster.Status.ReadyInstances == 0:
+		status.Message = "no instance is ready to export metrics"
+	default:
+		status.Ready = true
+	}
+	return status
+}
+
+// reconcileAlertRules creates the PrometheusRule generated from the alerting section of the
+// MonitoringConfig of the cluster, or removes it when alerting is not configured. Invalid
+// overrides are not rolled out, the AlertRulesValid condition of the cluster reports them.
+func (a *CNPGApplier) reconcileAlertRules() error {
+	ctx := context.TODO()
+	rule := &monitoringv1.PrometheusRule{}
+	rule.Name, rule.Namespace = monitoring.AlertRulesName(a.cluster), a.cluster.Namespace
+
+	var alerting *dbaasv1.AlertingSpec
+	if a.cluster.Spec.Monitoring != nil && a.cluster.Spec.Monitoring.Enabled {
+		config, err := monitoring.ResolveConfig(ctx, a.client, a.cluster)
+		if monitoring.IsConfigError(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if config != nil {
+			alerting = config.Spec.Alerting
+		}
+	}
+
+	desired, err := monitoring.PrometheusRule(a.cluster, alerting)
+	if monitoring.IsConfigError(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if desired == nil {
+		return deleteMonitor(ctx, a.client, rule)
+	}
+	_, err = controllerutil.CreateOrUpdate(ctx, a.client, rule, func() error {
+		rule.Labels = desired.Labels
+		rule.Spec = desired.Spec
+		return controllerutil.SetControllerReference(a.cluster, rule, a.scheme)
+	})
+	// Without the prometheus-operator CRDs there is nothing to create
+	if meta.IsNoMatchError(err) {
+		return nil
+	}
+	return err
+}
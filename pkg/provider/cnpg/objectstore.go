@@ -0,0 +1,133 @@
+package cnpg
+
+import (
+	"context"
+	"fmt"
+
+	cnpgv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	dbaasv1 "github.com/huynt0812/dbaas-operator/api/v1"
+	"github.com/huynt0812/dbaas-operator/pkg/backupstorage"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// getBackupStorage fetches a BackupStorage from the given namespace
+func getBackupStorage(ctx context.Context, c client.Client, namespace, name string) (*dbaasv1.BackupStorage, error) {
+	storage := &dbaasv1.BackupStorage{}
+	if err := c.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, storage); err != nil {
+		return nil, fmt.Errorf("failed to get BackupStorage %s: %w", name, err)
+	}
+	return storage, nil
+}
+
+// archiveServerName returns the name the cluster archives its backups and WAL under. A cluster
+// restored in place starts a new archive named after the restore: CNPG refuses to archive into
+// the one of the cluster it replaces, which still holds the WAL of the old timeline.
+func archiveServerName(cluster *dbaasv1.DatabaseCluster) string {
+	if restore := cluster.Status.Restore; restore != nil {
+		return fmt.Sprintf("%s-%s", cluster.Name, restore.OpsRequest)
+	}
+	if ops := cluster.Annotations[restoreOpsAnnotation]; ops != "" {
+		return fmt.Sprintf("%s-%s", cluster.Name, ops)
+	}
+	return cluster.Name
+}
+
+// barmanObjectStore builds the barman-cloud configuration pointing at a BackupStorage
+func barmanObjectStore(storage *dbaasv1.BackupStorage, serverName string) (*cnpgv1.BarmanObjectStoreConfiguration, error) {
+	destinationPath, err := backupstorage.DestinationPath(storage)
+	if err != nil {
+		return nil, err
+	}
+
+	objectStore := &cnpgv1.BarmanObjectStoreConfiguration{
+		DestinationPath: destinationPath,
+		ServerName:      serverName,
+	}
+
+	secretName := ""
+	if storage.Spec.CredentialsSecretRef != nil {
+		secretName = storage.Spec.CredentialsSecretRef.Name
+	}
+
+	if backupstorage.UsesWorkloadIdentity(storage) {
+		// The instances inherit the identity of their ServiceAccount, see bindWorkloadIdentity
+		if err := backupstorage.ValidateWorkloadIdentity(storage); err != nil {
+			return nil, err
+		}
+		switch storage.Spec.WorkloadIdentity.Provider {
+		case dbaasv1.WorkloadIdentityAWS:
+			objectStore.AWS = &cnpgv1.S3Credentials{InheritFromIAMRole: true}
+		case dbaasv1.WorkloadIdentityGCP:
+			objectStore.Google = &cnpgv1.GoogleCredentials{GKEEnvironment: true}
+		case dbaasv1.WorkloadIdentityAzure:
+			objectStore.Azure = &cnpgv1.AzureCredentials{InheritFromAzureAD: true}
+		}
+	}
+
+	switch storage.Spec.Type {
+	case "s3":
+		objectStore.EndpointURL = storage.Spec.S3.Endpoint
+		if secretName != "" {
+			objectStore.AWS = &cnpgv1.S3Credentials{
+				AccessKeyIDReference:     secretKey(secretName, backupstorage.S3AccessKeyIDKey),
+				SecretAccessKeyReference: secretKey(secretName, backupstorage.S3SecretAccessKeyKey),
+			}
+		}
+	case "gcs":
+		if secretName != "" {
+			objectStore.Google = &cnpgv1.GoogleCredentials{
+				ApplicationCredentials: secretKey(secretName, backupstorage.GCSApplicationCredentialsKey),
+			}
+		}
+	case "azure":
+		if secretName != "" {
+			objectStore.Azure = &cnpgv1.AzureCredentials{
+				StorageAccount: secretKey(secretName, backupstorage.AzureStorageAccountKey),
+				StorageKey:     secretKey(secretName, backupstorage.AzureStorageKeyKey),
+			}
+		}
+	}
+
+	if err := applyEncryption(objectStore, storage); err != nil {
+		return nil, err
+	}
+
+	return objectStore, nil
+}
+
+// applyEncryption configures barman-cloud to encrypt base backups and WAL files alike
+func applyEncryption(objectStore *cnpgv1.BarmanObjectStoreConfiguration, storage *dbaasv1.BackupStorage) error {
+	if err := backupstorage.ValidateEncryption(storage); err != nil {
+		return err
+	}
+
+	encryption := storage.Spec.Encryption
+	if encryption == nil {
+		return nil
+	}
+
+	var encryptionType cnpgv1.EncryptionType
+	switch encryption.Mode {
+	case dbaasv1.BackupEncryptionSSES3:
+		encryptionType = cnpgv1.EncryptionTypeAES256
+	case dbaasv1.BackupEncryptionSSEKMS:
+		encryptionType = cnpgv1.EncryptionTypeNoneAWSKMS
+	}
+
+	objectStore.Data = &cnpgv1.DataBackupConfiguration{Encryption: encryptionType}
+	objectStore.Wal = &cnpgv1.WalBackupConfiguration{Encryption: encryptionType}
+	if encryption.KMSKeyID != "" {
+		// WAL archiving has no extra arguments in CNPG and keeps using the bucket default KMS key
+		objectStore.Data.AdditionalCommandArgs = []string{"--sse-kms-key-id=" + encryption.KMSKeyID}
+	}
+	return nil
+}
+
+// secretKey builds a CNPG secret key selector
+func secretKey(name, key string) *cnpgv1.SecretKeySelector {
+	return &cnpgv1.SecretKeySelector{
+		LocalObjectReference: cnpgv1.LocalObjectReference{Name: name},
+		Key:                  key,
+	}
+}
@@ -7,12 +7,17 @@ import (
 
 	cnpgv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
 	dbaasv1 "github.com/huynt0812/dbaas-operator/api/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+// restoreOpsAnnotation records on a DatabaseCluster created by an out-of-place restore the OpsRequest that created it
+const restoreOpsAnnotation = "dbaas.io/restored-by"
+
 // CNPGOperationsHandler implements the OperationsHandler interface for CNPG
 type CNPGOperationsHandler struct {
 	client client.Client
@@ -192,8 +197,60 @@ func (h *CNPGOperationsHandler) Upgrade(ctx context.Context, cluster *dbaasv1.Da
 // Backup performs backup operation
 func (h *CNPGOperationsHandler) Backup(ctx context.Context, cluster *dbaasv1.DatabaseCluster, ops *dbaasv1.OpsRequest) error {
 	backupName := ops.Name
-	if ops.Spec.Backup != nil && ops.Spec.Backup.BackupName != "" {
-		backupName = ops.Spec.Backup.BackupName
+	var method dbaasv1.BackupMethod
+	if cluster.Spec.Backup != nil {
+		method = cluster.Spec.Backup.Method
+	}
+	if ops.Spec.Backup != nil {
+		if ops.Spec.Backup.BackupName != "" {
+			backupName = ops.Spec.Backup.BackupName
+		}
+		if ops.Spec.Backup.Method != "" {
+			method = ops.Spec.Backup.Method
+		}
+	}
+
+	// Logical backups are dumps taken by a Job streaming to the storage
+	if backupMethod(method) == dbaasv1.BackupMethodLogical {
+		if cluster.Spec.Backup == nil || cluster.Spec.Backup.BackupStorageRef == nil {
+			return fmt.Errorf("cluster %s has no backupStorageRef for %s backups", cluster.Name, dbaasv1.BackupMethodLogical)
+		}
+		storage, err := getBackupStorage(ctx, h.client, cluster.Namespace, cluster.Spec.Backup.BackupStorageRef.Name)
+		if err != nil {
+			return err
+		}
+		var override *dbaasv1.LogicalBackupSpec
+		if ops.Spec.Backup != nil {
+			override = ops.Spec.Backup.Logical
+		}
+		job, err := logicalBackupJob(cluster, storage, logicalBackupSpec(cluster, override), backupName)
+		if err != nil {
+			return err
+		}
+		return client.IgnoreAlreadyExists(h.client.Create(ctx, job))
+	}
+
+	cnpgMethod, err := cnpgBackupMethod(method)
+	if err != nil {
+		return err
+	}
+
+	// File-based storages are written by a backup Job mounting the storage
+	if cnpgMethod == cnpgv1.BackupMethodBarmanObjectStore {
+		storage, err := fileBackupStorage(ctx, h.client, cluster)
+		if err != nil {
+			return err
+		}
+		if storage != nil {
+			job, err := fileBackupJob(cluster, storage, backupName)
+			if err != nil {
+				return err
+			}
+			return client.IgnoreAlreadyExists(h.client.Create(ctx, job))
+		}
+	}
+	if cnpgMethod == cnpgv1.BackupMethodVolumeSnapshot && (cluster.Spec.Backup == nil || cluster.Spec.Backup.VolumeSnapshot == nil) {
+		return fmt.Errorf("cluster %s has no volumeSnapshot backup configuration", cluster.Name)
 	}
 
 	// Create a CNPG Backup object
@@ -201,43 +258,90 @@ func (h *CNPGOperationsHandler) Backup(ctx context.Context, cluster *dbaasv1.Dat
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      backupName,
 			Namespace: cluster.Namespace,
+			Labels: map[string]string{
+				"dbaas.io/cluster": cluster.Name,
+				"dbaas.io/engine":  cluster.Spec.Engine.Type,
+			},
 		},
 		Spec: cnpgv1.BackupSpec{
 			Cluster: cnpgv1.LocalObjectReference{
 				Name: cluster.Name,
 			},
+			Method: cnpgMethod,
 		},
 	}
 
-	return h.client.Create(ctx, backup)
+	// The operation is executed on every reconcile of the OpsRequest
+	return client.IgnoreAlreadyExists(h.client.Create(ctx, backup))
 }
 
-// Restore performs restore operation
+// Restore performs restore operation.
+// The data of the cluster is replaced by the backup, or a new cluster is created from it in target cluster mode.
 func (h *CNPGOperationsHandler) Restore(ctx context.Context, cluster *dbaasv1.DatabaseCluster, ops *dbaasv1.OpsRequest) error {
 	if ops.Spec.Restore == nil {
 		return fmt.Errorf("restore spec is required")
 	}
 
-	cnpgCluster := &cnpgv1.Cluster{}
-	if err := h.client.Get(ctx, types.NamespacedName{Name: cluster.Name, Namespace: cluster.Namespace}, cnpgCluster); err != nil {
+	backup := &dbaasv1.DatabaseBackup{}
+	err := h.client.Get(ctx, types.NamespacedName{Name: ops.Spec.Restore.BackupName, Namespace: cluster.Namespace}, backup)
+	if err != nil && !errors.IsNotFound(err) {
 		return err
 	}
-
-	// Configure restore from backup
-	// Note: CNPG v1.23 has different BackupSource API structure
-	// This is a simplified placeholder - full implementation would use proper CNPG API
-	cnpgCluster.Spec.Bootstrap = &cnpgv1.BootstrapConfiguration{
-		Recovery: &cnpgv1.BootstrapRecovery{},
+	logical := err == nil && backup.Spec.Method == dbaasv1.BackupMethodLogical
+	if logical && ops.Spec.Restore.PointInTime != nil {
+		return fmt.Errorf("point-in-time recovery is not supported from logical backup %s", backup.Name)
 	}
 
-	// If PITR is specified, set recovery target time
-	if ops.Spec.Restore.PointInTime != nil {
-		cnpgCluster.Spec.Bootstrap.Recovery.RecoveryTarget = &cnpgv1.RecoveryTarget{
-			TargetTime: ops.Spec.Restore.PointInTime.Format(time.RFC3339),
+	if ops.Spec.Restore.TargetCluster != nil {
+		if logical {
+			return h.logicalRestoreToTarget(ctx, cluster, ops, backup)
 		}
+		return h.restoreToTarget(ctx, cluster, ops)
+	}
+	if logical {
+		// Dumps are loaded into the running cluster
+		return h.logicalRestore(ctx, cluster, ops, backup)
 	}
 
-	return h.client.Update(ctx, cnpgCluster)
+	// The operation is executed on every reconcile of the OpsRequest, only restore once
+	if restore := cluster.Status.Restore; restore != nil && restore.OpsRequest == ops.Name {
+		return h.deleteReplacedCluster(ctx, cluster, restore)
+	}
+	if !ops.Spec.Restore.InPlace {
+		return fmt.Errorf("restoring backup %s in place replaces the data of cluster %s, set restore.inPlace to confirm it or restore into a targetCluster",
+			ops.Spec.Restore.BackupName, cluster.Name)
+	}
+
+	// Record the backup in status rather than in the spec of the cluster, so that the
+	// DatabaseCluster reconcile recreates the CNPG Cluster bootstrapped from it
+	restore := &dbaasv1.RestoreStatus{
+		OpsRequest:  ops.Name,
+		BackupName:  ops.Spec.Restore.BackupName,
+		PointInTime: ops.Spec.Restore.PointInTime,
+		StartedAt:   metav1.Now(),
+	}
+
+	// Resolve the recovery the way the recreated cluster will before touching anything, so
+	// that a backup it cannot bootstrap from leaves the database in place
+	restored := cluster.DeepCopy()
+	restored.Status.Restore = restore
+	applier := NewApplier(restored, h.client, h.scheme)
+	applier.cnpgCluster.Spec.Bootstrap = &cnpgv1.BootstrapConfiguration{Recovery: &cnpgv1.BootstrapRecovery{}}
+	if err := applier.backupRecovery(bootstrapBackup(restored)); err != nil {
+		return fmt.Errorf("cannot restore from backup %s: %w", ops.Spec.Restore.BackupName, err)
+	}
+
+	// Deleting the CNPG Cluster deletes its volumes, keep snapshots of them until the restored cluster is ready
+	ready, err := h.snapshotVolumes(ctx, cluster, ops)
+	if err != nil || !ready {
+		return err
+	}
+
+	cluster.Status.Restore = restore
+	if err := h.client.Status().Update(ctx, cluster); err != nil {
+		return err
+	}
+	return h.deleteReplacedCluster(ctx, cluster, restore)
 }
 
 // Expose exposes the database service
@@ -281,19 +385,65 @@ func (h *CNPGOperationsHandler) Custom(ctx context.Context, cluster *dbaasv1.Dat
 // GetStatus returns the current status of an operation
 func (h *CNPGOperationsHandler) GetStatus(ctx context.Context, cluster *dbaasv1.DatabaseCluster, ops *dbaasv1.OpsRequest) (*dbaasv1.OpsRequestStatus, error) {
 	status := &dbaasv1.OpsRequestStatus{
-		Phase: dbaasv1.OpsRequestPhaseRunning,
+		Phase:     dbaasv1.OpsRequestPhaseRunning,
+		StartTime: ops.Status.StartTime,
+	}
+
+	// Backups complete independently of the cluster state
+	if ops.Spec.Type == dbaasv1.OpsRequestTypeBackup {
+		return h.backupStatus(ctx, cluster, ops, status)
+	}
+
+	if ops.Spec.Type == dbaasv1.OpsRequestTypeRestore && ops.Spec.Restore != nil {
+		// Logical restores complete with their Job
+		backup := &dbaasv1.DatabaseBackup{}
+		err := h.client.Get(ctx, types.NamespacedName{Name: ops.Spec.Restore.BackupName, Namespace: cluster.Namespace}, backup)
+		if err != nil && !errors.IsNotFound(err) {
+			return nil, err
+		}
+		if err == nil && backup.Spec.Method == dbaasv1.BackupMethodLogical {
+			return h.logicalRestoreStatus(ctx, cluster, ops, status)
+		}
+
+		// Out-of-place restores complete with the new cluster
+		if ops.Spec.Restore.TargetCluster != nil {
+			return h.targetRestoreStatus(ctx, cluster, ops, status)
+		}
+
+		// In-place restores delete the cluster once the snapshots of its volumes are ready
+		if restore := cluster.Status.Restore; restore == nil || restore.OpsRequest != ops.Name {
+			status.Message = fmt.Sprintf("snapshotting the volumes of cluster %s before the restore", cluster.Name)
+			return status, nil
+		}
 	}
 
 	// Get current CNPG cluster status
 	cnpgCluster := &cnpgv1.Cluster{}
 	if err := h.client.Get(ctx, types.NamespacedName{Name: cluster.Name, Namespace: cluster.Namespace}, cnpgCluster); err != nil {
+		if errors.IsNotFound(err) && ops.Spec.Type == dbaasv1.OpsRequestTypeRestore {
+			// The cluster is being recreated from the backup
+			status.Message = "waiting for the cluster to be recreated from the backup"
+			return status, nil
+		}
 		status.Phase = dbaasv1.OpsRequestPhaseFailed
 		status.Message = err.Error()
 		return status, nil
 	}
+	if ops.Spec.Type == dbaasv1.OpsRequestTypeRestore &&
+		(cnpgCluster.DeletionTimestamp != nil || (ops.Status.StartTime != nil && cnpgCluster.CreationTimestamp.Before(ops.Status.StartTime))) {
+		// The cluster replaced by the restore is still being deleted
+		status.Message = "waiting for the cluster to be recreated from the backup"
+		return status, nil
+	}
 
 	// Check if operation completed successfully
 	if cnpgCluster.Status.Phase == "Cluster in healthy state" {
+		if ops.Spec.Type == dbaasv1.OpsRequestTypeRestore {
+			// The volumes of the replaced cluster are no longer needed
+			if err := h.deleteVolumeSnapshots(ctx, cluster, ops); err != nil {
+				return nil, err
+			}
+		}
 		status.Phase = dbaasv1.OpsRequestPhaseSucceeded
 		status.CompletionTime = &metav1.Time{Time: time.Now()}
 	}
@@ -310,3 +460,79 @@ func (h *CNPGOperationsHandler) GetStatus(ctx context.Context, cluster *dbaasv1.
 
 	return status, nil
 }
+
+// backupStatus maps the phase of the CNPG Backup created by a backup operation
+func (h *CNPGOperationsHandler) backupStatus(ctx context.Context, cluster *dbaasv1.DatabaseCluster, ops *dbaasv1.OpsRequest, status *dbaasv1.OpsRequestStatus) (*dbaasv1.OpsRequestStatus, error) {
+	backupName := ops.Name
+	if ops.Spec.Backup != nil && ops.Spec.Backup.BackupName != "" {
+		backupName = ops.Spec.Backup.BackupName
+	}
+
+	// Backups taken by Jobs are tracked through their DatabaseBackup, kept in sync with the Job
+	job := &batchv1.Job{}
+	err := h.client.Get(ctx, types.NamespacedName{Name: backupName, Namespace: cluster.Namespace}, job)
+	if err == nil && (job.Labels[fileBackupLabel] == cluster.Name || job.Labels[logicalBackupLabel] == cluster.Name) {
+		return h.jobBackupStatus(ctx, cluster, ops, backupName, status)
+	} else if err != nil && !errors.IsNotFound(err) {
+		return nil, err
+	}
+
+	backup := &cnpgv1.Backup{}
+	if err := h.client.Get(ctx, types.NamespacedName{Name: backupName, Namespace: cluster.Namespace}, backup); err != nil {
+		return nil, err
+	}
+
+	switch backup.Status.Phase {
+	case cnpgv1.BackupPhaseCompleted:
+		status.Phase = dbaasv1.OpsRequestPhaseSucceeded
+		status.CompletionTime = &metav1.Time{Time: time.Now()}
+	case cnpgv1.BackupPhaseFailed:
+		status.Phase = dbaasv1.OpsRequestPhaseFailed
+		status.CompletionTime = &metav1.Time{Time: time.Now()}
+		status.Message = backup.Status.Error
+	}
+
+	status.ActionLog = []dbaasv1.ActionLogEntry{
+		{
+			Timestamp: metav1.Time{Time: time.Now()},
+			Action:    string(ops.Spec.Type),
+			Status:    string(status.Phase),
+			Message:   fmt.Sprintf("backup %s (%s) is %s", backupName, backup.Spec.Method, backup.Status.Phase),
+		},
+	}
+
+	return status, nil
+}
+
+// jobBackupStatus maps the phase of the DatabaseBackup of a backup operation run by a Job
+func (h *CNPGOperationsHandler) jobBackupStatus(ctx context.Context, cluster *dbaasv1.DatabaseCluster, ops *dbaasv1.OpsRequest, backupName string, status *dbaasv1.OpsRequestStatus) (*dbaasv1.OpsRequestStatus, error) {
+	backup := &dbaasv1.DatabaseBackup{}
+	if err := h.client.Get(ctx, types.NamespacedName{Name: backupName, Namespace: cluster.Namespace}, backup); err != nil {
+		if errors.IsNotFound(err) {
+			// The record is created by the next status update of the cluster
+			return status, nil
+		}
+		return nil, err
+	}
+
+	switch backup.Status.Phase {
+	case dbaasv1.DatabaseBackupPhaseSucceeded:
+		status.Phase = dbaasv1.OpsRequestPhaseSucceeded
+		status.CompletionTime = &metav1.Time{Time: time.Now()}
+	case dbaasv1.DatabaseBackupPhaseFailed:
+		status.Phase = dbaasv1.OpsRequestPhaseFailed
+		status.CompletionTime = &metav1.Time{Time: time.Now()}
+		status.Message = backup.Status.Message
+	}
+
+	status.ActionLog = []dbaasv1.ActionLogEntry{
+		{
+			Timestamp: metav1.Time{Time: time.Now()},
+			Action:    string(ops.Spec.Type),
+			Status:    string(status.Phase),
+			Message:   fmt.Sprintf("backup %s (%s) is %s", backupName, backup.Spec.Method, backup.Status.Phase),
+		},
+	}
+
+	return status, nil
+}
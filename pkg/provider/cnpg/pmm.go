@@ -0,0 +1,186 @@
+package cnpg
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+
+	cnpgv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	dbaasv1 "github.com/huynt0812/dbaas-operator/api/v1"
+	"github.com/huynt0812/dbaas-operator/pkg/monitoring"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// monitoringUser is the PostgreSQL role the monitoring agents connect with
+const monitoringUser = "dbaas_monitor"
+
+// monitoringSecretName returns the basic-auth secret holding the credentials of the monitoring user
+func monitoringSecretName(cluster *dbaasv1.DatabaseCluster) string {
+	return fmt.Sprintf("%s-monitoring", cluster.Name)
+}
+
+// pmmClientName returns the name of the Deployment running the PMM client of the cluster
+func pmmClientName(cluster *dbaasv1.DatabaseCluster) string {
+	return fmt.Sprintf("%s-pmm", cluster.Name)
+}
+
+// pmmSettings returns the PMM server the cluster registers with, or nil when it is not monitored by PMM
+func pmmSettings(ctx context.Context, c client.Client, cluster *dbaasv1.DatabaseCluster) (*monitoring.PMMSettings, error) {
+	if cluster.Spec.Monitoring == nil || !cluster.Spec.Monitoring.Enabled {
+		return nil, nil
+	}
+	config, err := monitoring.ResolveConfig(ctx, c, cluster)
+	if err != nil {
+		return nil, err
+	}
+	return monitoring.ResolvePMM(cluster, config)
+}
+
+// monitoringUsername returns the user of the monitoring agents held by a basic-auth secret
+func monitoringUsername(secret *corev1.Secret) (string, error) {
+	username := string(secret.Data[corev1.BasicAuthUsernameKey])
+	if username == "" {
+		return "", &monitoring.ConfigError{Err: fmt.Errorf("monitoring credentials %s have no %s key", secret.Name, corev1.BasicAuthUsernameKey)}
+	}
+	return username, nil
+}
+
+// monitoringRole adds the user of the monitoring agents to the managed roles of the cluster and
+// returns its name. The credentials come from the given basic-auth secret; the one named after
+// the cluster is generated on first use, while a missing user-supplied secret or username is a
+// ConfigError. The role only gets the read access of pg_monitor.
+func (a *CNPGApplier) monitoringRole(secretName string) (string, error) {
+	ctx := context.TODO()
+	secret := &corev1.Secret{}
+	err := a.client.Get(ctx, types.NamespacedName{Name: secretName, Namespace: a.cluster.Namespace}, secret)
+	if errors.IsNotFound(err) && secretName == monitoringSecretName(a.cluster) {
+		password := make([]byte, 24)
+		if _, err := rand.Read(password); err != nil {
+			return "", fmt.Errorf("failed to generate the monitoring password: %w", err)
+		}
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      secretName,
+				Namespace: a.cluster.Namespace,
+				Labels:    map[string]string{"dbaas.io/cluster": a.cluster.Name},
+			},
+			Type: corev1.SecretTypeBasicAuth,
+			Data: map[string][]byte{
+				corev1.BasicAuthUsernameKey: []byte(monitoringUser),
+				corev1.BasicAuthPasswordKey: []byte(hex.EncodeToString(password)),
+			},
+		}
+		if err := controllerutil.SetControllerReference(a.cluster, secret, a.scheme); err != nil {
+			return "", err
+		}
+		if err := a.client.Create(ctx, secret); err != nil {
+			return "", err
+		}
+	} else if errors.IsNotFound(err) {
+		return "", &monitoring.ConfigError{Err: fmt.Errorf("monitoring credentials %s not found", secretName)}
+	} else if err != nil {
+		return "", fmt.Errorf("failed to get monitoring credentials %s: %w", secretName, err)
+	}
+
+	username, err := monitoringUsername(secret)
+	if err != nil {
+		return "", err
+	}
+
+	if a.cnpgCluster.Spec.Managed == nil {
+		a.cnpgCluster.Spec.Managed = &cnpgv1.ManagedConfiguration{}
+	}
+	for _, role := range a.cnpgCluster.Spec.Managed.Roles {
+		if role.Name == username {
+			return username, nil
+		}
+	}
+	a.cnpgCluster.Spec.Managed.Roles = append(a.cnpgCluster.Spec.Managed.Roles, cnpgv1.RoleConfiguration{
+		Name:           username,
+		Comment:        "Read-only user of the monitoring agents",
+		Ensure:         cnpgv1.EnsurePresent,
+		Login:          true,
+		InRoles:        []string{"pg_monitor"},
+		PasswordSecret: &cnpgv1.LocalObjectReference{Name: secretName},
+	})
+	return username, nil
+}
+
+// reconcilePMMClient runs the PMM client of the cluster, or removes it when PMM is not configured.
+// CNPG instance pods do not accept extra containers, so the client runs next to the cluster in its
+// own Deployment and monitors the primary through the read-write service: the replicas are not
+// registered and PMM has no host metrics of the instances. The client registers as a node named
+// after the Deployment, which it replaces on every restart instead of adding a new one.
+func (a *CNPGApplier) reconcilePMMClient(settings *monitoring.PMMSettings) error {
+	ctx := context.TODO()
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      pmmClientName(a.cluster),
+			Namespace: a.cluster.Namespace,
+		},
+	}
+	if settings == nil {
+		return client.IgnoreNotFound(a.client.Delete(ctx, deployment))
+	}
+
+	if _, err := a.monitoringRole(monitoringSecretName(a.cluster)); err != nil {
+		return err
+	}
+
+	container := monitoring.PMMClientContainer(settings, monitoring.PMMService{
+		Type:              "postgresql",
+		Host:              fmt.Sprintf("%s-rw.%s.svc", a.cluster.Name, a.cluster.Namespace),
+		Port:              5432,
+		ServiceName:       fmt.Sprintf("%s-%s", a.cluster.Namespace, a.cluster.Name),
+		ClusterName:       a.cluster.Name,
+		NodeName:          fmt.Sprintf("%s-%s", a.cluster.Namespace, pmmClientName(a.cluster)),
+		CredentialsSecret: monitoringSecretName(a.cluster),
+	})
+	labels := map[string]string{
+		"dbaas.io/cluster":   a.cluster.Name,
+		"dbaas.io/engine":    a.cluster.Spec.Engine.Type,
+		"dbaas.io/component": "pmm-client",
+	}
+	replicas := int32(1)
+
+	_, err := controllerutil.CreateOrUpdate(ctx, a.client, deployment, func() error {
+		deployment.Labels = labels
+		deployment.Spec.Replicas = &replicas
+		// Two clients must not register the same node at once during a rollout
+		deployment.Spec.Strategy = appsv1.DeploymentStrategy{Type: appsv1.RecreateDeploymentStrategyType}
+		// The selector of a Deployment is immutable, keep the one set at creation
+		if deployment.Spec.Selector == nil {
+			deployment.Spec.Selector = &metav1.LabelSelector{MatchLabels: labels}
+		}
+		deployment.Spec.Template.Labels = labels
+		deployment.Spec.Template.Spec.Containers = []corev1.Container{container}
+		return controllerutil.SetControllerReference(a.cluster, deployment, a.scheme)
+	})
+	return err
+}
+
+// pmmStatus reports whether the PMM client of the cluster is registered with the server
+func (p *CNPGProvider) pmmStatus(ctx context.Context, cluster *dbaasv1.DatabaseCluster, settings *monitoring.PMMSettings) *dbaasv1.MonitoringStatus {
+	status := &dbaasv1.MonitoringStatus{Enabled: true}
+
+	deployment := &appsv1.Deployment{}
+	err := p.client.Get(ctx, types.NamespacedName{Name: pmmClientName(cluster), Namespace: cluster.Namespace}, deployment)
+	switch {
+	case err != nil:
+		status.Message = fmt.Sprintf("failed to get PMM client %s: %v", pmmClientName(cluster), err)
+	case deployment.Status.ReadyReplicas == 0:
+		// The readiness probe of the client passes once pmm-agent is connected to the server
+		status.Message = fmt.Sprintf("PMM client is not registered with %s", monitoring.PMMServerAddress(settings))
+	default:
+		status.Ready = true
+		status.Endpoint = monitoring.PMMEndpoint(settings)
+	}
+	return status
+}
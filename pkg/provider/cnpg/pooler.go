@@ -0,0 +1,172 @@
+package cnpg
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	cnpgv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	dbaasv1 "github.com/huynt0812/dbaas-operator/api/v1"
+	dbaasproxy "github.com/huynt0812/dbaas-operator/pkg/proxy"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// pgbouncerContainer is the container of the Pooler pods
+const pgbouncerContainer = "pgbouncer"
+
+// poolerName returns the name of the Pooler of the cluster serving the given instances. Pooler
+// names must not match a cluster name, hence the suffix.
+func poolerName(cluster *dbaasv1.DatabaseCluster, poolerType cnpgv1.PoolerType) string {
+	return fmt.Sprintf("%s-pooler-%s", cluster.Name, poolerType)
+}
+
+// poolerParameters returns the pgbouncer.ini settings of a proxy. The parameters of the spec
+// win over the typed settings.
+func poolerParameters(spec *dbaasv1.ProxyInstanceSpec) map[string]string {
+	parameters := make(map[string]string)
+	if spec.DefaultPoolSize != nil {
+		parameters["default_pool_size"] = strconv.Itoa(int(*spec.DefaultPoolSize))
+	}
+	if spec.MaxClientConnections != nil {
+		parameters["max_client_conn"] = strconv.Itoa(int(*spec.MaxClientConnections))
+	}
+	for k, v := range spec.Parameters {
+		parameters[k] = v
+	}
+	return parameters
+}
+
+// poolerService returns the template of the service of a Pooler exposed as the spec asks
+func poolerService(expose *dbaasv1.ExposeSpec) *cnpgv1.ServiceTemplateSpec {
+	if expose == nil {
+		return nil
+	}
+	return &cnpgv1.ServiceTemplateSpec{
+		ObjectMeta: cnpgv1.Metadata{Annotations: expose.Annotations},
+		Spec: corev1.ServiceSpec{
+			Type:                     expose.Type,
+			LoadBalancerSourceRanges: expose.LoadBalancerSourceRanges,
+		},
+	}
+}
+
+// reconcilePoolers manages the PgBouncer Poolers of the cluster: the read-write one in front of
+// the primary and, when the proxy spec asks for it, the read-only one in front of the replicas.
+// CNPG deploys PgBouncer with each Pooler and authenticates the clients through the cluster
+// itself. It returns the read-write Pooler, nil when the proxy is disabled.
+func (a *CNPGApplier) reconcilePoolers() (*cnpgv1.Pooler, error) {
+	proxy := a.cluster.Spec.Proxy
+	if err := dbaasproxy.Validate(a.cluster.Spec.Engine.Type, proxy); err != nil {
+		return nil, err
+	}
+
+	var rw, ro *dbaasv1.ProxyInstanceSpec
+	if proxy != nil && proxy.Enabled {
+		rw, ro = &proxy.ProxyInstanceSpec, proxy.ReadOnly
+	}
+	if _, err := a.reconcilePooler(cnpgv1.PoolerTypeRO, ro); err != nil {
+		return nil, err
+	}
+	return a.reconcilePooler(cnpgv1.PoolerTypeRW, rw)
+}
+
+// reconcilePooler creates or updates the Pooler of the given type from its spec, and deletes it
+// when the spec is nil
+func (a *CNPGApplier) reconcilePooler(poolerType cnpgv1.PoolerType, spec *dbaasv1.ProxyInstanceSpec) (*cnpgv1.Pooler, error) {
+	ctx := context.TODO()
+	pooler := &cnpgv1.Pooler{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      poolerName(a.cluster, poolerType),
+			Namespace: a.cluster.Namespace,
+		},
+	}
+	if spec == nil {
+		return nil, client.IgnoreNotFound(a.client.Delete(ctx, pooler))
+	}
+
+	poolMode := cnpgv1.PgBouncerPoolModeSession
+	if spec.PoolMode != "" {
+		poolMode = cnpgv1.PgBouncerPoolMode(spec.PoolMode)
+	}
+	instances := spec.Replicas
+	_, err := controllerutil.CreateOrUpdate(ctx, a.client, pooler, func() error {
+		pooler.Labels = map[string]string{
+			"dbaas.io/cluster": a.cluster.Name,
+			"dbaas.io/engine":  a.cluster.Spec.Engine.Type,
+		}
+		pooler.Spec.Cluster = cnpgv1.LocalObjectReference{Name: a.cluster.Name}
+		pooler.Spec.Type = poolerType
+		pooler.Spec.Instances = &instances
+		if pooler.Spec.PgBouncer == nil {
+			pooler.Spec.PgBouncer = &cnpgv1.PgBouncerSpec{}
+		}
+		pooler.Spec.PgBouncer.PoolMode = poolMode
+		pooler.Spec.PgBouncer.Parameters = poolerParameters(spec)
+		pooler.Spec.ServiceTemplate = poolerService(spec.Expose)
+
+		// CNPG merges the template with the pgbouncer container it builds
+		pooler.Spec.Template = nil
+		if len(spec.Resources.Limits) > 0 || len(spec.Resources.Requests) > 0 {
+			pooler.Spec.Template = &cnpgv1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: pgbouncerContainer, Resources: spec.Resources}},
+				},
+			}
+		}
+		return controllerutil.SetControllerReference(a.cluster, pooler, a.scheme)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pooler, nil
+}
+
+// proxyStatus reports the Poolers of the cluster, the read-only one nested in the status of the
+// read-write one
+func (p *CNPGProvider) proxyStatus(ctx context.Context, cluster *dbaasv1.DatabaseCluster) (*dbaasv1.ProxyStatus, error) {
+	status, err := p.poolerStatus(ctx, cluster, cnpgv1.PoolerTypeRW)
+	if err != nil || cluster.Spec.Proxy.ReadOnly == nil {
+		return status, err
+	}
+	if status.ReadOnly, err = p.poolerStatus(ctx, cluster, cnpgv1.PoolerTypeRO); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// poolerStatus reports a Pooler of the cluster from the Deployment CNPG runs it with
+func (p *CNPGProvider) poolerStatus(ctx context.Context, cluster *dbaasv1.DatabaseCluster, poolerType cnpgv1.PoolerType) (*dbaasv1.ProxyStatus, error) {
+	name := poolerName(cluster, poolerType)
+	status := &dbaasv1.ProxyStatus{}
+
+	pooler := &cnpgv1.Pooler{}
+	if err := p.client.Get(ctx, types.NamespacedName{Name: name, Namespace: cluster.Namespace}, pooler); err != nil {
+		return status, client.IgnoreNotFound(err)
+	}
+	status.Endpoint = fmt.Sprintf("%s.%s.svc.cluster.local", pooler.Name, pooler.Namespace)
+
+	// CNPG names the Deployment of a Pooler after it
+	deployment := &appsv1.Deployment{}
+	err := p.client.Get(ctx, types.NamespacedName{Name: name, Namespace: cluster.Namespace}, deployment)
+	if errors.IsNotFound(err) {
+		status.Replicas = pooler.Status.Instances
+		return status, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	desired := int32(1)
+	if deployment.Spec.Replicas != nil {
+		desired = *deployment.Spec.Replicas
+	}
+	status.Replicas = deployment.Status.Replicas
+	status.ReadyReplicas = deployment.Status.ReadyReplicas
+	status.Ready = desired > 0 && deployment.Status.ReadyReplicas >= desired && deployment.Status.UpdatedReplicas >= desired
+	return status, nil
+}
@@ -7,6 +7,7 @@ import (
 	cnpgv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
 	dbaasv1 "github.com/huynt0812/dbaas-operator/api/v1"
 	"github.com/huynt0812/dbaas-operator/pkg/provider/interfaces"
+	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -62,8 +63,8 @@ func (p *CNPGProvider) Status(ctx context.Context, cluster *dbaasv1.DatabaseClus
 		PrimaryInstance: cnpgCluster.Status.CurrentPrimary,
 		Roles:           make(map[string]string),
 		Endpoints: &dbaasv1.DatabaseEndpoints{
-			Primary: fmt.Sprintf("%s-rw.%s.svc.cluster.local", cluster.Name, cluster.Namespace),
-			Replica: fmt.Sprintf("%s-ro.%s.svc.cluster.local", cluster.Name, cluster.Namespace),
+			Primary: endpointHost(cluster, "rw"),
+			Replica: endpointHost(cluster, "ro"),
 		},
 	}
 
@@ -74,30 +75,38 @@ func (p *CNPGProvider) Status(ctx context.Context, cluster *dbaasv1.DatabaseClus
 		status.Database.Roles[cnpgCluster.Status.CurrentPrimary] = "primary"
 	}
 
+	// Record the backups of the cluster as DatabaseBackups
+	if err := p.syncBackupRecords(ctx, cluster); err != nil {
+		return nil, fmt.Errorf("failed to sync backup records: %w", err)
+	}
+
 	// Map backup status
 	if cluster.Spec.Backup != nil && cluster.Spec.Backup.Enabled {
-		status.Backup = &dbaasv1.BackupStatus{}
-		if cnpgCluster.Status.FirstRecoverabilityPoint != "" {
-			// Parse time from CNPG format
-			// This is simplified - actual implementation would parse the time properly
-			status.Backup.LastBackupTime = &cluster.CreationTimestamp
+		backupStatus, err := p.clusterBackupStatus(ctx, cluster, cnpgCluster)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get backup status: %w", err)
 		}
+		status.Backup = backupStatus
 	}
 
 	// Map monitoring status
 	if cluster.Spec.Monitoring != nil && cluster.Spec.Monitoring.Enabled {
-		status.Monitoring = &dbaasv1.MonitoringStatus{
-			Enabled: true,
-			Ready:   true, // Simplified - would check actual monitoring status
+		status.Monitoring = p.monitoringStatus(ctx, cluster, cnpgCluster)
+		if link := p.dashboardURL(ctx, cluster); link != "" {
+			status.Monitoring.Endpoint = link
 		}
 	}
 
 	// Map proxy status
 	if cluster.Spec.Proxy != nil && cluster.Spec.Proxy.Enabled {
-		status.Proxy = &dbaasv1.ProxyStatus{
-			Ready:         true, // Simplified
-			Replicas:      cluster.Spec.Proxy.Replicas,
-			ReadyReplicas: cluster.Spec.Proxy.Replicas,
+		proxyStatus, err := p.proxyStatus(ctx, cluster)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get proxy status: %w", err)
+		}
+		status.Proxy = proxyStatus
+		status.Database.Endpoints.PoolerPrimary = proxyStatus.Endpoint
+		if proxyStatus.ReadOnly != nil {
+			status.Database.Endpoints.PoolerReplica = proxyStatus.ReadOnly.Endpoint
 		}
 	}
 
@@ -111,6 +120,15 @@ func (p *CNPGProvider) Status(ctx context.Context, cluster *dbaasv1.DatabaseClus
 
 // Cleanup performs cleanup operations when deleting a cluster
 func (p *CNPGProvider) Cleanup(ctx context.Context, cluster *dbaasv1.DatabaseCluster) error {
+	// Services swapped to the target of an out-of-place restore keep serving its clients, and
+	// services of other clusters swapped to this one go back to their own cluster
+	if err := handOverEndpoints(ctx, p.client, p.scheme, cluster); err != nil {
+		return err
+	}
+	if err := rollBackEndpoints(ctx, p.client, cluster); err != nil {
+		return err
+	}
+
 	// Delete the CNPG Cluster
 	cnpgCluster := &cnpgv1.Cluster{}
 	err := p.client.Get(ctx, types.NamespacedName{
@@ -127,13 +145,20 @@ func (p *CNPGProvider) Cleanup(ctx context.Context, cluster *dbaasv1.DatabaseClu
 // PreReconcileHook is called before reconciling the cluster
 func (p *CNPGProvider) PreReconcileHook(ctx context.Context, cluster *dbaasv1.DatabaseCluster) (requeueAfter int, err error) {
 	// Check if we're in the middle of a restore operation
-	if cluster.Spec.DataSource != nil && cluster.Spec.DataSource.BackupSource != nil {
+	if bootstrapBackup(cluster) != nil {
 		cnpgCluster := &cnpgv1.Cluster{}
 		err := p.client.Get(ctx, types.NamespacedName{
 			Name:      cluster.Name,
 			Namespace: cluster.Namespace,
 		}, cnpgCluster)
-		if err != nil {
+		if errors.IsNotFound(err) {
+			// The cluster is created by the reconcile itself, or recreated by an in-place restore,
+			// once a backup in a file storage is unpacked into a volume snapshot it can bootstrap from
+			if !cluster.DeletionTimestamp.IsZero() {
+				return 0, nil
+			}
+			return p.prepareFileRestore(ctx, cluster)
+		} else if err != nil {
 			return 0, err
 		}
 
@@ -141,6 +166,9 @@ func (p *CNPGProvider) PreReconcileHook(ctx context.Context, cluster *dbaasv1.Da
 		if cnpgCluster.Status.Phase != "Cluster in healthy state" {
 			return 5, nil
 		}
+		if err := p.cleanupFileRestore(ctx, cluster); err != nil {
+			return 0, err
+		}
 	}
 
 	return 0, nil
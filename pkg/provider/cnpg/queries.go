@@ -0,0 +1,119 @@
+package cnpg
+
+import (
+	"context"
+	"fmt"
+
+	cnpgv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	dbaasv1 "github.com/huynt0812/dbaas-operator/api/v1"
+	"github.com/huynt0812/dbaas-operator/pkg/monitoring"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/yaml"
+)
+
+// customQueriesKey is the key of the custom queries in their ConfigMap
+const customQueriesKey = "queries.yaml"
+
+// customQueriesConfigMapName returns the name of the ConfigMap holding the custom queries of the cluster
+func customQueriesConfigMapName(cluster *dbaasv1.DatabaseCluster) string {
+	return fmt.Sprintf("%s-custom-queries", cluster.Name)
+}
+
+// renderCustomQueries renders the queries in the format of the CNPG exporter
+func renderCustomQueries(queries []dbaasv1.CustomQuery) (string, error) {
+	rendered := make(map[string]interface{}, len(queries))
+	for _, query := range queries {
+		metrics := make([]map[string]map[string]string, 0, len(query.Metrics))
+		for _, metric := range query.Metrics {
+			usage := metric.Usage
+			if usage == "" {
+				usage = monitoring.UsageGauge
+			}
+			mapping := map[string]string{"usage": usage}
+			if metric.Description != "" {
+				mapping["description"] = metric.Description
+			}
+			metrics = append(metrics, map[string]map[string]string{metric.Column: mapping})
+		}
+
+		// CNPG runs the queries in the postgres database unless told otherwise
+		databases := query.Databases
+		if len(databases) == 0 {
+			databases = []string{"app"}
+		}
+		rendered[query.Name] = map[string]interface{}{
+			"query":            query.Query,
+			"primary":          query.PrimaryOnly,
+			"target_databases": databases,
+			"metrics":          metrics,
+		}
+	}
+	data, err := yaml.Marshal(rendered)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// reconcileCustomQueries rolls the custom queries of the cluster out to the CNPG exporter through
+// a ConfigMap. Invalid queries are not rolled out: the exporter keeps the last valid ones and the
+// CustomQueriesValid condition of the cluster reports the error.
+func (a *CNPGApplier) reconcileCustomQueries() error {
+	ctx := context.TODO()
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      customQueriesConfigMapName(a.cluster),
+			Namespace: a.cluster.Namespace,
+		},
+	}
+
+	config, err := monitoring.ResolveConfig(ctx, a.client, a.cluster)
+	if err != nil {
+		return a.referenceCustomQueries(ctx, configMap)
+	}
+	queries := monitoring.CustomQueries(a.cluster, config)
+	if len(queries) == 0 || a.cnpgCluster.Spec.Monitoring == nil {
+		return client.IgnoreNotFound(a.client.Delete(ctx, configMap))
+	}
+	if err := monitoring.ValidateCustomQueries(queries); err != nil {
+		return a.referenceCustomQueries(ctx, configMap)
+	}
+
+	rendered, err := renderCustomQueries(queries)
+	if err != nil {
+		return err
+	}
+	if _, err := controllerutil.CreateOrUpdate(ctx, a.client, configMap, func() error {
+		configMap.Labels = map[string]string{
+			"dbaas.io/cluster": a.cluster.Name,
+			"dbaas.io/engine":  a.cluster.Spec.Engine.Type,
+		}
+		configMap.Data = map[string]string{customQueriesKey: rendered}
+		return controllerutil.SetControllerReference(a.cluster, configMap, a.scheme)
+	}); err != nil {
+		return err
+	}
+	return a.referenceCustomQueries(ctx, configMap)
+}
+
+// referenceCustomQueries points the CNPG exporter at the custom queries ConfigMap when it exists
+func (a *CNPGApplier) referenceCustomQueries(ctx context.Context, configMap *corev1.ConfigMap) error {
+	if a.cnpgCluster.Spec.Monitoring == nil {
+		return nil
+	}
+	err := a.client.Get(ctx, types.NamespacedName{Name: configMap.Name, Namespace: configMap.Namespace}, configMap)
+	if errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	a.cnpgCluster.Spec.Monitoring.CustomQueriesConfigMap = []cnpgv1.ConfigMapKeySelector{
+		{LocalObjectReference: cnpgv1.LocalObjectReference{Name: configMap.Name}, Key: customQueriesKey},
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package cnpg
+
+import (
+	"context"
+	"time"
+
+	cnpgv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	dbaasv1 "github.com/huynt0812/dbaas-operator/api/v1"
+	"github.com/huynt0812/dbaas-operator/pkg/backupstorage"
+	"github.com/robfig/cron"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// clusterBackupStatus builds the backup status of a cluster, including the window
+// of points in time it can be restored to
+func (p *CNPGProvider) clusterBackupStatus(ctx context.Context, cluster *dbaasv1.DatabaseCluster, cnpgCluster *cnpgv1.Cluster) (*dbaasv1.BackupStatus, error) {
+	status := &dbaasv1.BackupStatus{
+		FirstRecoverabilityPoint: parseCNPGTime(cnpgCluster.Status.FirstRecoverabilityPoint),
+		LastBackupTime:           parseCNPGTime(cnpgCluster.Status.LastSuccessfulBackup),
+		LastFailedBackupTime:     parseCNPGTime(cnpgCluster.Status.LastFailedBackup),
+	}
+
+	if err := p.lastBackups(ctx, cluster, status); err != nil {
+		return nil, err
+	}
+
+	if schedule := cluster.Spec.Backup.Schedule; schedule != "" {
+		if sched, err := cron.ParseStandard(schedule); err == nil {
+			status.NextBackupTime = &metav1.Time{Time: sched.Next(time.Now())}
+		}
+	}
+
+	if condition := meta.FindStatusCondition(cnpgCluster.Status.Conditions, string(cnpgv1.ConditionContinuousArchiving)); condition != nil {
+		healthy := condition.Status == metav1.ConditionTrue
+		status.ArchivingHealthy = &healthy
+		if !healthy {
+			status.ArchivingMessage = condition.Message
+		}
+	}
+
+	if err := p.lastArchivedWAL(ctx, cluster, status); err != nil {
+		// The storage may be unreachable for a while, keep the last known WAL
+		log.FromContext(ctx).Error(err, "failed to find the last archived WAL file")
+		if previous := cluster.Status.Backup; previous != nil {
+			status.LastArchivedWAL = previous.LastArchivedWAL
+			status.LastArchivedWALTime = previous.LastArchivedWALTime
+		}
+	}
+
+	// Everything up to the end of the last archived WAL file or of the last backup can be recovered
+	status.LastRecoverabilityPoint = status.LastBackupTime
+	if status.LastArchivedWALTime != nil &&
+		(status.LastRecoverabilityPoint == nil || status.LastArchivedWALTime.After(status.LastRecoverabilityPoint.Time)) {
+		status.LastRecoverabilityPoint = status.LastArchivedWALTime
+	}
+	if status.FirstRecoverabilityPoint == nil {
+		status.LastRecoverabilityPoint = nil
+	}
+
+	return status, nil
+}
+
+// lastBackups records the latest succeeded and failed DatabaseBackups of the cluster,
+// covering the backups CNPG does not know about such as file-based ones
+func (p *CNPGProvider) lastBackups(ctx context.Context, cluster *dbaasv1.DatabaseCluster, status *dbaasv1.BackupStatus) error {
+	backups := &dbaasv1.DatabaseBackupList{}
+	if err := p.client.List(ctx, backups,
+		client.InNamespace(cluster.Namespace),
+		client.MatchingLabels{"dbaas.io/cluster": cluster.Name},
+	); err != nil {
+		return err
+	}
+
+	var oldest, succeeded, failed *dbaasv1.DatabaseBackup
+	for i := range backups.Items {
+		backup := &backups.Items[i]
+		if backup.Spec.ReadOnly || backup.Status.CompletedAt == nil {
+			continue
+		}
+		switch backup.Status.Phase {
+		case dbaasv1.DatabaseBackupPhaseSucceeded:
+			if succeeded == nil || backup.Status.CompletedAt.After(succeeded.Status.CompletedAt.Time) {
+				succeeded = backup
+			}
+			// Logical dumps cannot be rolled forward to a point in time
+			if backup.Spec.Method != dbaasv1.BackupMethodLogical &&
+				(oldest == nil || backup.Status.CompletedAt.Before(oldest.Status.CompletedAt)) {
+				oldest = backup
+			}
+		case dbaasv1.DatabaseBackupPhaseFailed:
+			if failed == nil || backup.Status.CompletedAt.After(failed.Status.CompletedAt.Time) {
+				failed = backup
+			}
+		}
+	}
+
+	if succeeded != nil {
+		status.LastBackupName = succeeded.Name
+		if status.LastBackupTime == nil || succeeded.Status.CompletedAt.After(status.LastBackupTime.Time) {
+			status.LastBackupTime = succeeded.Status.CompletedAt
+		}
+		// Without continuous archiving the oldest retained backup is the first recoverability point
+		if status.FirstRecoverabilityPoint == nil && oldest != nil {
+			status.FirstRecoverabilityPoint = oldest.Status.CompletedAt
+		}
+	}
+	if failed != nil {
+		status.LastFailedBackupName = failed.Name
+		if status.LastFailedBackupTime == nil || failed.Status.CompletedAt.After(status.LastFailedBackupTime.Time) {
+			status.LastFailedBackupTime = failed.Status.CompletedAt
+		}
+	}
+	return nil
+}
+
+// lastArchivedWAL looks up the last WAL file archived by the cluster in its object store
+func (p *CNPGProvider) lastArchivedWAL(ctx context.Context, cluster *dbaasv1.DatabaseCluster, status *dbaasv1.BackupStatus) error {
+	if cluster.Spec.Backup.BackupStorageRef == nil {
+		return nil
+	}
+	storage, err := getBackupStorage(ctx, p.client, cluster.Namespace, cluster.Spec.Backup.BackupStorageRef.Name)
+	if err != nil {
+		return err
+	}
+	if storage.Spec.Type != "s3" {
+		// File-based backups are self-contained and no WAL file is archived,
+		// the other object stores cannot be read by the operator yet
+		return nil
+	}
+
+	store, err := backupstorage.NewStore(ctx, p.client, storage)
+	if err != nil {
+		return err
+	}
+
+	previous := cluster.Status.Backup
+	after := ""
+	if previous != nil {
+		after = previous.LastArchivedWAL
+	}
+	wal, err := backupstorage.LastArchivedWAL(ctx, store, storage, archiveServerName(cluster), after)
+	if err != nil {
+		return err
+	}
+	if wal == nil {
+		// Nothing was archived since the last known WAL file
+		if after != "" {
+			status.LastArchivedWAL = previous.LastArchivedWAL
+			status.LastArchivedWALTime = previous.LastArchivedWALTime
+		}
+		return nil
+	}
+	status.LastArchivedWAL = wal.Name
+	status.LastArchivedWALTime = &metav1.Time{Time: wal.ArchivedAt}
+	return nil
+}
+
+// parseCNPGTime parses a timestamp reported in the CNPG Cluster status
+func parseCNPGTime(value string) *metav1.Time {
+	if value == "" {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil
+	}
+	return &metav1.Time{Time: t}
+}
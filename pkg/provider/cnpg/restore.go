@@ -0,0 +1,413 @@
+package cnpg
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	cnpgv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	dbaasv1 "github.com/huynt0812/dbaas-operator/api/v1"
+	snapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v7/apis/volumesnapshot/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// preRestoreSnapshotLabel labels the VolumeSnapshots taken before an in-place restore with its OpsRequest
+const preRestoreSnapshotLabel = "dbaas.io/pre-restore-of"
+
+// restoreToTarget creates a new DatabaseCluster with the spec of the cluster, bootstrapped from the backup,
+// and repoints the services of the cluster to it once it is ready when requested
+func (h *CNPGOperationsHandler) restoreToTarget(ctx context.Context, cluster *dbaasv1.DatabaseCluster, ops *dbaasv1.OpsRequest) error {
+	spec := ops.Spec.Restore.TargetCluster
+	if spec.Name == cluster.Name {
+		return fmt.Errorf("target cluster must differ from cluster %s", cluster.Name)
+	}
+
+	target := &dbaasv1.DatabaseCluster{}
+	err := h.client.Get(ctx, types.NamespacedName{Name: spec.Name, Namespace: cluster.Namespace}, target)
+	if errors.IsNotFound(err) {
+		target = &dbaasv1.DatabaseCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        spec.Name,
+				Namespace:   cluster.Namespace,
+				Labels:      cluster.Labels,
+				Annotations: map[string]string{restoreOpsAnnotation: ops.Name},
+			},
+			Spec: *cluster.Spec.DeepCopy(),
+		}
+		target.Spec.DataSource = &dbaasv1.DataSourceSpec{
+			BackupSource: &dbaasv1.BackupSourceSpec{
+				BackupName:  ops.Spec.Restore.BackupName,
+				PointInTime: ops.Spec.Restore.PointInTime,
+			},
+		}
+		return h.client.Create(ctx, target)
+	} else if err != nil {
+		return err
+	}
+
+	if target.Annotations[restoreOpsAnnotation] != ops.Name {
+		return fmt.Errorf("cluster %s already exists", spec.Name)
+	}
+	if !spec.SwapEndpoints || target.Status.Phase != dbaasv1.ClusterPhaseReady {
+		return nil
+	}
+	return h.swapEndpoints(ctx, cluster, target)
+}
+
+// swapEndpoints repoints the endpoint services of the cluster to the instances of the target.
+// Only services owned by the DatabaseCluster are changed, the CNPG services of both clusters are left
+// alone. Deleting an endpoint service lets the cluster recreate it pointing to itself: this is how
+// the swap is rolled back, by hand or when the target is deleted.
+func (h *CNPGOperationsHandler) swapEndpoints(ctx context.Context, cluster, target *dbaasv1.DatabaseCluster) error {
+	for _, suffix := range serviceSuffixes {
+		service := &corev1.Service{}
+		if err := h.client.Get(ctx, types.NamespacedName{Name: endpointServiceName(cluster.Name, suffix), Namespace: cluster.Namespace}, service); err != nil {
+			return err
+		}
+		if service.Labels[endpointSwapLabel] == target.Name {
+			continue
+		}
+
+		if service.Labels == nil {
+			service.Labels = make(map[string]string)
+		}
+		service.Labels[endpointSwapLabel] = target.Name
+		service.Spec.Selector = endpointSelector(target.Name, suffix)
+		if err := h.client.Update(ctx, service); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// handOverEndpoints keeps the endpoint services of a cluster being deleted that were repointed to the
+// target of an out-of-place restore: they are owned by the target instead, so that clients keep reaching it
+func handOverEndpoints(ctx context.Context, c client.Client, scheme *runtime.Scheme, cluster *dbaasv1.DatabaseCluster) error {
+	for _, suffix := range serviceSuffixes {
+		service := &corev1.Service{}
+		if err := c.Get(ctx, types.NamespacedName{Name: endpointServiceName(cluster.Name, suffix), Namespace: cluster.Namespace}, service); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+		targetName := service.Labels[endpointSwapLabel]
+		if targetName == "" {
+			continue
+		}
+
+		target := &dbaasv1.DatabaseCluster{}
+		if err := c.Get(ctx, types.NamespacedName{Name: targetName, Namespace: cluster.Namespace}, target); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+		if !target.DeletionTimestamp.IsZero() || metav1.IsControlledBy(service, target) {
+			continue
+		}
+
+		service.OwnerReferences = nil
+		if err := controllerutil.SetControllerReference(target, service, scheme); err != nil {
+			return err
+		}
+		if err := c.Update(ctx, service); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rollBackEndpoints points the endpoint services repointed to a cluster being deleted back to the
+// cluster they belong to. Services handed over to the deleted cluster go with it.
+func rollBackEndpoints(ctx context.Context, c client.Client, target *dbaasv1.DatabaseCluster) error {
+	services := &corev1.ServiceList{}
+	if err := c.List(ctx, services, client.InNamespace(target.Namespace), client.MatchingLabels{endpointSwapLabel: target.Name}); err != nil {
+		return err
+	}
+	for i := range services.Items {
+		service := &services.Items[i]
+		owner := service.Labels[endpointLabel]
+		if owner == "" || metav1.IsControlledBy(service, target) {
+			continue
+		}
+
+		suffix := ""
+		for _, candidate := range serviceSuffixes {
+			if service.Name == endpointServiceName(owner, candidate) {
+				suffix = candidate
+			}
+		}
+		if suffix == "" {
+			continue
+		}
+		delete(service.Labels, endpointSwapLabel)
+		service.Spec.Selector = endpointSelector(owner, suffix)
+		if err := c.Update(ctx, service); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// targetRestoreStatus follows the new cluster of an out-of-place restore, and the endpoint swap when requested
+func (h *CNPGOperationsHandler) targetRestoreStatus(ctx context.Context, cluster *dbaasv1.DatabaseCluster, ops *dbaasv1.OpsRequest, status *dbaasv1.OpsRequestStatus) (*dbaasv1.OpsRequestStatus, error) {
+	spec := ops.Spec.Restore.TargetCluster
+
+	target := &dbaasv1.DatabaseCluster{}
+	if err := h.client.Get(ctx, types.NamespacedName{Name: spec.Name, Namespace: cluster.Namespace}, target); err != nil {
+		if errors.IsNotFound(err) {
+			status.Message = fmt.Sprintf("waiting for cluster %s to be created", spec.Name)
+			return status, nil
+		}
+		return nil, err
+	}
+
+	message := fmt.Sprintf("cluster %s is %s", target.Name, target.Status.Phase)
+	if target.Status.Phase == dbaasv1.ClusterPhaseReady {
+		status.Phase = dbaasv1.OpsRequestPhaseSucceeded
+		if spec.SwapEndpoints {
+			swapped, err := h.endpointsSwapped(ctx, cluster, target)
+			if err != nil {
+				return nil, err
+			}
+			if swapped {
+				message = fmt.Sprintf("services of cluster %s point to cluster %s", cluster.Name, target.Name)
+			} else {
+				status.Phase = dbaasv1.OpsRequestPhaseRunning
+				message = fmt.Sprintf("repointing the services of cluster %s to cluster %s", cluster.Name, target.Name)
+			}
+		}
+		if status.Phase == dbaasv1.OpsRequestPhaseSucceeded {
+			status.CompletionTime = &metav1.Time{Time: time.Now()}
+		}
+	}
+
+	status.Message = message
+	status.ActionLog = []dbaasv1.ActionLogEntry{
+		{
+			Timestamp: metav1.Time{Time: time.Now()},
+			Action:    string(ops.Spec.Type),
+			Status:    string(status.Phase),
+			Message:   message,
+		},
+	}
+	return status, nil
+}
+
+// endpointsSwapped reports whether all services of the cluster point to the target
+func (h *CNPGOperationsHandler) endpointsSwapped(ctx context.Context, cluster, target *dbaasv1.DatabaseCluster) (bool, error) {
+	for _, suffix := range serviceSuffixes {
+		service := &corev1.Service{}
+		if err := h.client.Get(ctx, types.NamespacedName{Name: endpointServiceName(cluster.Name, suffix), Namespace: cluster.Namespace}, service); err != nil {
+			if errors.IsNotFound(err) {
+				return false, nil
+			}
+			return false, err
+		}
+		if service.Labels[endpointSwapLabel] != target.Name {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// logicalRestore loads a logical backup into the running cluster with a restore Job
+func (h *CNPGOperationsHandler) logicalRestore(ctx context.Context, destination *dbaasv1.DatabaseCluster, ops *dbaasv1.OpsRequest, backup *dbaasv1.DatabaseBackup) error {
+	job, err := logicalRestoreJob(ctx, h.client, destination, backup, ops)
+	if err != nil {
+		return err
+	}
+	return client.IgnoreAlreadyExists(h.client.Create(ctx, job))
+}
+
+// logicalRestoreToTarget creates an empty DatabaseCluster with the spec of the cluster, loads the logical backup
+// into it once it is ready, and repoints the services of the cluster to it after the load when requested
+func (h *CNPGOperationsHandler) logicalRestoreToTarget(ctx context.Context, cluster *dbaasv1.DatabaseCluster, ops *dbaasv1.OpsRequest, backup *dbaasv1.DatabaseBackup) error {
+	spec := ops.Spec.Restore.TargetCluster
+	if spec.Name == cluster.Name {
+		return fmt.Errorf("target cluster must differ from cluster %s", cluster.Name)
+	}
+
+	target := &dbaasv1.DatabaseCluster{}
+	err := h.client.Get(ctx, types.NamespacedName{Name: spec.Name, Namespace: cluster.Namespace}, target)
+	if errors.IsNotFound(err) {
+		target = &dbaasv1.DatabaseCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        spec.Name,
+				Namespace:   cluster.Namespace,
+				Labels:      cluster.Labels,
+				Annotations: map[string]string{restoreOpsAnnotation: ops.Name},
+			},
+			Spec: *cluster.Spec.DeepCopy(),
+		}
+		target.Spec.DataSource = nil
+		return h.client.Create(ctx, target)
+	} else if err != nil {
+		return err
+	}
+
+	if target.Annotations[restoreOpsAnnotation] != ops.Name {
+		return fmt.Errorf("cluster %s already exists", spec.Name)
+	}
+	if target.Status.Phase != dbaasv1.ClusterPhaseReady {
+		return nil
+	}
+	if err := h.logicalRestore(ctx, target, ops, backup); err != nil {
+		return err
+	}
+
+	job := &batchv1.Job{}
+	if err := h.client.Get(ctx, types.NamespacedName{Name: logicalRestoreJobName(ops), Namespace: target.Namespace}, job); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	_, finished, failed, err := jobTerminationMessage(ctx, h.client, job)
+	if err != nil || !finished || failed || !spec.SwapEndpoints {
+		return err
+	}
+	return h.swapEndpoints(ctx, cluster, target)
+}
+
+// logicalRestoreStatus follows the restore Job of a logical restore, and the endpoint swap when requested
+func (h *CNPGOperationsHandler) logicalRestoreStatus(ctx context.Context, cluster *dbaasv1.DatabaseCluster, ops *dbaasv1.OpsRequest, status *dbaasv1.OpsRequestStatus) (*dbaasv1.OpsRequestStatus, error) {
+	spec := ops.Spec.Restore.TargetCluster
+	destination := cluster
+	if spec != nil {
+		destination = &dbaasv1.DatabaseCluster{}
+		if err := h.client.Get(ctx, types.NamespacedName{Name: spec.Name, Namespace: cluster.Namespace}, destination); err != nil {
+			if errors.IsNotFound(err) {
+				status.Message = fmt.Sprintf("waiting for cluster %s to be created", spec.Name)
+				return status, nil
+			}
+			return nil, err
+		}
+	}
+
+	job := &batchv1.Job{}
+	err := h.client.Get(ctx, types.NamespacedName{Name: logicalRestoreJobName(ops), Namespace: destination.Namespace}, job)
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, err
+	}
+	message := fmt.Sprintf("waiting for cluster %s to be ready", destination.Name)
+	if err == nil {
+		jobMessage, finished, failed, err := jobTerminationMessage(ctx, h.client, job)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case failed:
+			status.Phase = dbaasv1.OpsRequestPhaseFailed
+			message = fmt.Sprintf("restore of backup %s into cluster %s failed: %s", ops.Spec.Restore.BackupName, destination.Name, jobMessage)
+		case finished:
+			status.Phase = dbaasv1.OpsRequestPhaseSucceeded
+			message = fmt.Sprintf("backup %s is restored into cluster %s", ops.Spec.Restore.BackupName, destination.Name)
+			if spec != nil && spec.SwapEndpoints {
+				swapped, err := h.endpointsSwapped(ctx, cluster, destination)
+				if err != nil {
+					return nil, err
+				}
+				if !swapped {
+					status.Phase = dbaasv1.OpsRequestPhaseRunning
+					message = fmt.Sprintf("repointing the services of cluster %s to cluster %s", cluster.Name, destination.Name)
+				}
+			}
+		default:
+			message = fmt.Sprintf("restoring backup %s into cluster %s", ops.Spec.Restore.BackupName, destination.Name)
+		}
+	}
+	if status.Phase == dbaasv1.OpsRequestPhaseSucceeded || status.Phase == dbaasv1.OpsRequestPhaseFailed {
+		status.CompletionTime = &metav1.Time{Time: time.Now()}
+	}
+
+	status.Message = message
+	status.ActionLog = []dbaasv1.ActionLogEntry{
+		{
+			Timestamp: metav1.Time{Time: time.Now()},
+			Action:    string(ops.Spec.Type),
+			Status:    string(status.Phase),
+			Message:   message,
+		},
+	}
+	return status, nil
+}
+
+// snapshotVolumes snapshots the volumes of the cluster before an in-place restore deletes them,
+// reporting whether all snapshots are ready to use. The snapshots are owned by the DatabaseCluster.
+func (h *CNPGOperationsHandler) snapshotVolumes(ctx context.Context, cluster *dbaasv1.DatabaseCluster, ops *dbaasv1.OpsRequest) (bool, error) {
+	pvcs := &corev1.PersistentVolumeClaimList{}
+	if err := h.client.List(ctx, pvcs, client.InNamespace(cluster.Namespace), client.MatchingLabels{"cnpg.io/cluster": cluster.Name}); err != nil {
+		return false, err
+	}
+
+	// Use the classes of volume snapshot backups, the default class otherwise
+	var dataClass, walClass *string
+	if cluster.Spec.Backup != nil && cluster.Spec.Backup.VolumeSnapshot != nil {
+		dataClass = &cluster.Spec.Backup.VolumeSnapshot.ClassName
+		walClass = dataClass
+		if cluster.Spec.Backup.VolumeSnapshot.WALClassName != "" {
+			walClass = &cluster.Spec.Backup.VolumeSnapshot.WALClassName
+		}
+	}
+
+	ready := true
+	for i := range pvcs.Items {
+		pvc := &pvcs.Items[i]
+		snapshot := &snapshotv1.VolumeSnapshot{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("%s-%s", pvc.Name, ops.Name), Namespace: cluster.Namespace},
+		}
+		_, err := controllerutil.CreateOrUpdate(ctx, h.client, snapshot, func() error {
+			if snapshot.Labels == nil {
+				snapshot.Labels = make(map[string]string)
+			}
+			snapshot.Labels["dbaas.io/cluster"] = cluster.Name
+			snapshot.Labels[preRestoreSnapshotLabel] = ops.Name
+			// The source of a snapshot is immutable
+			if snapshot.CreationTimestamp.IsZero() {
+				claimName := pvc.Name
+				snapshot.Spec.Source.PersistentVolumeClaimName = &claimName
+				snapshot.Spec.VolumeSnapshotClassName = dataClass
+				if pvc.Labels["cnpg.io/pvcRole"] == "PG_WAL" {
+					snapshot.Spec.VolumeSnapshotClassName = walClass
+				}
+			}
+			return controllerutil.SetControllerReference(cluster, snapshot, h.scheme)
+		})
+		if err != nil {
+			return false, err
+		}
+
+		if snapshot.Status != nil && snapshot.Status.Error != nil && snapshot.Status.Error.Message != nil {
+			return false, fmt.Errorf("failed to snapshot volume %s before the restore: %s", pvc.Name, *snapshot.Status.Error.Message)
+		}
+		if snapshot.Status == nil || snapshot.Status.ReadyToUse == nil || !*snapshot.Status.ReadyToUse {
+			ready = false
+		}
+	}
+	return ready, nil
+}
+
+// deleteReplacedCluster deletes the CNPG Cluster an in-place restore replaces, so that the
+// DatabaseCluster reconcile recreates it from the backup. A cluster created since is the restored one.
+func (h *CNPGOperationsHandler) deleteReplacedCluster(ctx context.Context, cluster *dbaasv1.DatabaseCluster, restore *dbaasv1.RestoreStatus) error {
+	cnpgCluster := &cnpgv1.Cluster{}
+	if err := h.client.Get(ctx, types.NamespacedName{Name: cluster.Name, Namespace: cluster.Namespace}, cnpgCluster); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if cnpgCluster.DeletionTimestamp != nil || !cnpgCluster.CreationTimestamp.Before(&restore.StartedAt) {
+		return nil
+	}
+	return client.IgnoreNotFound(h.client.Delete(ctx, cnpgCluster))
+}
+
+// deleteVolumeSnapshots deletes the snapshots taken before an in-place restore once the restored cluster is ready
+func (h *CNPGOperationsHandler) deleteVolumeSnapshots(ctx context.Context, cluster *dbaasv1.DatabaseCluster, ops *dbaasv1.OpsRequest) error {
+	return h.client.DeleteAllOf(ctx, &snapshotv1.VolumeSnapshot{}, client.InNamespace(cluster.Namespace),
+		client.MatchingLabels{"dbaas.io/cluster": cluster.Name, preRestoreSnapshotLabel: ops.Name})
+}
@@ -0,0 +1,136 @@
+package cnpg
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	dbaasv1 "github.com/huynt0812/dbaas-operator/api/v1"
+)
+
+// swapFixture holds a cluster whose endpoint services were swapped to the target of an out-of-place restore
+type swapFixture struct {
+	client  client.Client
+	scheme  *runtime.Scheme
+	cluster *dbaasv1.DatabaseCluster
+	target  *dbaasv1.DatabaseCluster
+}
+
+// newSwapFixture creates the endpoint services of cluster db, an unrelated service, and swaps the
+// endpoint services to cluster db-restored
+func newSwapFixture(t *testing.T) *swapFixture {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := dbaasv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	cluster := &dbaasv1.DatabaseCluster{ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "prod", UID: "db-uid"}}
+	target := &dbaasv1.DatabaseCluster{ObjectMeta: metav1.ObjectMeta{Name: "db-restored", Namespace: "prod", UID: "db-restored-uid"}}
+	other := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "db-rw", Namespace: "prod"},
+		Spec:       corev1.ServiceSpec{Selector: map[string]string{"cnpg.io/cluster": "db", "role": "primary"}},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(cluster, target, other).Build()
+
+	if err := NewApplier(cluster, c, scheme).reconcileEndpoints(); err != nil {
+		t.Fatalf("reconcileEndpoints() error = %v", err)
+	}
+	if err := NewOperationsHandler(c, scheme).swapEndpoints(context.Background(), cluster, target); err != nil {
+		t.Fatalf("swapEndpoints() error = %v", err)
+	}
+	return &swapFixture{client: c, scheme: scheme, cluster: cluster, target: target}
+}
+
+// endpoint returns the endpoint service of cluster db with the given suffix
+func (f *swapFixture) endpoint(t *testing.T, suffix string) *corev1.Service {
+	t.Helper()
+	service := &corev1.Service{}
+	if err := f.client.Get(context.Background(), types.NamespacedName{Name: endpointServiceName("db", suffix), Namespace: "prod"}, service); err != nil {
+		t.Fatalf("failed to get endpoint service %s: %v", suffix, err)
+	}
+	return service
+}
+
+func TestSwapEndpointsSurvivesReconcile(t *testing.T) {
+	f := newSwapFixture(t)
+
+	// The cluster keeps reconciling its endpoint services while they are swapped
+	if err := NewApplier(f.cluster, f.client, f.scheme).reconcileEndpoints(); err != nil {
+		t.Fatalf("reconcileEndpoints() error = %v", err)
+	}
+	for _, suffix := range serviceSuffixes {
+		service := f.endpoint(t, suffix)
+		if got := service.Spec.Selector["cnpg.io/cluster"]; got != "db-restored" {
+			t.Errorf("%s selects cluster %s, want db-restored", service.Name, got)
+		}
+		if got := service.Labels[endpointSwapLabel]; got != "db-restored" {
+			t.Errorf("%s is swapped to %q, want db-restored", service.Name, got)
+		}
+	}
+}
+
+func TestRollBackEndpoints(t *testing.T) {
+	f := newSwapFixture(t)
+
+	if err := rollBackEndpoints(context.Background(), f.client, f.target); err != nil {
+		t.Fatalf("rollBackEndpoints() error = %v", err)
+	}
+	for _, suffix := range serviceSuffixes {
+		service := f.endpoint(t, suffix)
+		want := endpointSelector("db", suffix)
+		for k, v := range want {
+			if service.Spec.Selector[k] != v {
+				t.Errorf("%s selector %v, want %v", service.Name, service.Spec.Selector, want)
+				break
+			}
+		}
+		if _, ok := service.Labels[endpointSwapLabel]; ok {
+			t.Errorf("%s is still labelled as swapped", service.Name)
+		}
+		if !metav1.IsControlledBy(service, f.cluster) {
+			t.Errorf("%s is not owned by cluster db", service.Name)
+		}
+	}
+
+	// Services the operator does not own are left alone
+	other := &corev1.Service{}
+	if err := f.client.Get(context.Background(), types.NamespacedName{Name: "db-rw", Namespace: "prod"}, other); err != nil {
+		t.Fatalf("CNPG service was removed: %v", err)
+	}
+	if other.Spec.Selector["cnpg.io/cluster"] != "db" || other.Labels[endpointSwapLabel] != "" {
+		t.Errorf("CNPG service was changed: %+v", other)
+	}
+}
+
+func TestRollBackEndpointsAfterHandOver(t *testing.T) {
+	f := newSwapFixture(t)
+
+	// Deleting the original cluster hands its swapped services over to the target
+	if err := handOverEndpoints(context.Background(), f.client, f.scheme, f.cluster); err != nil {
+		t.Fatalf("handOverEndpoints() error = %v", err)
+	}
+	// Deleting the target afterwards leaves them to be garbage collected with it
+	if err := rollBackEndpoints(context.Background(), f.client, f.target); err != nil {
+		t.Fatalf("rollBackEndpoints() error = %v", err)
+	}
+	for _, suffix := range serviceSuffixes {
+		service := f.endpoint(t, suffix)
+		if !metav1.IsControlledBy(service, f.target) {
+			t.Errorf("%s is not owned by cluster db-restored", service.Name)
+		}
+		if got := service.Spec.Selector["cnpg.io/cluster"]; got != "db-restored" {
+			t.Errorf("%s selects cluster %s, want db-restored", service.Name, got)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	dbaasv1 "github.com/huynt0812/dbaas-operator/api/v1"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -25,6 +26,22 @@ type Provider interface {
 
 	// Operations returns the operations handler for this provider
 	Operations() OperationsHandler
+
+	// ClientContainer returns a container with the engine client tools,
+	// configured through its environment to connect to the cluster
+	ClientContainer(cluster *dbaasv1.DatabaseCluster) (*corev1.Container, error)
+
+	// StatsClientContainer returns a container like ClientContainer, connected as a
+	// user allowed to read the statement statistics of every user of the cluster
+	StatsClientContainer(cluster *dbaasv1.DatabaseCluster) (*corev1.Container, error)
+
+	// QueryCommand returns a shell command that runs the SQL held in the given
+	// environment variable and prints the result without decoration
+	QueryCommand(sqlEnvVar string) string
+
+	// TopStatementsSQL returns the SQL printing the slowest statements of the cluster,
+	// at most limit of them, as one JSON monitoring.Statement per line
+	TopStatementsSQL(limit int32) (string, error)
 }
 
 // Applier defines the interface for building child cluster specifications
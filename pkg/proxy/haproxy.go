@@ -0,0 +1,111 @@
+package proxy
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	dbaasv1 "github.com/huynt0812/dbaas-operator/api/v1"
+)
+
+const (
+	// haproxyImage is the HAProxy image of the proxy instances
+	haproxyImage = "haproxy:2.8"
+
+	// haproxyConfigKey is the key of the configuration in its ConfigMap
+	haproxyConfigKey = "haproxy.cfg"
+
+	// haproxyDefaultMaxConn is the client connection limit when the spec sets none
+	haproxyDefaultMaxConn = 2000
+)
+
+// buildHAProxy renders the HAProxy instances of the proxy spec: the read-write one routing the
+// connections to the primary and, when the spec asks for it, the read-only one balancing them
+// over the replicas with the primary as fallback
+func buildHAProxy(spec *dbaasv1.ProxySpec, backend Backend) (*Resources, error) {
+	resources := &Resources{}
+	roles := []struct {
+		role string
+		spec *dbaasv1.ProxyInstanceSpec
+	}{
+		{RoleReadWrite, &spec.ProxyInstanceSpec},
+		{RoleReadOnly, spec.ReadOnly},
+	}
+	for _, r := range roles {
+		if r.spec == nil {
+			continue
+		}
+		instance{
+			cluster:   backend.Cluster,
+			proxyType: TypeHAProxy,
+			role:      r.role,
+			spec:      r.spec,
+			configKey: haproxyConfigKey,
+			config:    haproxyConfig(r.role, r.spec, backend),
+			container: corev1.Container{
+				Name:  "haproxy",
+				Image: haproxyImage,
+				Args:  []string{"-f", fmt.Sprintf("/etc/%s-config/%s", TypeHAProxy, haproxyConfigKey)},
+			},
+			port:        backend.Port,
+			servicePort: backend.Port,
+		}.build(resources)
+	}
+	return resources, nil
+}
+
+// haproxyConfig renders the TCP configuration of an HAProxy instance. The parameters of the spec
+// override the keywords of the defaults section.
+func haproxyConfig(role string, spec *dbaasv1.ProxyInstanceSpec, backend Backend) string {
+	maxConn := haproxyDefaultMaxConn
+	if spec.MaxClientConnections != nil {
+		maxConn = int(*spec.MaxClientConnections)
+	}
+	serverOptions := "check"
+	if spec.DefaultPoolSize != nil {
+		serverOptions = fmt.Sprintf("check maxconn %d", *spec.DefaultPoolSize)
+	}
+
+	defaults := map[string]string{
+		"timeout connect": "5s",
+		"timeout client":  "1h",
+		"timeout server":  "1h",
+		"retries":         "3",
+	}
+	for k, v := range spec.Parameters {
+		defaults[k] = v
+	}
+
+	var b strings.Builder
+	b.WriteString("global\n")
+	fmt.Fprintf(&b, "    maxconn %d\n", maxConn)
+	b.WriteString("    log stdout format raw local0\n\n")
+
+	b.WriteString("defaults\n")
+	b.WriteString("    mode tcp\n")
+	b.WriteString("    log global\n")
+	b.WriteString("    option tcplog\n")
+	for _, k := range sortedKeys(defaults) {
+		fmt.Fprintf(&b, "    %s %s\n", k, defaults[k])
+	}
+	b.WriteString("\n")
+
+	b.WriteString("frontend db\n")
+	fmt.Fprintf(&b, "    bind :%d\n", backend.Port)
+	b.WriteString("    default_backend db\n\n")
+
+	b.WriteString("backend db\n")
+	b.WriteString("    option tcp-check\n")
+	if role == RoleReadOnly && len(backend.Replicas) > 0 {
+		b.WriteString("    balance leastconn\n")
+		for i, replica := range backend.Replicas {
+			fmt.Fprintf(&b, "    server replica-%d %s:%d %s\n", i, replica, backend.Port, serverOptions)
+		}
+		fmt.Fprintf(&b, "    server primary %s:%d %s backup\n", backend.Primary, backend.Port, serverOptions)
+	} else {
+		b.WriteString("    balance first\n")
+		fmt.Fprintf(&b, "    server primary %s:%d %s\n", backend.Primary, backend.Port, serverOptions)
+	}
+	return b.String()
+}
@@ -0,0 +1,127 @@
+package proxy
+
+import (
+	"testing"
+
+	dbaasv1 "github.com/huynt0812/dbaas-operator/api/v1"
+)
+
+func TestHAProxyConfig(t *testing.T) {
+	maxConn, poolSize := int32(500), int32(50)
+
+	tests := []struct {
+		name string
+		role string
+		spec *dbaasv1.ProxyInstanceSpec
+		want string
+	}{
+		{
+			name: "read-write defaults",
+			role: RoleReadWrite,
+			spec: &dbaasv1.ProxyInstanceSpec{},
+			want: `global
+    maxconn 2000
+    log stdout format raw local0
+
+defaults
+    mode tcp
+    log global
+    option tcplog
+    retries 3
+    timeout client 1h
+    timeout connect 5s
+    timeout server 1h
+
+frontend db
+    bind :3306
+    default_backend db
+
+backend db
+    option tcp-check
+    balance first
+    server primary db-primary:3306 check
+`,
+		},
+		{
+			name: "read-write with limits and parameters",
+			role: RoleReadWrite,
+			spec: &dbaasv1.ProxyInstanceSpec{
+				MaxClientConnections: &maxConn,
+				DefaultPoolSize:      &poolSize,
+				Parameters:           map[string]string{"timeout client": "30m", "timeout check": "2s"},
+			},
+			want: `global
+    maxconn 500
+    log stdout format raw local0
+
+defaults
+    mode tcp
+    log global
+    option tcplog
+    retries 3
+    timeout check 2s
+    timeout client 30m
+    timeout connect 5s
+    timeout server 1h
+
+frontend db
+    bind :3306
+    default_backend db
+
+backend db
+    option tcp-check
+    balance first
+    server primary db-primary:3306 check maxconn 50
+`,
+		},
+		{
+			name: "read-only over the replicas",
+			role: RoleReadOnly,
+			spec: &dbaasv1.ProxyInstanceSpec{DefaultPoolSize: &poolSize},
+			want: `global
+    maxconn 2000
+    log stdout format raw local0
+
+defaults
+    mode tcp
+    log global
+    option tcplog
+    retries 3
+    timeout client 1h
+    timeout connect 5s
+    timeout server 1h
+
+frontend db
+    bind :3306
+    default_backend db
+
+backend db
+    option tcp-check
+    balance leastconn
+    server replica-0 db-replica-0:3306 check maxconn 50
+    server replica-1 db-replica-1:3306 check maxconn 50
+    server primary db-primary:3306 check maxconn 50 backup
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := haproxyConfig(tt.role, tt.spec, testBackend()); got != tt.want {
+				t.Errorf("haproxyConfig() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHAProxyConfigReadOnlyWithoutReplicas(t *testing.T) {
+	backend := testBackend()
+	backend.Replicas = nil
+
+	// Without replicas the read-only instance falls back to the primary
+	got := haproxyConfig(RoleReadOnly, &dbaasv1.ProxyInstanceSpec{}, backend)
+	want := haproxyConfig(RoleReadWrite, &dbaasv1.ProxyInstanceSpec{}, backend)
+	if got != want {
+		t.Errorf("haproxyConfig() =\n%s\nwant\n%s", got, want)
+	}
+}
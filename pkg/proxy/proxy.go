@@ -0,0 +1,284 @@
+package proxy
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	dbaasv1 "github.com/huynt0812/dbaas-operator/api/v1"
+)
+
+// Proxy types
+const (
+	TypePgBouncer = "pgbouncer"
+	TypeHAProxy   = "haproxy"
+	TypeProxySQL  = "proxysql"
+)
+
+// Proxy roles, the instances of the proxy spec
+const (
+	RoleReadWrite = "rw"
+	RoleReadOnly  = "ro"
+)
+
+const (
+	// configChecksumAnnotation rolls the proxy pods out when their configuration changes
+	configChecksumAnnotation = "dbaas.io/config-checksum"
+
+	// configVolume is the volume of the configuration ConfigMap
+	configVolume = "config"
+)
+
+// engineProxies lists the proxy types of each engine, the first one being the default
+var engineProxies = map[string][]string{
+	"postgresql": {TypePgBouncer},
+	"mysql":      {TypeHAProxy, TypeProxySQL},
+}
+
+// Backend is the database cluster behind the proxy, as its provider exposes it
+type Backend struct {
+	// Cluster is the database cluster
+	Cluster *dbaasv1.DatabaseCluster
+
+	// Primary is the host of the primary, typically a service following it
+	Primary string
+
+	// Replicas are the hosts of the replicas
+	Replicas []string
+
+	// Port is the port of the database servers
+	Port int32
+
+	// Users are the database users the proxy authenticates, for the proxies that need them
+	Users []User
+}
+
+// User is a database user held in a secret
+type User struct {
+	// SecretName is the name of the secret
+	SecretName string
+
+	// UsernameKey and PasswordKey are the keys of the credentials in the secret
+	UsernameKey string
+	PasswordKey string
+
+	// Version is the resource version of the secret, rolling the proxy out when it changes
+	Version string
+}
+
+// Resources are the objects running a proxy. The caller sets their owner and applies them.
+type Resources struct {
+	Deployments []*appsv1.Deployment
+	ConfigMaps  []*corev1.ConfigMap
+	Services    []*corev1.Service
+}
+
+// Type returns the proxy type of the spec, the default one of the engine when unset
+func Type(engine string, spec *dbaasv1.ProxySpec) string {
+	if spec.Type != "" {
+		return spec.Type
+	}
+	if types := engineProxies[engine]; len(types) > 0 {
+		return types[0]
+	}
+	return ""
+}
+
+// Validate checks that the proxy spec is usable with the engine
+func Validate(engine string, spec *dbaasv1.ProxySpec) error {
+	if spec == nil || !spec.Enabled {
+		return nil
+	}
+	types := engineProxies[engine]
+	if len(types) == 0 {
+		return fmt.Errorf("engine %s does not support proxies", engine)
+	}
+	proxyType := Type(engine, spec)
+	supported := false
+	for _, t := range types {
+		supported = supported || t == proxyType
+	}
+	if !supported {
+		return fmt.Errorf("proxy type %s is not supported for engine %s, use one of: %s", proxyType, engine, strings.Join(types, ", "))
+	}
+
+	instances := []*dbaasv1.ProxyInstanceSpec{&spec.ProxyInstanceSpec}
+	if spec.ReadOnly != nil {
+		instances = append(instances, spec.ReadOnly)
+	}
+	for _, instance := range instances {
+		if instance.PoolMode != "" && proxyType != TypePgBouncer {
+			return fmt.Errorf("pool mode is only supported by pgbouncer")
+		}
+	}
+	if spec.ReadOnly != nil && proxyType == TypeProxySQL {
+		return fmt.Errorf("proxysql routes reads through its query rules, it has no read-only instance")
+	}
+	if len(spec.QueryRules) > 0 && proxyType != TypeProxySQL {
+		return fmt.Errorf("query rules are only supported by proxysql")
+	}
+	for _, rule := range spec.QueryRules {
+		if _, err := regexp.Compile(rule.Pattern); err != nil {
+			return fmt.Errorf("invalid query rule pattern %q: %w", rule.Pattern, err)
+		}
+		if rule.Destination != "primary" && rule.Destination != "replica" {
+			return fmt.Errorf("invalid query rule destination %q", rule.Destination)
+		}
+	}
+	return nil
+}
+
+// Build renders the objects running the proxy of the cluster in front of the backend. Proxies
+// managed by the engine operator, such as the PgBouncer Poolers of CNPG, are not built here.
+func Build(spec *dbaasv1.ProxySpec, backend Backend) (*Resources, error) {
+	engine := backend.Cluster.Spec.Engine.Type
+	if err := Validate(engine, spec); err != nil {
+		return nil, err
+	}
+	if backend.Primary == "" || backend.Port == 0 {
+		return nil, fmt.Errorf("proxy backend has no primary")
+	}
+
+	switch proxyType := Type(engine, spec); proxyType {
+	case TypeHAProxy:
+		return buildHAProxy(spec, backend)
+	case TypeProxySQL:
+		return buildProxySQL(spec, backend)
+	default:
+		return nil, fmt.Errorf("proxy type %s is managed by the engine operator", proxyType)
+	}
+}
+
+// Name returns the name of the objects of a proxy instance
+func Name(cluster *dbaasv1.DatabaseCluster, proxyType, role string) string {
+	return fmt.Sprintf("%s-%s-%s", cluster.Name, proxyType, role)
+}
+
+// labels returns the labels of the objects of a proxy instance, selecting its pods
+func labels(cluster *dbaasv1.DatabaseCluster, proxyType, role string) map[string]string {
+	return map[string]string{
+		"dbaas.io/cluster":    cluster.Name,
+		"dbaas.io/engine":     cluster.Spec.Engine.Type,
+		"dbaas.io/proxy":      proxyType,
+		"dbaas.io/proxy-role": role,
+	}
+}
+
+// checksum hashes the configuration of a proxy instance and the versions of the secrets it reads
+func checksum(config string, users []User) string {
+	h := sha256.New()
+	h.Write([]byte(config))
+	for _, user := range users {
+		fmt.Fprintf(h, "\x00%s/%s", user.SecretName, user.Version)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// sortedKeys returns the keys of a map in order, for stable configurations
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// instance holds what a proxy instance is built from
+type instance struct {
+	cluster   *dbaasv1.DatabaseCluster
+	proxyType string
+	role      string
+	spec      *dbaasv1.ProxyInstanceSpec
+	configKey string
+	config    string
+	users     []User
+	container corev1.Container
+
+	// port is the port the proxy listens on, servicePort the port of its Service
+	port        int32
+	servicePort int32
+}
+
+// build renders the ConfigMap, Deployment and Service of a proxy instance. The configuration is
+// mounted in /etc/<proxy type>-config.
+func (i instance) build(resources *Resources) {
+	name := Name(i.cluster, i.proxyType, i.role)
+	podLabels := labels(i.cluster, i.proxyType, i.role)
+	meta := func() metav1.ObjectMeta {
+		return metav1.ObjectMeta{Name: name, Namespace: i.cluster.Namespace, Labels: labels(i.cluster, i.proxyType, i.role)}
+	}
+
+	resources.ConfigMaps = append(resources.ConfigMaps, &corev1.ConfigMap{
+		ObjectMeta: meta(),
+		Data:       map[string]string{i.configKey: i.config},
+	})
+
+	container := i.container
+	container.Resources = i.spec.Resources
+	container.Ports = []corev1.ContainerPort{{Name: "db", ContainerPort: i.port, Protocol: corev1.ProtocolTCP}}
+	container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+		Name:      configVolume,
+		MountPath: fmt.Sprintf("/etc/%s-config", i.proxyType),
+		ReadOnly:  true,
+	})
+	container.ReadinessProbe = &corev1.Probe{
+		ProbeHandler: corev1.ProbeHandler{
+			TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromInt32(i.port)},
+		},
+		PeriodSeconds: 10,
+	}
+
+	replicas := i.spec.Replicas
+	resources.Deployments = append(resources.Deployments, &appsv1.Deployment{
+		ObjectMeta: meta(),
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: podLabels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      podLabels,
+					Annotations: map[string]string{configChecksumAnnotation: checksum(i.config, i.users)},
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{container},
+					Volumes: []corev1.Volume{{
+						Name: configVolume,
+						VolumeSource: corev1.VolumeSource{
+							ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: name}},
+						},
+					}},
+				},
+			},
+		},
+	})
+
+	service := &corev1.Service{
+		ObjectMeta: meta(),
+		Spec: corev1.ServiceSpec{
+			Type:     corev1.ServiceTypeClusterIP,
+			Selector: podLabels,
+			Ports: []corev1.ServicePort{{
+				Name:       "db",
+				Port:       i.servicePort,
+				TargetPort: intstr.FromString("db"),
+				Protocol:   corev1.ProtocolTCP,
+			}},
+		},
+	}
+	if expose := i.spec.Expose; expose != nil {
+		if expose.Type != "" {
+			service.Spec.Type = expose.Type
+		}
+		service.Annotations = expose.Annotations
+		service.Spec.LoadBalancerSourceRanges = expose.LoadBalancerSourceRanges
+	}
+	resources.Services = append(resources.Services, service)
+}
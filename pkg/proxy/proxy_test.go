@@ -0,0 +1,311 @@
+package proxy
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	dbaasv1 "github.com/huynt0812/dbaas-operator/api/v1"
+)
+
+// testBackend returns a MySQL cluster with a primary and two replicas
+func testBackend() Backend {
+	return Backend{
+		Cluster: &dbaasv1.DatabaseCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "prod"},
+			Spec: dbaasv1.DatabaseClusterSpec{
+				Engine: dbaasv1.EngineSpec{Type: "mysql", Version: "8.0"},
+			},
+		},
+		Primary:  "db-primary",
+		Replicas: []string{"db-replica-0", "db-replica-1"},
+		Port:     3306,
+		Users: []User{
+			{SecretName: "db-app", UsernameKey: "username", PasswordKey: "password", Version: "1"},
+		},
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		engine  string
+		spec    *dbaasv1.ProxySpec
+		wantErr string
+	}{
+		{
+			name:   "disabled",
+			engine: "kafka",
+			spec:   &dbaasv1.ProxySpec{Type: "haproxy"},
+		},
+		{
+			name:   "default type",
+			engine: "mysql",
+			spec:   &dbaasv1.ProxySpec{Enabled: true},
+		},
+		{
+			name:   "proxysql with query rules",
+			engine: "mysql",
+			spec: &dbaasv1.ProxySpec{Enabled: true, Type: "proxysql", QueryRules: []dbaasv1.ProxyQueryRule{
+				{Pattern: "^SELECT .* FROM reports", Destination: "replica"},
+			}},
+		},
+		{
+			name:    "engine without proxies",
+			engine:  "kafka",
+			spec:    &dbaasv1.ProxySpec{Enabled: true},
+			wantErr: "does not support proxies",
+		},
+		{
+			name:    "type of another engine",
+			engine:  "mysql",
+			spec:    &dbaasv1.ProxySpec{Enabled: true, Type: "pgbouncer"},
+			wantErr: "use one of: haproxy, proxysql",
+		},
+		{
+			name:    "pool mode",
+			engine:  "mysql",
+			spec:    &dbaasv1.ProxySpec{Enabled: true, ProxyInstanceSpec: dbaasv1.ProxyInstanceSpec{PoolMode: "transaction"}},
+			wantErr: "pool mode is only supported by pgbouncer",
+		},
+		{
+			name:    "pool mode of the read-only instance",
+			engine:  "mysql",
+			spec:    &dbaasv1.ProxySpec{Enabled: true, ReadOnly: &dbaasv1.ProxyInstanceSpec{PoolMode: "session"}},
+			wantErr: "pool mode is only supported by pgbouncer",
+		},
+		{
+			name:    "proxysql read-only instance",
+			engine:  "mysql",
+			spec:    &dbaasv1.ProxySpec{Enabled: true, Type: "proxysql", ReadOnly: &dbaasv1.ProxyInstanceSpec{}},
+			wantErr: "no read-only instance",
+		},
+		{
+			name:   "haproxy query rules",
+			engine: "mysql",
+			spec: &dbaasv1.ProxySpec{Enabled: true, Type: "haproxy", QueryRules: []dbaasv1.ProxyQueryRule{
+				{Pattern: "^SELECT", Destination: "replica"},
+			}},
+			wantErr: "query rules are only supported by proxysql",
+		},
+		{
+			name:   "invalid pattern",
+			engine: "mysql",
+			spec: &dbaasv1.ProxySpec{Enabled: true, Type: "proxysql", QueryRules: []dbaasv1.ProxyQueryRule{
+				{Pattern: "^SELECT (", Destination: "replica"},
+			}},
+			wantErr: "invalid query rule pattern",
+		},
+		{
+			name:   "invalid destination",
+			engine: "mysql",
+			spec: &dbaasv1.ProxySpec{Enabled: true, Type: "proxysql", QueryRules: []dbaasv1.ProxyQueryRule{
+				{Pattern: "^SELECT", Destination: "standby"},
+			}},
+			wantErr: "invalid query rule destination",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.engine, tt.spec)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Validate() error = %v, want an error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Validate() unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestBuildErrors(t *testing.T) {
+	noPrimary := testBackend()
+	noPrimary.Primary = ""
+	noUsers := testBackend()
+	noUsers.Users = nil
+	postgres := testBackend()
+	postgres.Cluster = postgres.Cluster.DeepCopy()
+	postgres.Cluster.Spec.Engine.Type = "postgresql"
+
+	tests := []struct {
+		name    string
+		spec    *dbaasv1.ProxySpec
+		backend Backend
+		wantErr string
+	}{
+		{
+			name:    "invalid spec",
+			spec:    &dbaasv1.ProxySpec{Enabled: true, Type: "pgbouncer"},
+			backend: testBackend(),
+			wantErr: "is not supported for engine mysql",
+		},
+		{
+			name:    "no primary",
+			spec:    &dbaasv1.ProxySpec{Enabled: true},
+			backend: noPrimary,
+			wantErr: "has no primary",
+		},
+		{
+			name:    "managed by the engine operator",
+			spec:    &dbaasv1.ProxySpec{Enabled: true},
+			backend: postgres,
+			wantErr: "managed by the engine operator",
+		},
+		{
+			name:    "proxysql without users",
+			spec:    &dbaasv1.ProxySpec{Enabled: true, Type: "proxysql"},
+			backend: noUsers,
+			wantErr: "needs the database users",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Build(tt.spec, tt.backend)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Build() error = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBuildHAProxy(t *testing.T) {
+	spec := &dbaasv1.ProxySpec{
+		Enabled: true,
+		ProxyInstanceSpec: dbaasv1.ProxyInstanceSpec{
+			Replicas: 2,
+			Expose: &dbaasv1.ExposeSpec{
+				Type:                     corev1.ServiceTypeLoadBalancer,
+				Annotations:              map[string]string{"service.beta.kubernetes.io/aws-load-balancer-internal": "true"},
+				LoadBalancerSourceRanges: []string{"10.0.0.0/8"},
+			},
+		},
+		ReadOnly: &dbaasv1.ProxyInstanceSpec{Replicas: 1},
+	}
+	resources, err := Build(spec, testBackend())
+	if err != nil {
+		t.Fatalf("Build() unexpected error: %v", err)
+	}
+	if len(resources.ConfigMaps) != 2 || len(resources.Deployments) != 2 || len(resources.Services) != 2 {
+		t.Fatalf("Build() = %d ConfigMaps, %d Deployments, %d Services, want 2 of each",
+			len(resources.ConfigMaps), len(resources.Deployments), len(resources.Services))
+	}
+
+	for i, want := range []struct {
+		name     string
+		role     string
+		replicas int32
+		service  corev1.ServiceType
+	}{
+		{"db-haproxy-rw", RoleReadWrite, 2, corev1.ServiceTypeLoadBalancer},
+		{"db-haproxy-ro", RoleReadOnly, 1, corev1.ServiceTypeClusterIP},
+	} {
+		configMap, deployment, service := resources.ConfigMaps[i], resources.Deployments[i], resources.Services[i]
+		if configMap.Name != want.name || deployment.Name != want.name || service.Name != want.name {
+			t.Errorf("objects %d are named %s, %s and %s, want %s", i, configMap.Name, deployment.Name, service.Name, want.name)
+		}
+		if configMap.Namespace != "prod" {
+			t.Errorf("ConfigMap %s is in namespace %q, want prod", configMap.Name, configMap.Namespace)
+		}
+		if _, ok := configMap.Data[haproxyConfigKey]; !ok {
+			t.Errorf("ConfigMap %s has no %s key", configMap.Name, haproxyConfigKey)
+		}
+		if got := deployment.Spec.Template.Labels["dbaas.io/proxy-role"]; got != want.role {
+			t.Errorf("Deployment %s pods have role %q, want %q", deployment.Name, got, want.role)
+		}
+		if got := *deployment.Spec.Replicas; got != want.replicas {
+			t.Errorf("Deployment %s has %d replicas, want %d", deployment.Name, got, want.replicas)
+		}
+		container := deployment.Spec.Template.Spec.Containers[0]
+		if got := strings.Join(container.Args, " "); got != "-f /etc/haproxy-config/haproxy.cfg" {
+			t.Errorf("Deployment %s runs haproxy with %q", deployment.Name, got)
+		}
+		if got := container.Ports[0].ContainerPort; got != 3306 {
+			t.Errorf("Deployment %s listens on %d, want 3306", deployment.Name, got)
+		}
+		if deployment.Spec.Template.Annotations[configChecksumAnnotation] == "" {
+			t.Errorf("Deployment %s has no config checksum", deployment.Name)
+		}
+		if service.Spec.Type != want.service {
+			t.Errorf("Service %s has type %s, want %s", service.Name, service.Spec.Type, want.service)
+		}
+		if got := service.Spec.Ports[0].Port; got != 3306 {
+			t.Errorf("Service %s exposes %d, want 3306", service.Name, got)
+		}
+	}
+
+	if got := resources.Services[0].Spec.LoadBalancerSourceRanges; len(got) != 1 || got[0] != "10.0.0.0/8" {
+		t.Errorf("read-write Service source ranges = %v, want [10.0.0.0/8]", got)
+	}
+	if resources.Services[1].Annotations != nil {
+		t.Errorf("read-only Service annotations = %v, want none", resources.Services[1].Annotations)
+	}
+}
+
+func TestBuildProxySQL(t *testing.T) {
+	spec := &dbaasv1.ProxySpec{Enabled: true, Type: "proxysql", ProxyInstanceSpec: dbaasv1.ProxyInstanceSpec{Replicas: 2}}
+	backend := testBackend()
+	backend.Users = append(backend.Users, User{SecretName: "db-reporting", UsernameKey: "user", PasswordKey: "pass", Version: "7"})
+
+	resources, err := Build(spec, backend)
+	if err != nil {
+		t.Fatalf("Build() unexpected error: %v", err)
+	}
+	if len(resources.ConfigMaps) != 1 || len(resources.Deployments) != 1 || len(resources.Services) != 1 {
+		t.Fatalf("Build() = %d ConfigMaps, %d Deployments, %d Services, want 1 of each",
+			len(resources.ConfigMaps), len(resources.Deployments), len(resources.Services))
+	}
+	deployment, service := resources.Deployments[0], resources.Services[0]
+	if deployment.Name != "db-proxysql-rw" {
+		t.Errorf("Deployment is named %s, want db-proxysql-rw", deployment.Name)
+	}
+
+	// ProxySQL listens on its own port behind the port of the database
+	container := deployment.Spec.Template.Spec.Containers[0]
+	if got := container.Ports[0].ContainerPort; got != proxysqlPort {
+		t.Errorf("container listens on %d, want %d", got, proxysqlPort)
+	}
+	if got := service.Spec.Ports[0].Port; got != 3306 {
+		t.Errorf("Service exposes %d, want 3306", got)
+	}
+	if !strings.Contains(container.Command[2], "default_hostgroup=10") {
+		t.Errorf("startup script does not send the users to the writer hostgroup:\n%s", container.Command[2])
+	}
+
+	env := make(map[string]corev1.EnvVar, len(container.Env))
+	for _, e := range container.Env {
+		env[e.Name] = e
+	}
+	if got := env["PROXY_USER_COUNT"].Value; got != "2" {
+		t.Errorf("PROXY_USER_COUNT = %q, want 2", got)
+	}
+	for name, want := range map[string]corev1.SecretKeySelector{
+		"PROXY_USER_0":     {LocalObjectReference: corev1.LocalObjectReference{Name: "db-app"}, Key: "username"},
+		"PROXY_PASSWORD_0": {LocalObjectReference: corev1.LocalObjectReference{Name: "db-app"}, Key: "password"},
+		"PROXY_USER_1":     {LocalObjectReference: corev1.LocalObjectReference{Name: "db-reporting"}, Key: "user"},
+		"PROXY_PASSWORD_1": {LocalObjectReference: corev1.LocalObjectReference{Name: "db-reporting"}, Key: "pass"},
+	} {
+		e, ok := env[name]
+		if !ok || e.ValueFrom == nil || e.ValueFrom.SecretKeyRef == nil || *e.ValueFrom.SecretKeyRef != want {
+			t.Errorf("%s does not read key %s of secret %s", name, want.Key, want.Name)
+		}
+	}
+
+	// A new version of a user secret rolls the pods out
+	backend.Users[1].Version = "8"
+	rotated, err := Build(spec, backend)
+	if err != nil {
+		t.Fatalf("Build() unexpected error: %v", err)
+	}
+	before := deployment.Spec.Template.Annotations[configChecksumAnnotation]
+	after := rotated.Deployments[0].Spec.Template.Annotations[configChecksumAnnotation]
+	if before == after {
+		t.Errorf("config checksum %s did not change with the secret version", before)
+	}
+}
@@ -0,0 +1,183 @@
+package proxy
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	dbaasv1 "github.com/huynt0812/dbaas-operator/api/v1"
+)
+
+const (
+	// proxysqlImage is the ProxySQL image of the proxy instances
+	proxysqlImage = "proxysql/proxysql:2.6.2"
+
+	// proxysqlConfigKey is the key of the configuration in its ConfigMap
+	proxysqlConfigKey = "proxysql.cnf"
+
+	// proxysqlPort is the port of the MySQL interface of ProxySQL
+	proxysqlPort = 6033
+
+	// writerHostgroup and readerHostgroup hold the primary and the replicas
+	writerHostgroup = 10
+	readerHostgroup = 20
+)
+
+// proxysqlScript appends the users, read from the environment, to the configuration and starts
+// ProxySQL from it. The users stay out of the ConfigMap and are synced on every start, which the
+// checksum of the secrets triggers when they change.
+const proxysqlScript = `set -e
+esc() { printf '%s' "$1" | sed 's/[\\"]/\\&/g'; }
+{
+  cat /etc/proxysql-config/proxysql.cnf
+  echo 'mysql_users='
+  echo '('
+  i=0
+  sep=' '
+  while [ "$i" -lt "$PROXY_USER_COUNT" ]; do
+    eval "user=\$PROXY_USER_$i password=\$PROXY_PASSWORD_$i"
+    printf '  %s{ username="%s", password="%s", default_hostgroup={{writer}}, transaction_persistent=1 }\n' "$sep" "$(esc "$user")" "$(esc "$password")"
+    sep=','
+    i=$((i+1))
+  done
+  echo ')'
+} > /tmp/proxysql.cnf
+exec proxysql -f --initial -c /tmp/proxysql.cnf`
+
+// buildProxySQL renders the ProxySQL instance of the proxy spec, which sends the writes to the
+// primary and the reads to the replicas through its query rules
+func buildProxySQL(spec *dbaasv1.ProxySpec, backend Backend) (*Resources, error) {
+	if len(backend.Users) == 0 {
+		return nil, fmt.Errorf("proxysql needs the database users to authenticate the clients")
+	}
+
+	env := []corev1.EnvVar{{Name: "PROXY_USER_COUNT", Value: strconv.Itoa(len(backend.Users))}}
+	for i, user := range backend.Users {
+		secret := corev1.LocalObjectReference{Name: user.SecretName}
+		env = append(env,
+			corev1.EnvVar{
+				Name: fmt.Sprintf("PROXY_USER_%d", i),
+				ValueFrom: &corev1.EnvVarSource{
+					SecretKeyRef: &corev1.SecretKeySelector{LocalObjectReference: secret, Key: user.UsernameKey},
+				},
+			},
+			corev1.EnvVar{
+				Name: fmt.Sprintf("PROXY_PASSWORD_%d", i),
+				ValueFrom: &corev1.EnvVarSource{
+					SecretKeyRef: &corev1.SecretKeySelector{LocalObjectReference: secret, Key: user.PasswordKey},
+				},
+			},
+		)
+	}
+	script := strings.ReplaceAll(proxysqlScript, "{{writer}}", strconv.Itoa(writerHostgroup))
+
+	resources := &Resources{}
+	instance{
+		cluster:   backend.Cluster,
+		proxyType: TypeProxySQL,
+		role:      RoleReadWrite,
+		spec:      &spec.ProxyInstanceSpec,
+		configKey: proxysqlConfigKey,
+		config:    proxysqlConfig(spec, backend),
+		users:     backend.Users,
+		container: corev1.Container{
+			Name:    "proxysql",
+			Image:   proxysqlImage,
+			Command: []string{"sh", "-c", script},
+			Env:     env,
+		},
+		port:        proxysqlPort,
+		servicePort: backend.Port,
+	}.build(resources)
+	return resources, nil
+}
+
+// proxysqlConfig renders the configuration of ProxySQL without its users. The admin interface
+// only listens on the loopback of the pod. The parameters of the spec override mysql_variables.
+func proxysqlConfig(spec *dbaasv1.ProxySpec, backend Backend) string {
+	variables := map[string]string{
+		"threads":         "2",
+		"interfaces":      fmt.Sprintf("0.0.0.0:%d", proxysqlPort),
+		"max_connections": "2048",
+		// The primary and replica hosts follow the topology, ProxySQL need not discover it
+		"monitor_enabled": "false",
+	}
+	if spec.MaxClientConnections != nil {
+		variables["max_connections"] = strconv.Itoa(int(*spec.MaxClientConnections))
+	}
+	for k, v := range spec.Parameters {
+		variables[k] = v
+	}
+	serverConnections := 1000
+	if spec.DefaultPoolSize != nil {
+		serverConnections = int(*spec.DefaultPoolSize)
+	}
+
+	var b strings.Builder
+	b.WriteString("datadir=\"/var/lib/proxysql\"\n\n")
+	b.WriteString("admin_variables=\n{\n")
+	b.WriteString("  mysql_ifaces=\"127.0.0.1:6032\"\n")
+	b.WriteString("}\n\n")
+
+	b.WriteString("mysql_variables=\n{\n")
+	for _, k := range sortedKeys(variables) {
+		fmt.Fprintf(&b, "  %s=%s\n", k, libconfigValue(variables[k]))
+	}
+	b.WriteString("}\n\n")
+
+	servers := []string{fmt.Sprintf("{ address=%s, port=%d, hostgroup=%d, max_connections=%d }",
+		libconfigValue(backend.Primary), backend.Port, writerHostgroup, serverConnections)}
+	for _, replica := range backend.Replicas {
+		servers = append(servers, fmt.Sprintf("{ address=%s, port=%d, hostgroup=%d, max_connections=%d }",
+			libconfigValue(replica), backend.Port, readerHostgroup, serverConnections))
+	}
+	writeList(&b, "mysql_servers", servers)
+	b.WriteString("\n")
+
+	// The rules of the spec come first, then the reads go to the replicas when there are some
+	rules := append([]dbaasv1.ProxyQueryRule{}, spec.QueryRules...)
+	if len(backend.Replicas) > 0 {
+		rules = append(rules,
+			dbaasv1.ProxyQueryRule{Pattern: `^SELECT.*FOR (UPDATE|SHARE)`, Destination: "primary"},
+			dbaasv1.ProxyQueryRule{Pattern: `^SELECT`, Destination: "replica"},
+		)
+	}
+	var entries []string
+	for i, rule := range rules {
+		hostgroup := writerHostgroup
+		if rule.Destination == "replica" && len(backend.Replicas) > 0 {
+			hostgroup = readerHostgroup
+		}
+		entries = append(entries, fmt.Sprintf("{ rule_id=%d, active=1, match_pattern=%s, destination_hostgroup=%d, apply=1 }",
+			i+1, libconfigValue(rule.Pattern), hostgroup))
+	}
+	writeList(&b, "mysql_query_rules", entries)
+	b.WriteString("\n")
+	return b.String()
+}
+
+// writeList writes a libconfig list setting
+func writeList(b *strings.Builder, name string, entries []string) {
+	fmt.Fprintf(b, "%s=\n(\n", name)
+	for i, entry := range entries {
+		sep := ","
+		if i == len(entries)-1 {
+			sep = ""
+		}
+		fmt.Fprintf(b, "  %s%s\n", entry, sep)
+	}
+	b.WriteString(")\n")
+}
+
+// libconfigValue renders a setting value, quoting everything but integers and booleans
+func libconfigValue(value string) string {
+	if _, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return value
+	}
+	if value == "true" || value == "false" {
+		return value
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
@@ -0,0 +1,164 @@
+package proxy
+
+import (
+	"testing"
+
+	dbaasv1 "github.com/huynt0812/dbaas-operator/api/v1"
+)
+
+func TestProxySQLConfig(t *testing.T) {
+	maxConn, poolSize := int32(100), int32(200)
+
+	tests := []struct {
+		name     string
+		spec     *dbaasv1.ProxySpec
+		replicas []string
+		want     string
+	}{
+		{
+			name:     "reads to the replicas",
+			spec:     &dbaasv1.ProxySpec{},
+			replicas: []string{"db-replica-0", "db-replica-1"},
+			want: `datadir="/var/lib/proxysql"
+
+admin_variables=
+{
+  mysql_ifaces="127.0.0.1:6032"
+}
+
+mysql_variables=
+{
+  interfaces="0.0.0.0:6033"
+  max_connections=2048
+  monitor_enabled=false
+  threads=2
+}
+
+mysql_servers=
+(
+  { address="db-primary", port=3306, hostgroup=10, max_connections=1000 },
+  { address="db-replica-0", port=3306, hostgroup=20, max_connections=1000 },
+  { address="db-replica-1", port=3306, hostgroup=20, max_connections=1000 }
+)
+
+mysql_query_rules=
+(
+  { rule_id=1, active=1, match_pattern="^SELECT.*FOR (UPDATE|SHARE)", destination_hostgroup=10, apply=1 },
+  { rule_id=2, active=1, match_pattern="^SELECT", destination_hostgroup=20, apply=1 }
+)
+
+`,
+		},
+		{
+			name: "rules, limits and parameters",
+			spec: &dbaasv1.ProxySpec{
+				ProxyInstanceSpec: dbaasv1.ProxyInstanceSpec{
+					MaxClientConnections: &maxConn,
+					DefaultPoolSize:      &poolSize,
+					Parameters:           map[string]string{"threads": "4", "server_version": "8.0.36"},
+				},
+				QueryRules: []dbaasv1.ProxyQueryRule{
+					{Pattern: `^SELECT .* FROM "reports"`, Destination: "replica"},
+					{Pattern: `^SELECT .* FROM orders`, Destination: "primary"},
+				},
+			},
+			replicas: []string{"db-replica-0"},
+			want: `datadir="/var/lib/proxysql"
+
+admin_variables=
+{
+  mysql_ifaces="127.0.0.1:6032"
+}
+
+mysql_variables=
+{
+  interfaces="0.0.0.0:6033"
+  max_connections=100
+  monitor_enabled=false
+  server_version="8.0.36"
+  threads=4
+}
+
+mysql_servers=
+(
+  { address="db-primary", port=3306, hostgroup=10, max_connections=200 },
+  { address="db-replica-0", port=3306, hostgroup=20, max_connections=200 }
+)
+
+mysql_query_rules=
+(
+  { rule_id=1, active=1, match_pattern="^SELECT .* FROM \"reports\"", destination_hostgroup=20, apply=1 },
+  { rule_id=2, active=1, match_pattern="^SELECT .* FROM orders", destination_hostgroup=10, apply=1 },
+  { rule_id=3, active=1, match_pattern="^SELECT.*FOR (UPDATE|SHARE)", destination_hostgroup=10, apply=1 },
+  { rule_id=4, active=1, match_pattern="^SELECT", destination_hostgroup=20, apply=1 }
+)
+
+`,
+		},
+		{
+			name: "everything to the primary without replicas",
+			spec: &dbaasv1.ProxySpec{
+				QueryRules: []dbaasv1.ProxyQueryRule{
+					{Pattern: `^SELECT .* FROM reports`, Destination: "replica"},
+				},
+			},
+			want: `datadir="/var/lib/proxysql"
+
+admin_variables=
+{
+  mysql_ifaces="127.0.0.1:6032"
+}
+
+mysql_variables=
+{
+  interfaces="0.0.0.0:6033"
+  max_connections=2048
+  monitor_enabled=false
+  threads=2
+}
+
+mysql_servers=
+(
+  { address="db-primary", port=3306, hostgroup=10, max_connections=1000 }
+)
+
+mysql_query_rules=
+(
+  { rule_id=1, active=1, match_pattern="^SELECT .* FROM reports", destination_hostgroup=10, apply=1 }
+)
+
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := testBackend()
+			backend.Replicas = tt.replicas
+			if got := proxysqlConfig(tt.spec, backend); got != tt.want {
+				t.Errorf("proxysqlConfig() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLibconfigValue(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"2048", "2048"},
+		{"-1", "-1"},
+		{"true", "true"},
+		{"false", "false"},
+		{"0.0.0.0:6033", `"0.0.0.0:6033"`},
+		{`say "hi"`, `"say \"hi\""`},
+		{`C:\path`, `"C:\\path"`},
+		{"", `""`},
+	}
+	for _, tt := range tests {
+		if got := libconfigValue(tt.value); got != tt.want {
+			t.Errorf("libconfigValue(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}